@@ -0,0 +1,30 @@
+// Package api содержит OpenAPI спецификацию HTTP API фабрики.
+// Спецификация встраивается в бинарник и служит источником для клиента pkg/factoryclient.
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec - OpenAPI 3 спецификация в формате YAML
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+// SpecJSON возвращает спецификацию в формате JSON
+func SpecJSON() ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(Spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert OpenAPI spec to JSON: %w", err)
+	}
+	return data, nil
+}
@@ -1,56 +1,244 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"factory/internal/app"
+	"factory/internal/cli"
 	"factory/internal/config"
 	"factory/internal/database"
+	"factory/internal/grpcserver"
 	"factory/internal/handlers"
 	"factory/internal/keycloak"
 	"factory/internal/logger"
+	"factory/internal/metrics"
 	"factory/internal/services"
+	"factory/internal/session"
+	"factory/internal/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"gorm.io/gorm"
 )
 
 func main() {
-	// Инициализируем структурированное логирование
-	log := logger.Init()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to YAML config file")
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
 
 	// Загружаем переменные окружения
-	if err := godotenv.Load(); err != nil {
+	envErr := godotenv.Load()
+
+	// Инициализируем конфигурацию: запуск с неверными настройками невозможен
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		logger.For("main").WithError(err).Fatal("Invalid configuration")
+	}
+
+	// config print выводит действующие настройки со скрытыми секретами
+	if len(args) == 2 && args[0] == "config" && args[1] == "print" {
+		if err := cfg.Print(os.Stdout); err != nil {
+			logger.For("main").WithError(err).Fatal("Failed to print configuration")
+		}
+		return
+	}
+
+	serving := len(args) == 0 || (len(args) == 1 && args[0] == "serve")
+	if !serving && !(len(args) == 1 && args[0] == "migrate") && !cli.Has(args) {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", strings.Join(args, " "))
+		usage()
+		os.Exit(2)
+	}
+
+	// Все пакеты пишут логи через общий логгер в одном формате. У служебных
+	// команд логи идут в stderr, чтобы не смешиваться с их выводом.
+	logConfig := logger.Config{
+		Format: cfg.LogFormat,
+		Level:  cfg.LogLevel,
+		Levels: cfg.LogLevels,
+	}
+	if !serving {
+		logConfig.Output = os.Stderr
+	}
+	rootLogger, err := logger.New(logConfig)
+	if err != nil {
+		logger.For("main").WithError(err).Fatal("Invalid logging configuration")
+	}
+	logger.SetDefault(rootLogger)
+	log := logger.For("main")
+
+	if envErr != nil {
 		log.Info("No .env file found")
 	}
 
-	// Инициализируем конфигурацию
-	cfg := config.New()
+	switch {
+	case serving:
+		serve(cfg, *configPath, rootLogger)
+	case args[0] == "migrate":
+		migrate(cfg)
+	default:
+		os.Exit(runCommand(cfg, args))
+	}
+}
 
-	// Настраиваем Gin для JSON логирования
-	if os.Getenv("GIN_MODE") == "release" {
-		gin.SetMode(gin.ReleaseMode)
+// usage выводит список команд фабрики
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [-config file] [command]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  serve\n    \trun the factory server (default)")
+	fmt.Fprintln(out, "  migrate\n    \tcreate missing database tables and columns")
+	fmt.Fprintln(out, "  config print\n    \tprint the effective configuration with secrets hidden")
+	cli.Usage(out)
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// migrate применяет миграции базы данных и завершается
+func migrate(cfg *config.Config) {
+	log := logger.For("main")
+	db, err := database.Initialize(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to migrate database")
 	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// runCommand выполняет служебную команду на тех же настройках и сервисах, что и
+// сервер, и возвращает код завершения: 2 - неверный вызов, 1 - ошибка команды
+func runCommand(cfg *config.Config, args []string) int {
+	log := logger.For("main")
+
+	// Миграции применяет serve или migrate: служебные команды схему не меняют
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.WithError(err).Error("Failed to connect to database")
+		return 1
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	// События об удалении плюмбусов публикуются так же, как из сервера
+	eventsService, err := services.NewEventsService(cfg)
+	if err != nil {
+		log.WithError(err).Warn("Failed to initialize NATS events service")
+		eventsService = nil
+	}
+	if eventsService != nil {
+		defer eventsService.Close()
+	}
+
+	userService := services.NewUserService(db)
+	signatureService := services.NewSignatureService(cfg)
+	webhookService := services.NewWebhookService(db)
+	webhookService.AllowedHosts = services.SplitHosts(cfg.WebhookAllowedHosts)
+	generationService := services.NewGenerationService(userService, services.NewPlumbusService(cfg), signatureService, eventsService, webhookService)
+	generationService.MaxAttempts = cfg.PlumbusMaxAttempts
+	generationService.StuckAfter = cfg.PlumbusStuckAfter
+	imageGC := services.NewImageGC(userService, services.ImageDir)
+	imageGC.Grace = cfg.ImageGCGrace
+	deletionService := services.NewDeletionService(userService, eventsService, cfg.PlumbusRestoreWindow)
+	deletionService.Generation = generationService
+
+	env := &cli.Env{
+		Config:     cfg,
+		Users:      userService,
+		Generation: generationService,
+		Deletion:   deletionService,
+		Signature:  signatureService,
+		Images:     imageGC,
+		Out:        os.Stdout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = cli.Run(ctx, env, args)
+	switch {
+	case errors.Is(err, cli.ErrUsage):
+		fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		usage()
+		return 2
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// serve запускает HTTP и gRPC серверы фабрики и фоновые задачи до сигнала остановки
+func serve(cfg *config.Config, configPath string, rootLogger *logger.Logger) {
+	log := logger.For("main")
+
+	// Настраиваем Gin для JSON логирования
+	gin.SetMode(cfg.Mode)
 
 	// Используем logrus writer для Gin
 	gin.DefaultWriter = io.MultiWriter(os.Stdout)
 	gin.DefaultErrorWriter = io.MultiWriter(os.Stdout)
 
+	// Инициализируем трассировку до клиентов, чьи запросы в нее попадают
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize tracing")
+	}
+
 	// Инициализируем базу данных
 	db, err := database.Initialize(cfg)
 	if err != nil {
 		log.WithError(err).Fatal("Failed to initialize database")
 	}
 
+	// Статистика пула соединений в /metrics
+	if sqlDB, err := db.DB(); err == nil {
+		if err := metrics.RegisterDB(sqlDB); err != nil {
+			log.WithError(err).Warn("Failed to register database metrics")
+		}
+	}
+
 	// Инициализируем Keycloak клиент
 	kcClient := keycloak.NewClient(cfg)
 
+	// Инициализируем серверные сессии
+	// В release режиме фабрика с этим значением не запускается
+	if cfg.UsesDefaultSessionSecret() {
+		log.Warn("SESSION_SECRET is not set, using insecure default")
+	}
+	sessionStore, err := session.NewStore(cfg.SessionStore, db)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to initialize session store")
+	}
+	sessionManager := session.NewManager(sessionStore, cfg.SessionSecret, kcClient)
+	sessionManager.BaseURL = cfg.BaseURL
+
 	// Инициализируем сервисы
 	plumbusService := services.NewPlumbusService(cfg)
 	userService := services.NewUserService(db)
+	userService.Rarity().SetChance(cfg.RareChance)
 	signatureService := services.NewSignatureService(cfg)
+	tokenService := services.NewTokenService(db)
+	webhookService := services.NewWebhookService(db)
+	webhookService.AllowedHosts = services.SplitHosts(cfg.WebhookAllowedHosts)
 
 	// Инициализируем сервис событий NATS
 	eventsService, err := services.NewEventsService(cfg)
@@ -59,54 +247,143 @@ func main() {
 		eventsService = nil // Продолжаем работу без NATS
 	}
 
-	// Закрываем соединение с NATS при завершении
-	if eventsService != nil {
-		defer eventsService.Close()
-	}
-
 	// Настраиваем роутер
 	router := gin.New()
 
+	// ID запроса ставится первым: его видят трейсы, логи, метрики, восстановление после паники и статика
+	router.Use(handlers.RequestID())
+
 	// Добавляем middleware для JSON логирования
-	router.Use(ginJSONLogger(log))
+	router.Use(otelgin.Middleware(cfg.OTelServiceName))
+	router.Use(ginJSONLogger(logger.For("http")))
+	router.Use(metrics.Middleware())
 	router.Use(gin.Recovery())
 
 	// Статические файлы (CSS, JS, изображения)
 	router.Static("/static", "./web/static")
 	router.LoadHTMLGlob("web/templates/*")
 
+	// Удаленные плюмбусы можно восстановить в течение окна, затем они очищаются вместе с изображением
+	deletionService := services.NewDeletionService(userService, eventsService, cfg.PlumbusRestoreWindow)
+
+	// Генерация плюмбусов общая для HTTP и gRPC
+	generationService := services.NewGenerationService(userService, plumbusService, signatureService, eventsService, webhookService)
+	generationService.MaxAttempts = cfg.PlumbusMaxAttempts
+	generationService.RateLimiter = services.NewRateLimiter(cfg.GenerationRateLimit)
+	generationService.StuckAfter = cfg.PlumbusStuckAfter
+	deletionService.Generation = generationService
+
+	// Сверка каталога изображений с плюмбусами: файлы-сироты и пропавшие файлы
+	imageGC := services.NewImageGC(userService, services.ImageDir)
+	imageGC.Grace = cfg.ImageGCGrace
+
+	// Проверки зависимостей для /readyz
+	healthService := newHealthService(cfg, db, eventsService, kcClient, plumbusService, signatureService)
+
 	// Инициализируем обработчики
-	h := handlers.NewHandler(plumbusService, userService, signatureService, eventsService, kcClient)
+	h := handlers.NewHandler(userService, generationService, deletionService, tokenService, webhookService, healthService, kcClient, sessionManager)
+
+	// Часть настроек применяется без перезапуска: по SIGHUP, при изменении файла
+	// настроек и по запросу администратора
+	reloader := config.NewReloader(configPath, cfg)
+	reloader.Subscribe("logger", func(c *config.Config) {
+		if err := rootLogger.SetLevels(c.LogLevel, c.LogLevels); err != nil {
+			log.WithError(err).Warn("Failed to apply reloaded log levels")
+		}
+	})
+	reloader.Subscribe("rarity", func(c *config.Config) {
+		userService.Rarity().SetChance(c.RareChance)
+	})
+	reloader.Subscribe("rate_limiter", func(c *config.Config) {
+		generationService.RateLimiter.SetLimit(c.GenerationRateLimit)
+	})
+	reloader.Subscribe("timeouts", func(c *config.Config) {
+		plumbusService.SetTimeout(c.GeneratorTimeout)
+		signatureService.SetTimeout(c.SigStoreTimeout)
+	})
+	h.ConfigReloader = reloader
 
 	// Маршруты
-	router.GET("/health", func(c *gin.Context) {
-		c.String(200, "OK")
+	h.RegisterRoutes(router)
+
+	// gRPC сервер работает рядом с HTTP
+	grpcListener, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.GRPCPort))
+	if err != nil {
+		log.WithError(err).Fatal("Failed to listen for gRPC")
+	}
+	grpcServer := grpcserver.New(userService, generationService, kcClient).NewGRPCServer()
+
+	httpListener, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.Port))
+	if err != nil {
+		log.WithError(err).Fatal("Failed to listen for HTTP")
+	}
+
+	factory := app.New(&http.Server{Handler: router}, httpListener, generationService, cfg.ShutdownTimeout)
+	factory.GRPCServer = grpcServer
+	factory.GRPCListener = grpcListener
+	factory.Events = eventsService
+	factory.DB = db
+
+	// Фоновые задачи останавливаются вместе с фабрикой, база закрывается после них
+	runErr := factory.Run(context.Background(), func(ctx context.Context) {
+		factory.Track(sessionManager.StartCleanup(ctx, 10*time.Minute, func(err error) {
+			log.WithError(err).Warn("Failed to clean up expired sessions")
+		}))
+		factory.Track(deletionService.StartPurger(ctx, 10*time.Minute, func(err error) {
+			log.WithError(err).Warn("Failed to purge deleted plumbuses")
+		}))
+		// Зависшие после падения экземпляра плюмбусы собирает одна реплика за раз
+		if cfg.ReaperInterval > 0 {
+			factory.Track(generationService.StartReaper(ctx, cfg.ReaperInterval, func(err error) {
+				log.WithError(err).Warn("Failed to reap stuck plumbuses")
+			}))
+		}
+		if cfg.ImageGCInterval > 0 {
+			factory.Track(imageGC.Start(ctx, cfg.ImageGCInterval, cfg.ImageGCDelete, func(err error) {
+				log.WithError(err).Warn("Failed to reconcile image directory")
+			}))
+		}
+		cfg.StartSecretRefresh(ctx, func(err error) {
+			log.WithError(err).Warn("Failed to refresh secrets")
+		})
+		reloader.Watch(ctx, cfg.ConfigWatchInterval)
 	})
-	router.GET("/", h.HomePage)
-	router.GET("/auth/login", h.Login)
-	router.GET("/auth/callback", h.AuthCallback)
-	router.GET("/auth/logout", h.Logout)
 
-	// Защищенные маршруты
-	protected := router.Group("/")
-	protected.Use(h.AuthMiddleware())
-	{
-		protected.GET("/dashboard", h.Dashboard)
-		protected.POST("/plumbus/generate", h.GeneratePlumbus)
-		protected.GET("/plumbus/status/:id", h.GetPlumbusStatus)
-		protected.GET("/plumbus/image/:id", h.GetPlumbusImage)
-		protected.GET("/plumbus/list", h.GetUserPlumbuses)
+	// Отправляем оставшиеся спаны, включая спаны остановки
+	tracingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		log.WithError(err).Warn("Failed to flush traces")
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	if runErr != nil {
+		log.WithError(runErr).Fatal("Failed to start server")
 	}
+}
 
-	log.WithField("port", port).Info("Starting server")
-	if err := router.Run(":" + port); err != nil {
-		log.WithError(err).Fatal("Failed to start server")
+// newHealthService регистрирует проверки зависимостей фабрики. Критичные
+// зависимости задаются через HEALTH_CRITICAL, остальные считаются необязательными.
+func newHealthService(cfg *config.Config, db *gorm.DB, events *services.EventsService, kc *keycloak.Client, plumbus *services.PlumbusService, signature *services.SignatureService) *services.HealthService {
+	log := logger.For("main")
+
+	critical, err := services.ParseCriticalDependencies(cfg.HealthCritical)
+	if err != nil {
+		log.WithError(err).WithField("value", cfg.HealthCritical).Fatal("Invalid HEALTH_CRITICAL")
 	}
+
+	health := services.NewHealthService(cfg.HealthCheckTimeout, cfg.HealthCacheTTL)
+	health.Register(services.DependencyDatabase, critical[services.DependencyDatabase], func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	health.Register(services.DependencyNATS, critical[services.DependencyNATS], events.Ping)
+	health.Register(services.DependencyKeycloak, critical[services.DependencyKeycloak], kc.Ping)
+	health.Register(services.DependencyGenerator, critical[services.DependencyGenerator], plumbus.Ping)
+	health.Register(services.DependencySigStore, critical[services.DependencySigStore], signature.Ping)
+	return health
 }
 
 // ginJSONLogger возвращает middleware для JSON логирования Gin запросов
@@ -123,7 +400,7 @@ func ginJSONLogger(logger *logrus.Logger) gin.HandlerFunc {
 		end := time.Now()
 		latency := end.Sub(start)
 
-		entry := logger.WithFields(logrus.Fields{
+		entry := logger.WithContext(c.Request.Context()).WithFields(logrus.Fields{
 			"status":     c.Writer.Status(),
 			"method":     c.Request.Method,
 			"path":       path,
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"factory/internal/logger"
+	"factory/internal/services"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+)
+
+// DefaultStreamGrace - сколько gRPC сервер по умолчанию ждет открытые стримы при остановке
+const DefaultStreamGrace = 2 * time.Second
+
+// App связывает HTTP и gRPC серверы фабрики с ресурсами, которые нужно
+// корректно освободить при остановке
+type App struct {
+	HTTPServer   *http.Server
+	HTTPListener net.Listener
+	GRPCServer   *grpc.Server
+	GRPCListener net.Listener
+
+	Generation *services.GenerationService
+	Events     *services.EventsService
+	DB         *gorm.DB
+
+	// ShutdownTimeout ограничивает время ожидания текущих запросов и генераций при остановке
+	ShutdownTimeout time.Duration
+	// StreamGrace - сколько gRPC сервер ждет завершения вызовов при остановке, прежде чем
+	// оборвать их. Watch стримы сами не завершаются и иначе держали бы остановку до дедлайна.
+	StreamGrace time.Duration
+
+	// background - фоновые задачи, которые должны завершиться до закрытия базы данных
+	background []<-chan struct{}
+
+	logger *logrus.Logger
+}
+
+// New создает приложение. gRPC сервер, события и база данных необязательны.
+func New(httpServer *http.Server, httpListener net.Listener, generation *services.GenerationService, shutdownTimeout time.Duration) *App {
+	return &App{
+		HTTPServer:      httpServer,
+		HTTPListener:    httpListener,
+		Generation:      generation,
+		ShutdownTimeout: shutdownTimeout,
+		StreamGrace:     DefaultStreamGrace,
+		logger:          logger.For("app"),
+	}
+}
+
+// Track регистрирует фоновую задачу, запущенную в onStart: остановка ждет закрытия done,
+// прежде чем закрыть NATS и базу данных
+func (a *App) Track(done <-chan struct{}) {
+	a.background = append(a.background, done)
+}
+
+// Run запускает серверы и продолжает прерванные генерации, затем ждет SIGINT/SIGTERM
+// или отмены ctx и останавливает фабрику. Фоновые задачи, запущенные с контекстом
+// из onStart, останавливаются вместе с приложением.
+func (a *App) Run(ctx context.Context, onStart func(ctx context.Context)) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 2)
+	go func() {
+		a.logger.WithField("addr", a.HTTPListener.Addr().String()).Info("Starting server")
+		if err := a.HTTPServer.Serve(a.HTTPListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+	if a.GRPCServer != nil {
+		go func() {
+			a.logger.WithField("addr", a.GRPCListener.Addr().String()).Info("Starting gRPC server")
+			if err := a.GRPCServer.Serve(a.GRPCListener); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	if resumed, err := a.Generation.Resume(); err != nil {
+		a.logger.WithError(err).Error("Failed to resume interrupted plumbus generations")
+	} else if resumed > 0 {
+		a.logger.WithField("count", resumed).Info("Resumed interrupted plumbus generations")
+	}
+
+	if onStart != nil {
+		onStart(ctx)
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info("Shutting down")
+	case runErr = <-errs:
+		a.logger.WithError(runErr).Error("Server failed, shutting down")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.ShutdownTimeout)
+	defer cancel()
+	a.shutdown(shutdownCtx)
+
+	return runErr
+}
+
+// shutdown останавливает фабрику: одновременно перестает принимать запросы, ждет
+// генерации и фоновые задачи, и только потом закрывает NATS и базу данных. Этапы
+// делят общий дедлайн, поэтому медленный этап не отнимает время у остальных.
+func (a *App) shutdown(ctx context.Context) {
+	var wg sync.WaitGroup
+	run := func(stage func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stage()
+		}()
+	}
+
+	run(func() {
+		if err := a.HTTPServer.Shutdown(ctx); err != nil {
+			a.logger.WithError(err).Warn("HTTP server did not shut down gracefully")
+		}
+	})
+	if a.GRPCServer != nil {
+		run(func() { a.stopGRPC(ctx) })
+	}
+	run(func() {
+		if err := a.Generation.Shutdown(ctx); err != nil {
+			a.logger.WithError(err).Warn("Plumbus generations were interrupted and will resume after restart")
+		}
+	})
+	run(func() { a.waitBackground(ctx) })
+	wg.Wait()
+
+	if a.Events != nil {
+		a.Events.Close()
+	}
+
+	if a.DB != nil {
+		if sqlDB, err := a.DB.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				a.logger.WithError(err).Warn("Failed to close database connection")
+			}
+		}
+	}
+
+	a.logger.Info("Shutdown complete")
+}
+
+// stopGRPC дает текущим вызовам StreamGrace на завершение, затем обрывает оставшиеся,
+// в том числе открытые Watch стримы
+func (a *App) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		a.GRPCServer.GracefulStop()
+		close(stopped)
+	}()
+
+	grace := time.NewTimer(a.StreamGrace)
+	defer grace.Stop()
+	select {
+	case <-stopped:
+		return
+	case <-grace.C:
+	case <-ctx.Done():
+	}
+	a.logger.Info("Closing remaining gRPC streams")
+	a.GRPCServer.Stop()
+	<-stopped
+}
+
+// waitBackground ждет остановки фоновых задач, зарегистрированных через Track
+func (a *App) waitBackground(ctx context.Context) {
+	for _, done := range a.background {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			a.logger.Warn("Background tasks did not stop before the shutdown deadline")
+			return
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/grpcserver"
+	"factory/internal/keycloak"
+	"factory/internal/models"
+	"factory/internal/services"
+	"factory/internal/testutils"
+	"factory/pkg/factorypb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+var testRequest = models.PlumbusRequest{
+	Name: "Shutdown", Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+}
+
+type testFactory struct {
+	users  *services.UserService
+	user   *models.User
+	newApp func(generatorURL string, timeout time.Duration) (*App, *services.GenerationService)
+}
+
+func setupFactory(t *testing.T) *testFactory {
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change working directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	db := testutils.SetupTestDB(t)
+	if err := db.AutoMigrate(&models.PlumbusAttempt{}); err != nil {
+		t.Fatalf("Failed to migrate plumbus attempts table: %v", err)
+	}
+	// Каждое соединение с SQLite в памяти видит свою базу
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	users := services.NewUserService(db)
+	user, err := users.GetOrCreateUser("shutdown-user", "shutdown", "shutdown@example.com")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser() error = %v", err)
+	}
+
+	return &testFactory{
+		users: users,
+		user:  user,
+		newApp: func(generatorURL string, timeout time.Duration) (*App, *services.GenerationService) {
+			cfg := &config.Config{PlumbusServiceURL: generatorURL}
+			generation := services.NewGenerationService(users, services.NewPlumbusService(cfg), services.NewSignatureService(cfg), nil, nil)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed to listen: %v", err)
+			}
+			return New(&http.Server{Handler: http.NotFoundHandler()}, listener, generation, timeout), generation
+		},
+	}
+}
+
+// start запускает приложение и ждет, пока оно начнет принимать запросы
+func start(t *testing.T, a *App, onStart func(ctx context.Context)) chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- a.Run(context.Background(), onStart) }()
+
+	url := "http://" + a.HTTPListener.Addr().String()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+			return done
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// terminate отправляет процессу SIGTERM и ждет остановки приложения
+func terminate(t *testing.T, done chan error) {
+	t.Helper()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("Failed to send SIGTERM: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run() did not return after SIGTERM")
+	}
+}
+
+func waitForStatus(t *testing.T, users *services.UserService, plumbus *models.Plumbus, status models.PlumbusStatus) *models.Plumbus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stored, err := users.GetPlumbus(plumbus.ID)
+		if err == nil && stored.Status == status {
+			return stored
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("plumbus status = %v, want %v", stored.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestApp_SIGTERMWaitsForGeneration(t *testing.T) {
+	started := make(chan struct{}, 1)
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		started <- struct{}{}
+		time.Sleep(300 * time.Millisecond)
+		w.Write(testutils.CreateTestPNGData())
+	}))
+	defer generator.Close()
+
+	factory := setupFactory(t)
+	a, generation := factory.newApp(generator.URL, 5*time.Second)
+	done := start(t, a, nil)
+
+	plumbus, err := generation.Start(context.Background(), factory.user.ID, testRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	<-started
+	terminate(t, done)
+
+	// Run возвращается только после завершения генерации
+	stored, _ := factory.users.GetPlumbus(plumbus.ID)
+	if stored.Status != models.StatusCompleted || stored.Interrupted {
+		t.Errorf("plumbus = %+v, want completed", stored)
+	}
+
+	if _, err := http.Get("http://" + a.HTTPListener.Addr().String()); err == nil {
+		t.Error("server still accepts requests after shutdown")
+	}
+	if _, err := generation.Start(context.Background(), factory.user.ID, testRequest); err != nil {
+		t.Fatalf("Start() after shutdown error = %v", err)
+	}
+	if pending, _ := factory.users.GetInterruptedPlumbuses(); len(pending) != 1 {
+		t.Errorf("plumbuses started after shutdown = %d, want 1 marked for resumption", len(pending))
+	}
+}
+
+func TestApp_SIGTERMInterruptsAndResumesGeneration(t *testing.T) {
+	started := make(chan struct{}, 1)
+	blocking := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer blocking.Close()
+
+	factory := setupFactory(t)
+	a, generation := factory.newApp(blocking.URL, 200*time.Millisecond)
+	done := start(t, a, nil)
+
+	plumbus, err := generation.Start(context.Background(), factory.user.ID, testRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	<-started
+	terminate(t, done)
+
+	stored, _ := factory.users.GetPlumbus(plumbus.ID)
+	if stored.Status != models.StatusPending || !stored.Interrupted {
+		t.Errorf("plumbus = %+v, want pending and interrupted", stored)
+	}
+	if attempts, _ := factory.users.GetPlumbusAttempts(plumbus.ID); len(attempts) != 0 {
+		t.Errorf("interrupted generation recorded %d attempts, want 0", len(attempts))
+	}
+
+	// После перезапуска генерация продолжается под тем же номером попытки
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testutils.CreateTestPNGData())
+	}))
+	defer generator.Close()
+
+	restarted, _ := factory.newApp(generator.URL, 5*time.Second)
+	done = start(t, restarted, nil)
+
+	completed := waitForStatus(t, factory.users, plumbus, models.StatusCompleted)
+	if completed.Interrupted || completed.Attempts != 1 {
+		t.Errorf("resumed plumbus = %+v, want attempt 1 without interruption", completed)
+	}
+	images, _ := filepath.Glob(filepath.Join("storage", "images", "*.png"))
+	if len(images) != 1 {
+		t.Errorf("images = %v, want only the resumed one", images)
+	}
+
+	terminate(t, done)
+}
+
+func TestApp_SIGTERMClosesWatchStreams(t *testing.T) {
+	started := make(chan struct{}, 1)
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		started <- struct{}{}
+		time.Sleep(500 * time.Millisecond)
+		w.Write(testutils.CreateTestPNGData())
+	}))
+	defer generator.Close()
+
+	factory := setupFactory(t)
+	a, generation := factory.newApp(generator.URL, 5*time.Second)
+	a.StreamGrace = 100 * time.Millisecond
+
+	provider := testutils.NewFakeOIDCProvider(t)
+	kc := keycloak.NewClient(&config.Config{
+		KeycloakURL:          provider.URL,
+		KeycloakInternalURL:  provider.URL,
+		KeycloakRealm:        testutils.FakeOIDCRealm,
+		KeycloakClientID:     testutils.FakeOIDCClientID,
+		KeycloakClientSecret: config.NewSecret(testutils.FakeOIDCClientSecret),
+		KeycloakVerifyMode:   keycloak.VerifyModeJWKS,
+	})
+	srv := grpcserver.New(factory.users, generation, kc)
+	srv.WatchInterval = 10 * time.Millisecond
+	a.GRPCServer = srv.NewGRPCServer()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	a.GRPCListener = listener
+	done := start(t, a, nil)
+
+	// Плюмбус без запущенной генерации держит Watch стрим открытым
+	user, _ := factory.users.GetOrCreateUser(provider.User.Subject, provider.User.Username, provider.User.Email)
+	stuck, err := factory.users.CreatePlumbus(user.ID, testRequest)
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial gRPC: %v", err)
+	}
+	defer conn.Close()
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+provider.AccessToken(t))
+	stream, err := factorypb.NewPlumbusServiceClient(conn).WatchPlumbus(ctx, &factorypb.WatchPlumbusRequest{Id: stuck.ID.String()})
+	if err != nil {
+		t.Fatalf("WatchPlumbus() error = %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first Recv() error = %v", err)
+	}
+
+	plumbus, err := generation.Start(context.Background(), factory.user.ID, testRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	<-started
+
+	// Стрим обрывается после StreamGrace, а не держит остановку до дедлайна
+	begin := time.Now()
+	terminate(t, done)
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("shutdown took %v with an open Watch stream, want well under the 5s deadline", elapsed)
+	}
+	if _, err := stream.Recv(); err == nil {
+		t.Error("Watch stream is still open after shutdown")
+	}
+
+	// Генерация получила свое время, пока закрывался gRPC сервер
+	stored, _ := factory.users.GetPlumbus(plumbus.ID)
+	if stored.Status != models.StatusCompleted || stored.Interrupted {
+		t.Errorf("plumbus = %+v, want completed", stored)
+	}
+}
+
+func TestApp_SIGTERMWaitsForBackgroundTasks(t *testing.T) {
+	factory := setupFactory(t)
+	a, _ := factory.newApp("http://127.0.0.1:0", 5*time.Second)
+
+	// Задача завершает текущий проход уже после отмены контекста
+	var finished atomic.Bool
+	done := start(t, a, func(ctx context.Context) {
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			<-ctx.Done()
+			time.Sleep(200 * time.Millisecond)
+			finished.Store(true)
+		}()
+		a.Track(stopped)
+	})
+	terminate(t, done)
+
+	if !finished.Load() {
+		t.Error("Run() returned before the background task stopped")
+	}
+}
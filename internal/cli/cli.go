@@ -0,0 +1,106 @@
+// Package cli - служебные команды фабрики для обслуживания из контейнера без
+// ручных SQL запросов. Команды используют те же настройки и сервисы, что и сервер.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"factory/internal/config"
+	"factory/internal/services"
+)
+
+// ErrUsage возвращается при неизвестной команде или неверных аргументах
+var ErrUsage = errors.New("invalid usage")
+
+// Env - настройки и сервисы, с которыми работают команды
+type Env struct {
+	Config     *config.Config
+	Users      *services.UserService
+	Generation *services.GenerationService
+	Deletion   *services.DeletionService
+	Signature  *services.SignatureService
+	Images     *services.ImageGC
+	// Out - куда команды пишут результат
+	Out io.Writer
+}
+
+type command struct {
+	// name - команда вместе с группой, например "plumbus list"
+	name  string
+	args  string
+	short string
+	run   func(ctx context.Context, env *Env, args []string) error
+}
+
+// commands - служебные команды. serve, migrate и config print выполняет main:
+// им нужна инициализация, отличная от остальных команд.
+var commands = []command{
+	{name: "users list", short: "list users with plumbus counts", run: usersList},
+	{name: "plumbus list", args: "[-status s] [-user id] [-name text] [-stuck] [-deleted] [-limit n]", short: "list plumbuses of all users", run: plumbusList},
+	{name: "plumbus show", args: "<id>", short: "show a plumbus with its generation attempts", run: plumbusShow},
+	{name: "plumbus retry", args: "<id>", short: "retry a failed generation and wait for the result", run: plumbusRetry},
+	{name: "plumbus delete", args: "[-hard] <id>", short: "soft-delete a plumbus, or delete it permanently with -hard", run: plumbusDelete},
+	{name: "resign", args: "[-all]", short: "sign completed plumbuses without a signature (-all: every completed plumbus)", run: resign},
+	{name: "verify-all", short: "verify signatures of all completed plumbuses", run: verifyAll},
+	{name: "gc-images", args: "[-delete] [-grace d]", short: "find image files no plumbus refers to and plumbuses whose image is missing", run: gcImages},
+}
+
+// Has сообщает, является ли args служебной командой
+func Has(args []string) bool {
+	_, _, ok := lookup(args)
+	return ok
+}
+
+// Run выполняет служебную команду args
+func Run(ctx context.Context, env *Env, args []string) error {
+	cmd, rest, ok := lookup(args)
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, strings.Join(args, " "))
+	}
+	return cmd.run(ctx, env, rest)
+}
+
+// lookup находит команду по первым словам args и возвращает остальные аргументы
+func lookup(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+// Usage выводит список служебных команд
+func Usage(w io.Writer) {
+	for _, cmd := range commands {
+		usage := cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(w, "  %s\n    \t%s\n", usage, cmd.short)
+	}
+}
+
+// parseFlags разбирает флаги команды. Ошибки разбора возвращаются как ErrUsage.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUsage, fs.Name(), err)
+	}
+	return nil
+}
+
+// newTable возвращает writer для вывода выровненной таблицы
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
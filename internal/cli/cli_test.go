@@ -0,0 +1,331 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/models"
+	"factory/internal/services"
+	"factory/internal/testutils"
+)
+
+type testEnv struct {
+	*Env
+	out  *bytes.Buffer
+	user *models.User
+	// signatures - подписи, которые sig-store считает верными
+	signatures map[string]bool
+}
+
+// setupEnv создает окружение команд на SQLite с пользователем rick. Сервис
+// генерации всегда отвечает ошибкой, sig-store подписывает любой файл.
+func setupEnv(t *testing.T) *testEnv {
+	t.Helper()
+	env := &testEnv{out: &bytes.Buffer{}, signatures: make(map[string]bool)}
+
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(generator.Close)
+
+	sigStore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/register":
+			_, header, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			signature := "sig-" + header.Filename
+			env.signatures[signature] = true
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": 1, "signature": signature, "created_at": time.Now().UTC(),
+			})
+		case "/api/v1/verify":
+			json.NewEncoder(w).Encode(map[string]interface{}{"valid": env.signatures[r.FormValue("signature")]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(sigStore.Close)
+
+	cfg := &config.Config{
+		PlumbusServiceURL:    generator.URL,
+		SigStoreURL:          sigStore.URL,
+		PlumbusRestoreWindow: time.Hour,
+	}
+	db := testutils.SetupTestDB(t)
+	if err := db.AutoMigrate(&models.PlumbusAttempt{}); err != nil {
+		t.Fatalf("Failed to migrate plumbus attempts table: %v", err)
+	}
+	users := services.NewUserService(db)
+	signature := services.NewSignatureService(cfg)
+	generation := services.NewGenerationService(users, services.NewPlumbusService(cfg), signature, nil, nil)
+	deletion := services.NewDeletionService(users, nil, cfg.PlumbusRestoreWindow)
+	deletion.Generation = generation
+	env.Env = &Env{
+		Config:     cfg,
+		Users:      users,
+		Generation: generation,
+		Deletion:   deletion,
+		Signature:  signature,
+		Images:     services.NewImageGC(users, t.TempDir()),
+		Out:        env.out,
+	}
+
+	user, err := users.GetOrCreateUser("kc-rick", "rick", "rick@citadel.example")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser() error = %v", err)
+	}
+	env.user = user
+	return env
+}
+
+// createPlumbus создает плюмбус rick в статусе status, у готового - с изображением
+func (env *testEnv) createPlumbus(t *testing.T, name string, status models.PlumbusStatus) *models.Plumbus {
+	t.Helper()
+	plumbus, err := env.Users.CreatePlumbus(env.user.ID, models.PlumbusRequest{
+		Name: name, Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+	})
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+
+	var imagePath, errorMsg *string
+	switch status {
+	case models.StatusCompleted:
+		path := filepath.Join(env.Images.Dir, plumbus.ID.String()+".png")
+		if err := os.WriteFile(path, testutils.CreateTestPNGData(), 0644); err != nil {
+			t.Fatalf("Failed to write image: %v", err)
+		}
+		imagePath = &path
+	case models.StatusFailed:
+		msg := "generator timeout"
+		errorMsg = &msg
+	}
+	if err := env.Users.UpdatePlumbusStatus(plumbus.ID, status, imagePath, errorMsg, nil, nil); err != nil {
+		t.Fatalf("UpdatePlumbusStatus() error = %v", err)
+	}
+
+	plumbus, err = env.Users.GetPlumbusIncludingDeleted(plumbus.ID)
+	if err != nil {
+		t.Fatalf("GetPlumbusIncludingDeleted() error = %v", err)
+	}
+	return plumbus
+}
+
+// run выполняет команду и возвращает ее вывод
+func (env *testEnv) run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	env.out.Reset()
+	err := Run(context.Background(), env.Env, args)
+	return env.out.String(), err
+}
+
+func TestRun_Usage(t *testing.T) {
+	env := setupEnv(t)
+
+	tests := [][]string{
+		{"plumbus"},
+		{"plumbus", "explode"},
+		{"plumbus", "show"},
+		{"plumbus", "show", "not-a-uuid"},
+		{"plumbus", "list", "-user", "not-a-uuid"},
+		{"gc-images", "-unknown"},
+	}
+	for _, args := range tests {
+		if _, err := env.run(t, args...); !errors.Is(err, ErrUsage) {
+			t.Errorf("Run(%q) error = %v, want ErrUsage", args, err)
+		}
+	}
+
+	if !Has([]string{"plumbus", "show", "id"}) || Has([]string{"serve"}) {
+		t.Error("Has() does not match the command list")
+	}
+}
+
+func TestUsersAndPlumbusList(t *testing.T) {
+	env := setupEnv(t)
+	env.createPlumbus(t, "Classic", models.StatusCompleted)
+	failed := env.createPlumbus(t, "Broken", models.StatusFailed)
+
+	out, err := env.run(t, "users", "list")
+	if err != nil {
+		t.Fatalf("users list error = %v", err)
+	}
+	if !strings.Contains(out, env.user.ID.String()) || !strings.Contains(out, "rick") {
+		t.Errorf("users list output misses rick:\n%s", out)
+	}
+
+	out, err = env.run(t, "plumbus", "list", "-status", "failed")
+	if err != nil {
+		t.Fatalf("plumbus list error = %v", err)
+	}
+	if !strings.Contains(out, failed.ID.String()) || !strings.Contains(out, "rick") || strings.Contains(out, "Classic") {
+		t.Errorf("plumbus list -status failed output:\n%s", out)
+	}
+}
+
+func TestPlumbusShow(t *testing.T) {
+	env := setupEnv(t)
+	plumbus := env.createPlumbus(t, "Broken", models.StatusFailed)
+	if err := env.Users.CreatePlumbusAttempt(&models.PlumbusAttempt{
+		PlumbusID: plumbus.ID, Attempt: 1, Status: models.StatusFailed, Error: "generator timeout",
+		StartedAt: time.Now().Add(-time.Second), FinishedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreatePlumbusAttempt() error = %v", err)
+	}
+
+	out, err := env.run(t, "plumbus", "show", plumbus.ID.String())
+	if err != nil {
+		t.Fatalf("plumbus show error = %v", err)
+	}
+	for _, want := range []string{plumbus.ID.String(), "Broken", "failed", "generator timeout", "ATTEMPT"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plumbus show output misses %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPlumbusRetry(t *testing.T) {
+	env := setupEnv(t)
+	plumbus := env.createPlumbus(t, "Broken", models.StatusFailed)
+
+	// Генератор недоступен: команда дожидается новой неудачи и сообщает о ней
+	out, err := env.run(t, "plumbus", "retry", plumbus.ID.String())
+	if err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Fatalf("plumbus retry error = %v, want failed generation", err)
+	}
+	if !strings.Contains(out, "attempt 2") {
+		t.Errorf("plumbus retry output = %q, want attempt 2", out)
+	}
+	attempts, err := env.Users.GetPlumbusAttempts(plumbus.ID)
+	if err != nil || len(attempts) != 1 || attempts[0].Attempt != 2 {
+		t.Errorf("GetPlumbusAttempts() = %+v, %v, want the retried attempt", attempts, err)
+	}
+
+	completed := env.createPlumbus(t, "Classic", models.StatusCompleted)
+	if _, err := env.run(t, "plumbus", "retry", completed.ID.String()); !errors.Is(err, services.ErrPlumbusNotFailed) {
+		t.Errorf("plumbus retry of completed plumbus error = %v, want ErrPlumbusNotFailed", err)
+	}
+}
+
+func TestPlumbusDelete(t *testing.T) {
+	env := setupEnv(t)
+	plumbus := env.createPlumbus(t, "Classic", models.StatusCompleted)
+
+	if _, err := env.run(t, "plumbus", "delete", plumbus.ID.String()); err != nil {
+		t.Fatalf("plumbus delete error = %v", err)
+	}
+	deleted, err := env.Users.GetPlumbusIncludingDeleted(plumbus.ID)
+	if err != nil || !deleted.DeletedAt.Valid {
+		t.Fatalf("plumbus not soft-deleted: %+v, %v", deleted, err)
+	}
+	if _, err := env.run(t, "plumbus", "delete", plumbus.ID.String()); err == nil {
+		t.Error("second soft delete succeeded")
+	}
+
+	if _, err := env.run(t, "plumbus", "delete", "-hard", plumbus.ID.String()); err != nil {
+		t.Fatalf("plumbus delete -hard error = %v", err)
+	}
+	if _, err := env.Users.GetPlumbusIncludingDeleted(plumbus.ID); err == nil {
+		t.Error("plumbus still exists after hard delete")
+	}
+	if _, err := os.Stat(*deleted.ImagePath); !os.IsNotExist(err) {
+		t.Errorf("image still exists after hard delete: %v", err)
+	}
+}
+
+func TestResignAndVerifyAll(t *testing.T) {
+	env := setupEnv(t)
+	plumbus := env.createPlumbus(t, "Classic", models.StatusCompleted)
+	env.createPlumbus(t, "Broken", models.StatusFailed)
+
+	out, err := env.run(t, "verify-all")
+	if err != nil {
+		t.Fatalf("verify-all error = %v", err)
+	}
+	if !strings.Contains(out, "UNSIGNED "+plumbus.ID.String()) {
+		t.Errorf("verify-all output misses the unsigned plumbus:\n%s", out)
+	}
+
+	out, err = env.run(t, "resign")
+	if err != nil {
+		t.Fatalf("resign error = %v", err)
+	}
+	if !strings.Contains(out, "Signed 1 plumbuses") {
+		t.Errorf("resign output = %q, want one signed plumbus", out)
+	}
+	signed, _ := env.Users.GetPlumbus(plumbus.ID)
+	if signed.Signature == nil || signed.SignatureDate == nil {
+		t.Fatalf("plumbus not signed: %+v", signed)
+	}
+
+	// Без -all подписанные плюмбусы не подписываются повторно
+	if out, _ := env.run(t, "resign"); !strings.Contains(out, "Signed 0 plumbuses") {
+		t.Errorf("repeated resign output = %q, want nothing signed", out)
+	}
+
+	if out, err := env.run(t, "verify-all"); err != nil || !strings.Contains(out, "1 valid") {
+		t.Errorf("verify-all = %q, %v, want one valid signature", out, err)
+	}
+
+	// Подпись, которую sig-store не признает, делает проверку неуспешной
+	env.signatures = map[string]bool{}
+	out, err = env.run(t, "verify-all")
+	if err == nil || !strings.Contains(out, "INVALID "+plumbus.ID.String()) {
+		t.Errorf("verify-all = %q, %v, want invalid signature error", out, err)
+	}
+}
+
+func TestGCImages(t *testing.T) {
+	env := setupEnv(t)
+	env.createPlumbus(t, "Classic", models.StatusCompleted)
+	lost := env.createPlumbus(t, "Lost", models.StatusCompleted)
+	if err := os.Remove(*lost.ImagePath); err != nil {
+		t.Fatalf("Failed to remove image: %v", err)
+	}
+	orphan := filepath.Join(env.Images.Dir, "orphan.png")
+	if err := os.WriteFile(orphan, testutils.CreateTestPNGData(), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+
+	out, err := env.run(t, "gc-images")
+	if err != nil {
+		t.Fatalf("gc-images error = %v", err)
+	}
+	if !strings.Contains(out, orphan) || !strings.Contains(out, "recent") || !strings.Contains(out, "Dry run") {
+		t.Errorf("gc-images output misses the orphan:\n%s", out)
+	}
+	if !strings.Contains(out, "MISSING") || !strings.Contains(out, lost.ID.String()) {
+		t.Errorf("gc-images output misses the lost image:\n%s", out)
+	}
+	if stored, _ := env.Users.GetPlumbus(lost.ID); !stored.Broken {
+		t.Error("plumbus with missing image not flagged as broken")
+	}
+
+	// Свежий файл-сирота защищен периодом ожидания
+	if _, err := env.run(t, "gc-images", "-delete"); err != nil {
+		t.Fatalf("gc-images -delete error = %v", err)
+	}
+	if _, err := os.Stat(orphan); err != nil {
+		t.Fatalf("recent orphan removed: %v", err)
+	}
+
+	if _, err := env.run(t, "gc-images", "-delete", "-grace", "0"); err != nil {
+		t.Fatalf("gc-images -delete -grace 0 error = %v", err)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("orphan still exists: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"factory/internal/models"
+)
+
+// resign подписывает готовые плюмбусы, у которых нет подписи, например после
+// недоступности sig-store. С -all подписывает заново все готовые плюмбусы.
+func resign(ctx context.Context, env *Env, args []string) error {
+	fs := flag.NewFlagSet("resign", flag.ContinueOnError)
+	all := fs.Bool("all", false, "re-sign plumbuses that already have a signature")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	users := env.Users.WithContext(ctx)
+	plumbuses, err := users.GetCompletedPlumbuses()
+	if err != nil {
+		return fmt.Errorf("failed to list plumbuses: %w", err)
+	}
+
+	signed, failed := 0, 0
+	for _, p := range plumbuses {
+		if p.Signature != nil && !*all {
+			continue
+		}
+		signature, err := env.Signature.SignFile(ctx, *p.ImagePath)
+		if err == nil {
+			err = users.UpdatePlumbusStatus(p.ID, models.StatusCompleted, nil, nil, &signature.Signature, &signature.CreatedAt)
+		}
+		if err != nil {
+			fmt.Fprintf(env.Out, "FAILED %s: %v\n", p.ID, err)
+			failed++
+			continue
+		}
+		signed++
+	}
+
+	fmt.Fprintf(env.Out, "Signed %d plumbuses, %d failed\n", signed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d plumbuses could not be signed", failed)
+	}
+	return nil
+}
+
+// verifyAll проверяет в sig-store подписи всех готовых плюмбусов. Завершается
+// ошибкой, если хоть одна подпись неверна или не проверена.
+func verifyAll(ctx context.Context, env *Env, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("verify-all", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	plumbuses, err := env.Users.WithContext(ctx).GetCompletedPlumbuses()
+	if err != nil {
+		return fmt.Errorf("failed to list plumbuses: %w", err)
+	}
+
+	valid, invalid, unsigned, failed := 0, 0, 0, 0
+	for i := range plumbuses {
+		p := &plumbuses[i]
+		if p.Signature == nil {
+			fmt.Fprintf(env.Out, "UNSIGNED %s\n", p.ID)
+			unsigned++
+			continue
+		}
+		ok, err := env.Generation.Verify(ctx, p)
+		switch {
+		case err != nil:
+			fmt.Fprintf(env.Out, "ERROR %s: %v\n", p.ID, err)
+			failed++
+		case !ok:
+			fmt.Fprintf(env.Out, "INVALID %s %s\n", p.ID, *p.ImagePath)
+			invalid++
+		default:
+			valid++
+		}
+	}
+
+	fmt.Fprintf(env.Out, "Verified %d plumbuses: %d valid, %d invalid, %d unsigned, %d errors\n",
+		len(plumbuses), valid, invalid, unsigned, failed)
+	if invalid > 0 || failed > 0 {
+		return fmt.Errorf("%d signatures are invalid, %d could not be verified", invalid, failed)
+	}
+	return nil
+}
+
+// gcImages перечисляет файлы изображений без плюмбуса и, с -delete, удаляет их
+func gcImages(ctx context.Context, env *Env, args []string) error {
+	fs := flag.NewFlagSet("gc-images", flag.ContinueOnError)
+	remove := fs.Bool("delete", false, "delete orphaned images instead of only listing them")
+	grace := fs.Duration("grace", env.Images.Grace, "keep orphaned images younger than this")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *grace < 0 {
+		return fmt.Errorf("%w: -grace must not be negative", ErrUsage)
+	}
+	env.Images.Grace = *grace
+
+	report, err := env.Images.Run(ctx, *remove)
+	if err != nil {
+		return err
+	}
+	if report.Skipped {
+		return fmt.Errorf("image directory is being reconciled by another instance")
+	}
+
+	tw := newTable(env.Out)
+	for _, o := range report.Orphans {
+		state := "expired"
+		if o.Recent {
+			state = "recent"
+		}
+		fmt.Fprintf(tw, "ORPHAN\t%s\t%d bytes\t%s\t%s\n", o.Path, o.Size, o.ModTime.Local().Format(timeFormat), state)
+	}
+	for _, m := range report.Missing {
+		fmt.Fprintf(tw, "MISSING\t%s\tplumbus %s\n", m.Path, m.ID)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.Out, "Scanned %d images in %s: %d orphaned, %d deleted (%d bytes)\n",
+		report.Scanned, env.Images.Dir, len(report.Orphans), report.Removed, report.RemovedBytes)
+	fmt.Fprintf(env.Out, "%d plumbuses with missing images: %d newly flagged as broken, %d restored\n",
+		len(report.Missing), report.Flagged, report.Restored)
+	if len(report.Orphans) > 0 && !*remove {
+		fmt.Fprintf(env.Out, "Dry run, use -delete to remove orphaned images older than %s\n", *grace)
+	}
+	return nil
+}
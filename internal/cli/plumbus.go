@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"factory/internal/models"
+	"factory/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// timeFormat - формат времени в выводе команд
+const timeFormat = "2006-01-02 15:04:05"
+
+// plumbusList выводит плюмбусы всех пользователей по фильтру, как панель администратора
+func plumbusList(ctx context.Context, env *Env, args []string) error {
+	fs := flag.NewFlagSet("plumbus list", flag.ContinueOnError)
+	status := fs.String("status", "", "only plumbuses with this status")
+	user := fs.String("user", "", "only plumbuses of this user ID")
+	name := fs.String("name", "", "only plumbuses whose name contains this text")
+	stuck := fs.Bool("stuck", false, "only pending or generating plumbuses not updated for a while")
+	deleted := fs.Bool("deleted", false, "only soft-deleted plumbuses")
+	limit := fs.Int("limit", services.DefaultAdminListLimit, "maximum number of plumbuses")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	filter := services.PlumbusFilter{
+		Status:     models.PlumbusStatus(*status),
+		Name:       strings.TrimSpace(*name),
+		Stuck:      *stuck,
+		StuckAfter: env.Generation.StuckAfter,
+		Deleted:    *deleted,
+		Limit:      *limit,
+	}
+	if *user != "" {
+		id, err := uuid.Parse(*user)
+		if err != nil {
+			return fmt.Errorf("%w: invalid user ID %q", ErrUsage, *user)
+		}
+		filter.UserID = id
+	}
+
+	users := env.Users.WithContext(ctx)
+	plumbuses, err := users.ListPlumbuses(filter, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list plumbuses: %w", err)
+	}
+	stats, err := users.GetUserStats()
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	usernames := make(map[uuid.UUID]string, len(stats))
+	for _, s := range stats {
+		usernames[s.UserID] = s.Username
+	}
+
+	tw := newTable(env.Out)
+	fmt.Fprintln(tw, "ID\tUSER\tSTATUS\tATTEMPTS\tUPDATED\tNAME")
+	for _, p := range plumbuses {
+		username, ok := usernames[p.UserID]
+		if !ok {
+			username = p.UserID.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", p.ID, username, p.Status, p.Attempts, p.UpdatedAt.Local().Format(timeFormat), p.Name)
+	}
+	return tw.Flush()
+}
+
+// plumbusShow выводит плюмбус, в том числе удаленный, и историю попыток его генерации
+func plumbusShow(ctx context.Context, env *Env, args []string) error {
+	fs := flag.NewFlagSet("plumbus show", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	plumbus, err := loadPlumbus(ctx, env, fs.Args())
+	if err != nil {
+		return err
+	}
+	attempts, err := env.Users.WithContext(ctx).GetPlumbusAttempts(plumbus.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load attempts: %w", err)
+	}
+
+	tw := newTable(env.Out)
+	fmt.Fprintf(tw, "ID:\t%s\n", plumbus.ID)
+	fmt.Fprintf(tw, "Name:\t%s\n", plumbus.Name)
+	fmt.Fprintf(tw, "User:\t%s\n", plumbus.UserID)
+	fmt.Fprintf(tw, "Status:\t%s\n", plumbus.Status)
+	fmt.Fprintf(tw, "Rare:\t%t\n", plumbus.IsRare)
+	fmt.Fprintf(tw, "Parameters:\tsize=%s color=%s shape=%s weight=%s wrapping=%s\n",
+		plumbus.Size, plumbus.Color, plumbus.Shape, plumbus.Weight, plumbus.Wrapping)
+	fmt.Fprintf(tw, "Attempts:\t%d of %d\n", plumbus.Attempts, env.Generation.MaxAttempts)
+	if plumbus.ImagePath != nil {
+		fmt.Fprintf(tw, "Image:\t%s\n", *plumbus.ImagePath)
+	}
+	if plumbus.Signature != nil {
+		signed := "yes"
+		if plumbus.SignatureDate != nil {
+			signed += ", " + plumbus.SignatureDate.Local().Format(timeFormat)
+		}
+		fmt.Fprintf(tw, "Signed:\t%s\n", signed)
+	}
+	if plumbus.ErrorMsg != nil {
+		fmt.Fprintf(tw, "Error:\t%s\n", *plumbus.ErrorMsg)
+	}
+	fmt.Fprintf(tw, "Created:\t%s\n", plumbus.CreatedAt.Local().Format(timeFormat))
+	fmt.Fprintf(tw, "Updated:\t%s\n", plumbus.UpdatedAt.Local().Format(timeFormat))
+	if plumbus.DeletedAt.Valid {
+		fmt.Fprintf(tw, "Deleted:\t%s\n", plumbus.DeletedAt.Time.Local().Format(timeFormat))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(attempts) == 0 {
+		return nil
+	}
+	fmt.Fprintln(env.Out)
+	tw = newTable(env.Out)
+	fmt.Fprintln(tw, "ATTEMPT\tSTATUS\tSTARTED\tDURATION\tERROR")
+	for _, a := range attempts {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", a.Attempt, a.Status, a.StartedAt.Local().Format(timeFormat),
+			a.FinishedAt.Sub(a.StartedAt).Round(time.Millisecond), a.Error)
+	}
+	return tw.Flush()
+}
+
+// plumbusRetry повторяет генерацию неудавшегося плюмбуса и ждет ее завершения
+func plumbusRetry(ctx context.Context, env *Env, args []string) error {
+	fs := flag.NewFlagSet("plumbus retry", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	plumbus, err := loadPlumbus(ctx, env, fs.Args())
+	if err != nil {
+		return err
+	}
+	if plumbus.DeletedAt.Valid {
+		return fmt.Errorf("plumbus %s is deleted", plumbus.ID)
+	}
+
+	retried, err := env.Generation.Retry(ctx, plumbus)
+	if err != nil {
+		return fmt.Errorf("failed to retry plumbus %s: %w", plumbus.ID, err)
+	}
+	fmt.Fprintf(env.Out, "Retrying plumbus %s, attempt %d of %d\n", retried.ID, retried.Attempts, env.Generation.MaxAttempts)
+
+	// Генерация идет в фоне; при прерывании она продолжится после запуска сервера
+	if err := env.Generation.Shutdown(ctx); err != nil {
+		return fmt.Errorf("generation interrupted: %w", err)
+	}
+
+	result, err := env.Users.WithContext(ctx).GetPlumbus(retried.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load plumbus: %w", err)
+	}
+	if result.Status != models.StatusCompleted {
+		errorMsg := ""
+		if result.ErrorMsg != nil {
+			errorMsg = *result.ErrorMsg
+		}
+		return fmt.Errorf("plumbus %s finished with status %s: %s", result.ID, result.Status, errorMsg)
+	}
+	fmt.Fprintf(env.Out, "Plumbus %s completed\n", result.ID)
+	return nil
+}
+
+// plumbusDelete мягко удаляет плюмбус или, с -hard, удаляет его безвозвратно вместе с изображением
+func plumbusDelete(ctx context.Context, env *Env, args []string) error {
+	fs := flag.NewFlagSet("plumbus delete", flag.ContinueOnError)
+	hard := fs.Bool("hard", false, "delete permanently together with the image")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	plumbus, err := loadPlumbus(ctx, env, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	if *hard {
+		if err := env.Deletion.HardDelete(ctx, plumbus); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Out, "Plumbus %s deleted permanently\n", plumbus.ID)
+		return nil
+	}
+
+	if plumbus.DeletedAt.Valid {
+		return fmt.Errorf("plumbus %s is already deleted", plumbus.ID)
+	}
+	restoreUntil, err := env.Deletion.Delete(ctx, plumbus)
+	if err != nil {
+		return fmt.Errorf("failed to delete plumbus %s: %w", plumbus.ID, err)
+	}
+	fmt.Fprintf(env.Out, "Plumbus %s deleted, can be restored until %s\n", plumbus.ID, restoreUntil.Local().Format(timeFormat))
+	return nil
+}
+
+// loadPlumbus загружает плюмбус, в том числе удаленный, по единственному аргументу команды
+func loadPlumbus(ctx context.Context, env *Env, args []string) (*models.Plumbus, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: expected a plumbus ID", ErrUsage)
+	}
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid plumbus ID %q", ErrUsage, args[0])
+	}
+	plumbus, err := env.Users.WithContext(ctx).GetPlumbusIncludingDeleted(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plumbus %s: %w", id, err)
+	}
+	return plumbus, nil
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+// usersList выводит пользователей со сводкой по их плюмбусам
+func usersList(ctx context.Context, env *Env, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("users list", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	stats, err := env.Users.WithContext(ctx).GetUserStats()
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	tw := newTable(env.Out)
+	fmt.Fprintln(tw, "ID\tUSERNAME\tTOTAL\tCOMPLETED\tIN PROGRESS\tFAILED\tRARE")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n", s.UserID, s.Username, s.Total, s.Completed, s.InProgress, s.Failed, s.Rare)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaskedValue заменяет значения секретов в настройках
+const MaskedValue = "******"
+
+// Masked возвращает копию настроек, в которой скрыты секреты и пароли в URL
+func (c *Config) Masked() *Config {
+	masked := *c
+	value := reflect.ValueOf(&masked).Elem()
+	fields := value.Type()
+	for i := 0; i < fields.NumField(); i++ {
+		target := value.Field(i)
+		if target.Kind() != reflect.String || target.String() == "" {
+			continue
+		}
+		// Значения типа *Secret маскируются сами при выводе
+		if fields.Field(i).Tag.Get("secret") == "url" {
+			target.SetString(maskURL(target.String()))
+		}
+	}
+	return &masked
+}
+
+// maskedQueryParams - параметры строки запроса URL, в которых передают пароли
+var maskedQueryParams = map[string]bool{"password": true, "sslpassword": true}
+
+// maskURL скрывает пароль в URL и в параметрах password и sslpassword.
+// Неразбираемый URL скрывается целиком.
+func maskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return MaskedValue
+	}
+
+	masked := false
+	if u.RawQuery != "" {
+		// Разбираем строку запроса вручную, чтобы сохранить порядок параметров
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			key, _, _ := strings.Cut(param, "=")
+			if name, err := url.QueryUnescape(key); err == nil && maskedQueryParams[strings.ToLower(name)] {
+				params[i] = key + "=" + MaskedValue
+				masked = true
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), MaskedValue)
+		masked = true
+	}
+	if !masked {
+		return raw
+	}
+	// Звездочки в пароле экранируются, возвращаем их для читаемости
+	return strings.Replace(u.String(), url.QueryEscape(MaskedValue), MaskedValue, 1)
+}
+
+// Print выводит действующие настройки в формате YAML со скрытыми секретами.
+// Вывод можно использовать как файл настроек.
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Masked()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Провайдеры секретов
+const (
+	SecretsProviderFile  = "file"
+	SecretsProviderVault = "vault"
+)
+
+// SecretProvider загружает значения секретов по имени. Имена совпадают с ключами
+// YAML файла: session_secret, keycloak_client_secret, database_password.
+type SecretProvider interface {
+	Get(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// FileSecretProvider читает секреты из файлов каталога: каждый секрет - файл с
+// именем секрета. Подходит для секретов Kubernetes, смонтированных как том:
+// kubelet обновляет файлы при ротации.
+type FileSecretProvider struct {
+	Dir string
+}
+
+func (p *FileSecretProvider) Get(ctx context.Context, names []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, name := range names {
+		value, err := readSecretFile(filepath.Join(p.Dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// VaultSecretProvider читает секреты из Vault по HTTP API. Path - путь секрета
+// после /v1/, например secret/data/factory для KV v2 или secret/factory для KV v1.
+type VaultSecretProvider struct {
+	Address string
+	Token   *Secret
+	Path    string
+	Client  *http.Client
+}
+
+func (p *VaultSecretProvider) Get(ctx context.Context, names []string) (map[string]string, error) {
+	endpoint := strings.TrimRight(p.Address, "/") + "/v1/" + strings.TrimLeft(p.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.Token.Value())
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets from vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	// KV v2 вкладывает значения в data.data, KV v1 отдает их прямо в data
+	data := payload.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	values := make(map[string]string)
+	for _, name := range names {
+		if value, ok := data[name].(string); ok {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// newSecretProvider создает провайдер по настройкам. Без SECRETS_PROVIDER
+// секреты берутся только из файла настроек и переменных окружения. Для неполных
+// настроек провайдер не создается: о них сообщит Validate.
+func (c *Config) newSecretProvider() SecretProvider {
+	switch c.SecretsProvider {
+	case SecretsProviderFile:
+		if c.SecretsDir != "" {
+			return &FileSecretProvider{Dir: c.SecretsDir}
+		}
+	case SecretsProviderVault:
+		if c.VaultAddr != "" && c.VaultToken.IsSet() && c.VaultSecretPath != "" {
+			return &VaultSecretProvider{Address: c.VaultAddr, Token: c.VaultToken, Path: c.VaultSecretPath}
+		}
+	}
+	return nil
+}
+
+// RefreshSecrets загружает секреты из провайдера. Секреты, которых нет в
+// провайдере, сохраняют значения из файла настроек и переменных окружения.
+func (c *Config) RefreshSecrets(ctx context.Context) error {
+	if c.secretProvider == nil {
+		return nil
+	}
+
+	secrets := c.secrets()
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+
+	values, err := c.secretProvider.Get(ctx, names)
+	if err != nil {
+		return err
+	}
+	for name, value := range values {
+		if secret := secrets[name]; secret != nil {
+			secret.Set(value)
+		}
+	}
+	return nil
+}
+
+// StartSecretRefresh периодически обновляет секреты из провайдера до отмены контекста
+func (c *Config) StartSecretRefresh(ctx context.Context, onError func(error)) {
+	if c.secretProvider == nil {
+		return
+	}
+	ticker := time.NewTicker(c.SecretsRefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.RefreshSecrets(ctx); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+}
+
+// readSecretFile читает секрет из файла без завершающего перевода строки
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
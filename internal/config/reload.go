@@ -0,0 +1,228 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"factory/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Результаты перезагрузки настроек
+const (
+	ReloadApplied   = "applied"
+	ReloadUnchanged = "unchanged"
+	ReloadFailed    = "failed"
+)
+
+// Причины перезагрузки настроек
+const (
+	TriggerSignal = "signal"
+	TriggerFile   = "file"
+	TriggerAPI    = "api"
+)
+
+// maxReloadHistory - сколько последних перезагрузок помнит Reloader
+const maxReloadHistory = 20
+
+// ReloadResult описывает одну попытку перезагрузки настроек
+type ReloadResult struct {
+	Time    time.Time `json:"time"`
+	Trigger string    `json:"trigger"`
+	Status  string    `json:"status"`
+	// Changed - ключи примененных настроек
+	Changed []string `json:"changed,omitempty"`
+	// RestartRequired - измененные ключи, которые вступят в силу только после перезапуска
+	RestartRequired []string `json:"restart_required,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+type subscriber struct {
+	name  string
+	apply func(*Config)
+}
+
+// Reloader перечитывает настройки по SIGHUP, при изменении файла настроек или
+// по запросу и передает подписчикам поля с тегом reload. Новые настройки
+// проверяются целиком: если хоть одно значение неверно, не применяется ничего.
+type Reloader struct {
+	path   string
+	logger *logrus.Logger
+
+	mu          sync.Mutex
+	current     *Config
+	subscribers []subscriber
+	history     []ReloadResult
+
+	// Состояние файла при последней проверке, используется только в Watch
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader создает Reloader для настроек cfg, загруженных из файла path
+// (пустой path - только переменные окружения)
+func NewReloader(path string, cfg *Config) *Reloader {
+	current := *cfg
+	r := &Reloader{path: path, logger: logger.For("config"), current: &current}
+	r.fileChanged()
+	return r
+}
+
+// Subscribe регистрирует функцию, применяющую новые настройки в компоненте name.
+// Функции вызываются последовательно после каждой успешной перезагрузки.
+func (r *Reloader) Subscribe(name string, apply func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, subscriber{name: name, apply: apply})
+}
+
+// Reload перечитывает настройки и применяет изменившиеся поля с тегом reload.
+// Изменения остальных полей только перечисляются в результате.
+func (r *Reloader) Reload(trigger string) ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := ReloadResult{Time: time.Now(), Trigger: trigger}
+	next, err := Load(r.path)
+	if err != nil {
+		result.Status = ReloadFailed
+		result.Error = err.Error()
+	} else {
+		applied := *r.current
+		result.Changed, result.RestartRequired = diff(&applied, next)
+		if len(result.Changed) == 0 {
+			result.Status = ReloadUnchanged
+		} else {
+			result.Status = ReloadApplied
+			r.current = &applied
+			for _, s := range r.subscribers {
+				r.logger.WithField("subscriber", s.name).Debug("Applying reloaded configuration")
+				s.apply(&applied)
+			}
+		}
+	}
+
+	r.record(result)
+	return result
+}
+
+// diff переносит в current изменившиеся поля next с тегом reload и возвращает
+// их ключи, а также ключи изменившихся полей, требующих перезапуска. Секреты
+// не сравниваются: их обновляет провайдер секретов.
+func diff(current, next *Config) (changed, restartRequired []string) {
+	target := reflect.ValueOf(current).Elem()
+	source := reflect.ValueOf(next).Elem()
+	fields := target.Type()
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		key := field.Tag.Get("yaml")
+		if key == "" || field.Type == secretType {
+			continue
+		}
+		if reflect.DeepEqual(target.Field(i).Interface(), source.Field(i).Interface()) {
+			continue
+		}
+		if field.Tag.Get("reload") != "true" {
+			restartRequired = append(restartRequired, key)
+			continue
+		}
+		target.Field(i).Set(source.Field(i))
+		changed = append(changed, key)
+	}
+	return changed, restartRequired
+}
+
+// record запоминает и логирует результат перезагрузки. Вызывается под mu.
+func (r *Reloader) record(result ReloadResult) {
+	r.history = append(r.history, result)
+	if len(r.history) > maxReloadHistory {
+		r.history = r.history[len(r.history)-maxReloadHistory:]
+	}
+
+	entry := r.logger.WithFields(logrus.Fields{
+		"trigger": result.Trigger,
+		"status":  result.Status,
+	})
+	if len(result.Changed) > 0 {
+		entry = entry.WithField("changed", result.Changed)
+	}
+	if len(result.RestartRequired) > 0 {
+		entry = entry.WithField("restart_required", result.RestartRequired)
+	}
+	switch {
+	case result.Status == ReloadFailed:
+		entry.WithField("error", result.Error).Error("Configuration reload failed")
+	case len(result.RestartRequired) > 0:
+		entry.Warn("Configuration reloaded, some changes require a restart")
+	default:
+		entry.Info("Configuration reloaded")
+	}
+}
+
+// History возвращает последние результаты перезагрузки, начиная с самого нового
+func (r *Reloader) History() []ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	history := make([]ReloadResult, len(r.history))
+	for i, result := range r.history {
+		history[len(r.history)-1-i] = result
+	}
+	return history
+}
+
+// Watch перезагружает настройки по SIGHUP и при изменении файла настроек,
+// который проверяется с периодом interval (0 - не проверять), до отмены ctx
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if r.path != "" && interval > 0 {
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+
+	go func() {
+		defer signal.Stop(signals)
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				r.fileChanged()
+				r.Reload(TriggerSignal)
+			case <-tick:
+				if r.fileChanged() {
+					r.Reload(TriggerFile)
+				}
+			}
+		}
+	}()
+}
+
+// fileChanged проверяет, изменился ли файл настроек с прошлой проверки
+func (r *Reloader) fileChanged() bool {
+	if r.path == "" {
+		return false
+	}
+	info, err := os.Stat(r.path)
+	if err != nil {
+		// Отсутствие файла обнаружит Load при следующей перезагрузке
+		return false
+	}
+	if info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+		return false
+	}
+	r.modTime, r.size = info.ModTime(), info.Size()
+	return true
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func loadReloader(t *testing.T, content string) (*Reloader, string) {
+	t.Helper()
+	path := writeConfigFile(t, content)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return NewReloader(path, cfg), path
+}
+
+func rewriteConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	// Файл мог измениться в пределах разрешения часов файловой системы
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Failed to touch config file: %v", err)
+	}
+}
+
+func TestReloader_AppliesReloadableFields(t *testing.T) {
+	r, path := loadReloader(t, "rare_chance: 0.1\ngrpc_port: 9090\n")
+
+	var applied []*Config
+	r.Subscribe("rarity", func(cfg *Config) { applied = append(applied, cfg) })
+
+	rewriteConfigFile(t, path, "rare_chance: 0.5\ngeneration_rate_limit: 3\ngrpc_port: 9191\n")
+	result := r.Reload(TriggerAPI)
+
+	if result.Status != ReloadApplied {
+		t.Fatalf("Reload() status = %q (%s), want %q", result.Status, result.Error, ReloadApplied)
+	}
+	if want := []string{"rare_chance", "generation_rate_limit"}; !reflect.DeepEqual(result.Changed, want) {
+		t.Errorf("Reload() changed = %v, want %v", result.Changed, want)
+	}
+	if want := []string{"grpc_port"}; !reflect.DeepEqual(result.RestartRequired, want) {
+		t.Errorf("Reload() restart_required = %v, want %v", result.RestartRequired, want)
+	}
+	if len(applied) != 1 {
+		t.Fatalf("subscriber called %d times, want 1", len(applied))
+	}
+	// Настройки, требующие перезапуска, подписчики не видят
+	if applied[0].RareChance != 0.5 || applied[0].GenerationRateLimit != 3 || applied[0].GRPCPort != 9090 {
+		t.Errorf("applied config = rare %v, limit %d, grpc %d", applied[0].RareChance, applied[0].GenerationRateLimit, applied[0].GRPCPort)
+	}
+}
+
+func TestReloader_InvalidConfigAppliesNothing(t *testing.T) {
+	r, path := loadReloader(t, "rare_chance: 0.1\n")
+
+	called := false
+	r.Subscribe("rarity", func(*Config) { called = true })
+
+	rewriteConfigFile(t, path, "rare_chance: 0.5\nlog_level: loud\n")
+	result := r.Reload(TriggerSignal)
+
+	if result.Status != ReloadFailed || result.Error == "" {
+		t.Errorf("Reload() = %+v, want failed result with error", result)
+	}
+	if called {
+		t.Error("subscriber called for invalid configuration")
+	}
+
+	// После исправления файла применяется и то, что не прошло проверку раньше
+	rewriteConfigFile(t, path, "rare_chance: 0.5\n")
+	if result := r.Reload(TriggerSignal); result.Status != ReloadApplied || !called {
+		t.Errorf("Reload() after fix = %+v, subscriber called %v", result, called)
+	}
+}
+
+func TestReloader_Unchanged(t *testing.T) {
+	r, path := loadReloader(t, "rare_chance: 0.1\n")
+
+	called := false
+	r.Subscribe("rarity", func(*Config) { called = true })
+
+	rewriteConfigFile(t, path, "rare_chance: 0.1\nport: 8181\n")
+	result := r.Reload(TriggerAPI)
+	if result.Status != ReloadUnchanged || called {
+		t.Errorf("Reload() status = %q, subscriber called %v, want unchanged without call", result.Status, called)
+	}
+	if want := []string{"port"}; !reflect.DeepEqual(result.RestartRequired, want) {
+		t.Errorf("Reload() restart_required = %v, want %v", result.RestartRequired, want)
+	}
+}
+
+func TestReloader_History(t *testing.T) {
+	r, path := loadReloader(t, "rare_chance: 0.1\n")
+
+	for i := 0; i < maxReloadHistory+5; i++ {
+		r.Reload(TriggerAPI)
+	}
+	rewriteConfigFile(t, path, "rare_chance: 0.2\n")
+	r.Reload(TriggerFile)
+
+	history := r.History()
+	if len(history) != maxReloadHistory {
+		t.Fatalf("History() length = %d, want %d", len(history), maxReloadHistory)
+	}
+	if history[0].Trigger != TriggerFile || history[0].Status != ReloadApplied {
+		t.Errorf("History()[0] = %+v, want the latest reload first", history[0])
+	}
+}
+
+func TestReloader_WatchFile(t *testing.T) {
+	r, path := loadReloader(t, "log_level: info\n")
+
+	levels := make(chan string, 1)
+	r.Subscribe("logger", func(cfg *Config) { levels <- cfg.LogLevel })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Watch(ctx, 10*time.Millisecond)
+
+	rewriteConfigFile(t, path, "log_level: debug\n")
+	select {
+	case level := <-levels:
+		if level != "debug" {
+			t.Errorf("reloaded log level = %q, want debug", level)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("configuration was not reloaded after the file changed")
+	}
+}
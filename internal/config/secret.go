@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Secret хранит значение секрета, которое может обновляться во время работы.
+// Значение доступно только через Value: при выводе через fmt, в логах, JSON
+// и YAML секрет заменяется на MaskedValue.
+type Secret struct {
+	values atomic.Pointer[secretValues]
+}
+
+// secretValues - текущее и прежнее значения секрета, заменяются вместе
+type secretValues struct {
+	current  string
+	previous string
+}
+
+// NewSecret создает секрет с начальным значением
+func NewSecret(value string) *Secret {
+	s := &Secret{}
+	s.Set(value)
+	return s
+}
+
+// Value возвращает текущее значение секрета. Для nil секрета - пустую строку.
+func (s *Secret) Value() string {
+	current, _ := s.Values()
+	return current
+}
+
+// Previous возвращает значение, действовавшее до последней смены секрета, или
+// пустую строку. По нему проверяют подписи, сделанные до ротации.
+func (s *Secret) Previous() string {
+	_, previous := s.Values()
+	return previous
+}
+
+// Values возвращает текущее и прежнее значения секрета, прочитанные одновременно
+func (s *Secret) Values() (current, previous string) {
+	if s == nil {
+		return "", ""
+	}
+	if v := s.values.Load(); v != nil {
+		return v.current, v.previous
+	}
+	return "", ""
+}
+
+// Set заменяет значение секрета. Если значение изменилось, прежнее доступно через Previous.
+func (s *Secret) Set(value string) {
+	for {
+		old := s.values.Load()
+		next := &secretValues{current: value}
+		if old != nil {
+			if old.current == value {
+				return
+			}
+			next.previous = old.current
+		}
+		if s.values.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// IsSet проверяет, задано ли значение
+func (s *Secret) IsSet() bool {
+	return s.Value() != ""
+}
+
+func (s *Secret) masked() string {
+	if !s.IsSet() {
+		return ""
+	}
+	return MaskedValue
+}
+
+func (s *Secret) String() string {
+	return s.masked()
+}
+
+func (s *Secret) GoString() string {
+	return s.masked()
+}
+
+func (s *Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.masked())
+}
+
+func (s *Secret) MarshalYAML() (interface{}, error) {
+	return s.masked(), nil
+}
+
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	s.Set(value)
+	return nil
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSecret_ValueNeverPrinted(t *testing.T) {
+	secret := NewSecret("hunter2")
+	cfg := &Config{SessionSecret: secret}
+
+	outputs := []string{
+		fmt.Sprint(secret),
+		fmt.Sprintf("%s %v %+v %#v", secret, secret, cfg, cfg),
+	}
+	data, _ := json.Marshal(cfg)
+	outputs = append(outputs, string(data))
+
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.WithField("secret", secret).WithField("config", cfg).Info("loaded")
+	log.SetFormatter(&logrus.TextFormatter{DisableColors: true})
+	log.WithField("secret", secret).Info("loaded")
+	outputs = append(outputs, buf.String())
+
+	for _, out := range outputs {
+		if strings.Contains(out, "hunter2") {
+			t.Errorf("output contains secret value: %s", out)
+		}
+	}
+	if secret.Value() != "hunter2" {
+		t.Errorf("Value() = %q, want hunter2", secret.Value())
+	}
+
+	var missing *Secret
+	if missing.Value() != "" || missing.IsSet() || missing.String() != "" {
+		t.Error("nil secret should be empty")
+	}
+}
+
+func TestSecret_Previous(t *testing.T) {
+	secret := NewSecret("first")
+	if secret.Previous() != "" {
+		t.Errorf("Previous() before rotation = %q, want empty", secret.Previous())
+	}
+
+	secret.Set("second")
+	// Повторная установка того же значения не стирает прежнее
+	secret.Set("second")
+	if secret.Value() != "second" || secret.Previous() != "first" {
+		t.Errorf("after rotation Value() = %q, Previous() = %q, want second and first", secret.Value(), secret.Previous())
+	}
+}
+
+func TestSecret_ValuesConsistentDuringRotation(t *testing.T) {
+	secret := NewSecret("0")
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				secret.Set(strconv.Itoa(i))
+			}
+		}
+	}()
+
+	// Читатель всегда видит пару, в которой прежнее значение предшествует текущему
+	for i := 0; i < 10000; i++ {
+		current, previous := secret.Values()
+		n, _ := strconv.Atoi(current)
+		if n > 0 && previous != strconv.Itoa(n-1) {
+			t.Fatalf("Values() = %q, %q, want previous %d", current, previous, n-1)
+		}
+	}
+	close(stop)
+	<-done
+}
+
+func writeSecret(t *testing.T, dir, name, value string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	return path
+}
+
+func TestLoad_SecretsFromFileEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SESSION_SECRET", "")
+	t.Setenv("SESSION_SECRET_FILE", writeSecret(t, dir, "session", "session-from-file\n"))
+	t.Setenv("DATABASE_PASSWORD_FILE", writeSecret(t, dir, "db", "db-from-file"))
+	t.Setenv("DATABASE_URL_FILE", writeSecret(t, dir, "url", "postgres://factory:x@db:5432/factory\n"))
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.SessionSecret.Value() != "session-from-file" || cfg.DatabasePassword.Value() != "db-from-file" {
+		t.Errorf("secrets = %q, %q, want values from files", cfg.SessionSecret.Value(), cfg.DatabasePassword.Value())
+	}
+	if cfg.DatabaseURL != "postgres://factory:x@db:5432/factory" {
+		t.Errorf("DatabaseURL = %q, want value from file", cfg.DatabaseURL)
+	}
+
+	// Значение и файл одновременно - ошибка настройки
+	t.Setenv("SESSION_SECRET", "direct")
+	t.Setenv("KEYCLOAK_CLIENT_SECRET_FILE", filepath.Join(dir, "missing"))
+	_, err = Load("")
+	if err == nil || !strings.Contains(err.Error(), "SESSION_SECRET_FILE") || !strings.Contains(err.Error(), "KEYCLOAK_CLIENT_SECRET_FILE") {
+		t.Errorf("Load() error = %v, want both secret file problems", err)
+	}
+}
+
+func TestLoad_FileSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret(t, dir, "keycloak_client_secret", "client-v1")
+	t.Setenv("SECRETS_PROVIDER", SecretsProviderFile)
+	t.Setenv("SECRETS_DIR", dir)
+	t.Setenv("SESSION_SECRET", "session-from-env")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.KeycloakClientSecret.Value() != "client-v1" {
+		t.Errorf("KeycloakClientSecret = %q, want client-v1", cfg.KeycloakClientSecret.Value())
+	}
+	// Секреты, которых нет в провайдере, остаются из окружения
+	if cfg.SessionSecret.Value() != "session-from-env" {
+		t.Errorf("SessionSecret = %q, want value from env", cfg.SessionSecret.Value())
+	}
+
+	// Ротация: новое значение видно через тот же секрет
+	client := cfg.KeycloakClientSecret
+	writeSecret(t, dir, "keycloak_client_secret", "client-v2")
+	if err := cfg.RefreshSecrets(context.Background()); err != nil {
+		t.Fatalf("RefreshSecrets() error = %v", err)
+	}
+	if client.Value() != "client-v2" {
+		t.Errorf("KeycloakClientSecret after refresh = %q, want client-v2", client.Value())
+	}
+}
+
+// vaultStub отдает секреты в формате KV v2 и проверяет токен
+func vaultStub(t *testing.T, secrets map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/factory" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     secrets,
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLoad_VaultSecretProvider(t *testing.T) {
+	secrets := map[string]string{
+		"session_secret":         strings.Repeat("v", 32),
+		"keycloak_client_secret": "client-from-vault",
+		"database_password":      "db-from-vault",
+	}
+	vault := vaultStub(t, secrets)
+
+	t.Setenv("GIN_MODE", ModeRelease)
+	t.Setenv("DATABASE_URL", "postgres://factory@db:5432/factory")
+	t.Setenv("SECRETS_PROVIDER", SecretsProviderVault)
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_SECRET_PATH", "secret/data/factory")
+	t.Setenv("VAULT_TOKEN_FILE", writeSecret(t, t.TempDir(), "token", "vault-token"))
+
+	// Секреты из Vault удовлетворяют требованиям release режима
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.KeycloakClientSecret.Value() != "client-from-vault" || cfg.DatabasePassword.Value() != "db-from-vault" {
+		t.Errorf("secrets = %q, %q, want values from vault", cfg.KeycloakClientSecret.Value(), cfg.DatabasePassword.Value())
+	}
+
+	secrets["keycloak_client_secret"] = "client-rotated"
+	if err := cfg.RefreshSecrets(context.Background()); err != nil {
+		t.Fatalf("RefreshSecrets() error = %v", err)
+	}
+	if cfg.KeycloakClientSecret.Value() != "client-rotated" {
+		t.Errorf("KeycloakClientSecret after refresh = %q, want client-rotated", cfg.KeycloakClientSecret.Value())
+	}
+
+	cfg.VaultToken.Set("wrong-token")
+	if err := cfg.RefreshSecrets(context.Background()); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("RefreshSecrets() with wrong token error = %v, want 403", err)
+	}
+	if cfg.KeycloakClientSecret.Value() != "client-rotated" {
+		t.Error("failed refresh should keep previous secrets")
+	}
+}
+
+func TestLoad_VaultProviderRequiresSettings(t *testing.T) {
+	t.Setenv("SECRETS_PROVIDER", SecretsProviderVault)
+
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("Load() error = nil, want missing vault settings")
+	}
+	for _, key := range []string{"vault_addr", "vault_token", "vault_secret_path"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Load() error = %v, want %s listed", err, key)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"factory/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FieldError описывает неверное значение одной настройки
+type FieldError struct {
+	// Key - ключ в YAML файле
+	Key string
+	// Env - переменная окружения
+	Env     string
+	Message string
+}
+
+func (e FieldError) String() string {
+	return fmt.Sprintf("%s (%s): %s", e.Key, e.Env, e.Message)
+}
+
+// ValidationError перечисляет все неверные настройки
+type ValidationError []FieldError
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(*e))
+	for i, problem := range *e {
+		problems[i] = problem.String()
+	}
+	return "invalid configuration: " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) add(field reflect.StructField, message string) {
+	*e = append(*e, FieldError{Key: field.Tag.Get("yaml"), Env: field.Tag.Get("env"), Message: message})
+}
+
+// Допустимые значения перечислимых настроек. Совпадают с константами
+// keycloak.VerifyMode* и session.Store*, которые импортируют этот пакет.
+var (
+	verifyModes   = []string{"jwks", "userinfo"}
+	sessionStores = []string{"database", "memory"}
+	modes         = []string{ModeDebug, ModeRelease, ModeTest}
+	logFormats    = []string{logger.FormatJSON, logger.FormatText}
+	// Пустое значение - секреты только из файла настроек и переменных окружения
+	secretsProviders = []string{"", SecretsProviderFile, SecretsProviderVault}
+)
+
+// Validate проверяет все настройки и возвращает *ValidationError со списком
+// неверных полей. В release режиме небезопасные значения по умолчанию запрещены.
+func (c *Config) Validate() error {
+	var problems ValidationError
+	check := func(name string, ok bool, message string) {
+		if ok {
+			return
+		}
+		field, _ := reflect.TypeOf(c).Elem().FieldByName(name)
+		problems.add(field, message)
+	}
+	oneOf := func(name, value string, allowed []string) {
+		for _, v := range allowed {
+			if value == v {
+				return
+			}
+		}
+		check(name, false, "must be one of "+strings.Join(allowed, ", "))
+	}
+	validURL := func(name, value string, optional bool) {
+		if value == "" && optional {
+			return
+		}
+		u, err := url.Parse(value)
+		check(name, err == nil && u.Scheme != "" && u.Host != "", "must be an absolute URL")
+	}
+
+	oneOf("Mode", c.Mode, modes)
+	check("Port", c.Port > 0 && c.Port < 65536, "must be a port between 1 and 65535")
+	check("GRPCPort", c.GRPCPort > 0 && c.GRPCPort < 65536, "must be a port between 1 and 65535")
+
+	validURL("DatabaseURL", c.DatabaseURL, false)
+	validURL("KeycloakURL", c.KeycloakURL, false)
+	validURL("KeycloakInternalURL", c.KeycloakInternalURL, false)
+	validURL("KeycloakIssuer", c.KeycloakIssuer, true)
+	validURL("PlumbusServiceURL", c.PlumbusServiceURL, false)
+	validURL("SigStoreURL", c.SigStoreURL, false)
+	validURL("NatsURL", c.NatsURL, false)
+	validURL("OTLPEndpoint", c.OTLPEndpoint, true)
+
+	check("KeycloakRealm", c.KeycloakRealm != "", "is required")
+	check("KeycloakClientID", c.KeycloakClientID != "", "is required")
+	oneOf("KeycloakVerifyMode", c.KeycloakVerifyMode, verifyModes)
+	check("SessionSecret", c.SessionSecret.IsSet(), "is required")
+	oneOf("SessionStore", c.SessionStore, sessionStores)
+	check("NatsTopic", c.NatsTopic != "", "is required")
+	check("EventSource", c.EventSource != "", "is required")
+	check("OTelServiceName", c.OTelServiceName != "", "is required")
+
+	check("PlumbusRestoreWindow", c.PlumbusRestoreWindow > 0, "must be positive")
+	check("PlumbusMaxAttempts", c.PlumbusMaxAttempts >= 1, "must be at least 1")
+	// Генерация дольше таймаутов не длится: иначе сборщик зависших плюмбусов заберет живую генерацию
+	check("PlumbusStuckAfter", c.PlumbusStuckAfter > c.GeneratorTimeout+c.SigStoreTimeout,
+		"must be longer than generator_timeout plus sig_store_timeout")
+	check("ReaperInterval", c.ReaperInterval >= 0, "must not be negative")
+	check("ImageGCInterval", c.ImageGCInterval >= 0, "must not be negative")
+	check("ImageGCGrace", c.ImageGCGrace >= 0, "must not be negative")
+	check("RareChance", c.RareChance >= 0 && c.RareChance <= 1, "must be between 0 and 1")
+	check("GenerationRateLimit", c.GenerationRateLimit >= 0, "must not be negative")
+	check("GeneratorTimeout", c.GeneratorTimeout > 0, "must be positive")
+	check("SigStoreTimeout", c.SigStoreTimeout > 0, "must be positive")
+	check("ShutdownTimeout", c.ShutdownTimeout > 0, "must be positive")
+	check("HealthCheckTimeout", c.HealthCheckTimeout > 0, "must be positive")
+	check("HealthCacheTTL", c.HealthCacheTTL >= 0, "must not be negative")
+
+	_, err := logrus.ParseLevel(c.LogLevel)
+	check("LogLevel", err == nil, "must be one of trace, debug, info, warn, error")
+	oneOf("LogFormat", c.LogFormat, logFormats)
+	_, err = logger.ParseLevels(c.LogLevels)
+	check("LogLevels", err == nil, "must be a list like database=debug,http=warn")
+	check("ConfigWatchInterval", c.ConfigWatchInterval >= 0, "must not be negative")
+
+	oneOf("SecretsProvider", c.SecretsProvider, secretsProviders)
+	switch c.SecretsProvider {
+	case SecretsProviderFile:
+		check("SecretsDir", c.SecretsDir != "", "is required for the file secrets provider")
+	case SecretsProviderVault:
+		validURL("VaultAddr", c.VaultAddr, false)
+		check("VaultToken", c.VaultToken.IsSet(), "is required for the vault secrets provider")
+		check("VaultSecretPath", c.VaultSecretPath != "", "is required for the vault secrets provider")
+	}
+	if c.SecretsProvider != "" {
+		check("SecretsRefreshInterval", c.SecretsRefreshInterval > 0, "must be positive")
+	}
+
+	if c.Mode == ModeRelease {
+		check("SessionSecret", !c.UsesDefaultSessionSecret() && len(c.SessionSecret.Value()) >= 32,
+			"must be a random value of at least 32 characters in release mode")
+		check("KeycloakClientSecret", c.KeycloakClientSecret.IsSet(), "is required in release mode")
+		check("DatabaseURL", c.DatabaseURL != defaultDatabaseURL, "must not use the default credentials in release mode")
+	}
+
+	if len(problems) > 0 {
+		return &problems
+	}
+	return nil
+}
+
+// UsesDefaultSessionSecret проверяет, что секрет сессий не задан и действует
+// небезопасное значение по умолчанию
+func (c *Config) UsesDefaultSessionSecret() bool {
+	return c.SessionSecret.Value() == defaultSessionSecret
+}
@@ -1,35 +1,156 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"factory/internal/config"
+	"factory/internal/logger"
 	"factory/internal/models"
+	"factory/internal/tracing"
 	"fmt"
-	"log"
 	"net/url"
-	"os"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormlogger "gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
+	"gorm.io/gorm/utils"
 )
 
-func Initialize(cfg *config.Config) (*gorm.DB, error) {
-	// Настраиваем уровень логирования GORM
-	var logLevel logger.LogLevel
-	envLogLevel := strings.ToLower(os.Getenv("LOG_LEVEL"))
-
-	switch envLogLevel {
-	case "trace", "debug":
-		logLevel = logger.Info // Показываем SQL запросы в debug/trace режиме
-	case "warn", "warning":
-		logLevel = logger.Warn
-	case "error":
-		logLevel = logger.Error
+// slowQueryThreshold - запросы дольше этого времени пишутся в лог как медленные
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger пишет логи GORM через логгер компонента database. Уровень GORM
+// следует уровню компонента и определяется при каждой записи, поэтому смена
+// LOG_LEVELS при перезагрузке конфигурации действует без переподключения:
+// SQL запросы видны только в debug и trace.
+func gormLogger(log *logrus.Logger) gormlogger.Interface {
+	return &levelLogger{log: log}
+}
+
+// gormLevel сопоставляет уровень логгера компонента уровню GORM
+func gormLevel(level logrus.Level) gormlogger.LogLevel {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return gormlogger.Info
+	case logrus.InfoLevel:
+		return gormlogger.Silent // По умолчанию скрываем SQL запросы
+	case logrus.WarnLevel:
+		return gormlogger.Warn
 	default:
-		logLevel = logger.Silent // По умолчанию скрываем SQL запросы
+		return gormlogger.Error
+	}
+}
+
+// levelLogger пишет записи GORM в логгер компонента с соответствующим уровнем logrus
+// и полями запроса из контекста
+type levelLogger struct {
+	log *logrus.Logger
+	// level задан через LogMode, например db.Debug(). Ноль - следовать уровню компонента.
+	level gormlogger.LogLevel
+}
+
+func (l *levelLogger) current() gormlogger.LogLevel {
+	if l.level != 0 {
+		return l.level
+	}
+	return gormLevel(l.log.GetLevel())
+}
+
+// LogMode фиксирует уровень и больше не следует уровню компонента
+func (l *levelLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &levelLogger{log: l.log, level: level}
+}
+
+func (l *levelLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.current() >= gormlogger.Info {
+		l.log.WithContext(ctx).Debugf(msg, data...)
+	}
+}
+
+func (l *levelLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.current() >= gormlogger.Warn {
+		l.log.WithContext(ctx).Warnf(msg, data...)
+	}
+}
+
+func (l *levelLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.current() >= gormlogger.Error {
+		l.log.WithContext(ctx).Errorf(msg, data...)
+	}
+}
+
+// Trace пишет ошибки запросов в error, медленные запросы в warn, остальные - в debug
+func (l *levelLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	level := l.current()
+	if level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	entry := func() *logrus.Entry {
+		sql, rows := fc()
+		return l.log.WithContext(ctx).WithFields(logrus.Fields{
+			"sql":         sql,
+			"rows":        rows,
+			"duration_ms": float64(elapsed.Microseconds()) / 1000,
+			"source":      utils.FileWithLineNum(),
+		})
+	}
+
+	switch {
+	case err != nil && level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		entry().WithError(err).Error("SQL query failed")
+	case elapsed > slowQueryThreshold && level >= gormlogger.Warn:
+		entry().WithField("threshold", slowQueryThreshold.String()).Warn("Slow SQL query")
+	case level >= gormlogger.Info:
+		entry().Debug("SQL query")
+	}
+}
+
+// open подключается к БД по URL. Пароль из DATABASE_PASSWORD, если он задан,
+// заменяет пароль из URL и читается при каждом новом соединении, поэтому пул
+// подхватывает обновленный провайдером секрет без перезапуска.
+func open(dsn string, password *config.Secret, gormConfig *gorm.Config) (*gorm.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+
+	sqlDB := stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(func(ctx context.Context, cc *pgx.ConnConfig) error {
+		if password.IsSet() {
+			cc.Password = password.Value()
+		}
+		return nil
+	}))
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), gormConfig)
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Initialize подключается к базе данных, при необходимости создавая ее, и применяет миграции
+func Initialize(cfg *config.Config) (*gorm.DB, error) {
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(db); err != nil {
+		return nil, err
 	}
+	return db, nil
+}
+
+// Connect подключается к базе данных, при необходимости создавая ее, без миграций
+func Connect(cfg *config.Config) (*gorm.DB, error) {
+	log := logger.For("database")
 
 	// Парсим URL для получения параметров подключения
 	parsedURL, err := url.Parse(cfg.DatabaseURL)
@@ -47,8 +168,8 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 	systemDBURL := strings.Replace(cfg.DatabaseURL, "/"+dbName, "/postgres", 1)
 
 	// Подключаемся к системной БД
-	systemDB, err := gorm.Open(postgres.Open(systemDBURL), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent), // Всегда скрываем логи системной БД
+	systemDB, err := open(systemDBURL, cfg.DatabasePassword, &gorm.Config{
+		Logger: gormlogger.Discard, // Всегда скрываем логи системной БД
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to system database: %w", err)
@@ -69,15 +190,15 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 
 	// Создаем БД если не существует
 	if !exists {
-		log.Printf("Database '%s' does not exist, creating...", dbName)
+		log.WithField("database", dbName).Info("Database does not exist, creating")
 		createQuery := fmt.Sprintf("CREATE DATABASE %s WITH TEMPLATE template0", dbName)
 		err = systemDB.Exec(createQuery).Error
 		if err != nil {
 			return nil, fmt.Errorf("failed to create database '%s': %w", dbName, err)
 		}
-		log.Printf("Database '%s' created successfully", dbName)
+		log.WithField("database", dbName).Info("Database created")
 	} else {
-		log.Printf("Database '%s' already exists", dbName)
+		log.WithField("database", dbName).Info("Database already exists")
 	}
 
 	// Закрываем соединение с системной БД
@@ -88,8 +209,8 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 	sqlDB.Close()
 
 	// Теперь подключаемся к целевой БД с дополнительными настройками
-	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+	db, err := open(cfg.DatabaseURL, cfg.DatabasePassword, &gorm.Config{
+		Logger: gormLogger(log),
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true, // Используем имена таблиц в единственном числе
 		},
@@ -98,6 +219,11 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// Каждый запрос попадает в трейс, переданный через db.WithContext
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to enable query tracing: %w", err)
+	}
+
 	// Создаем расширение uuid-ossp в целевой БД
 	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\" WITH SCHEMA public;").Error; err != nil {
 		return nil, fmt.Errorf("failed to create uuid extension in target database: %w", err)
@@ -113,37 +239,141 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("uuid-ossp extension was not created")
 	}
 
-	log.Printf("Starting database migration...")
+	return db, nil
+}
+
+// Migrate создает недостающие таблицы и колонки
+func Migrate(db *gorm.DB) error {
+	log := logger.For("database")
+	log.Info("Starting database migration")
 
 	// Проверяем существование таблиц и создаем их, если они отсутствуют
 	if !db.Migrator().HasTable(&models.User{}) {
-		log.Printf("Creating users table...")
+		log.Info("Creating users table")
 		if err := db.Migrator().CreateTable(&models.User{}); err != nil {
-			return nil, fmt.Errorf("failed to create users table: %w", err)
+			return fmt.Errorf("failed to create users table: %w", err)
 		}
 	} else {
-		log.Printf("Users table already exists")
+		log.Info("Users table already exists")
 	}
 
 	if !db.Migrator().HasTable(&models.Plumbus{}) {
-		log.Printf("Creating plumbuses table...")
+		log.Info("Creating plumbuses table")
 		if err := db.Migrator().CreateTable(&models.Plumbus{}); err != nil {
-			return nil, fmt.Errorf("failed to create plumbuses table: %w", err)
+			return fmt.Errorf("failed to create plumbuses table: %w", err)
+		}
+	} else {
+		log.Info("Plumbuses table already exists")
+	}
+
+	if !db.Migrator().HasTable(&models.Session{}) {
+		log.Info("Creating sessions table")
+		if err := db.Migrator().CreateTable(&models.Session{}); err != nil {
+			return fmt.Errorf("failed to create sessions table: %w", err)
+		}
+	} else {
+		log.Info("Sessions table already exists")
+	}
+
+	if !db.Migrator().HasTable(&models.APIToken{}) {
+		log.Info("Creating API tokens table")
+		if err := db.Migrator().CreateTable(&models.APIToken{}); err != nil {
+			return fmt.Errorf("failed to create API tokens table: %w", err)
+		}
+	} else {
+		log.Info("API tokens table already exists")
+	}
+
+	if !db.Migrator().HasTable(&models.Webhook{}) {
+		log.Info("Creating webhooks table")
+		if err := db.Migrator().CreateTable(&models.Webhook{}); err != nil {
+			return fmt.Errorf("failed to create webhooks table: %w", err)
+		}
+	} else {
+		log.Info("Webhooks table already exists")
+	}
+
+	if !db.Migrator().HasTable(&models.WebhookDelivery{}) {
+		log.Info("Creating webhook deliveries table")
+		if err := db.Migrator().CreateTable(&models.WebhookDelivery{}); err != nil {
+			return fmt.Errorf("failed to create webhook deliveries table: %w", err)
+		}
+	} else {
+		log.Info("Webhook deliveries table already exists")
+	}
+
+	if !db.Migrator().HasTable(&models.PlumbusAttempt{}) {
+		log.Info("Creating plumbus attempts table")
+		if err := db.Migrator().CreateTable(&models.PlumbusAttempt{}); err != nil {
+			return fmt.Errorf("failed to create plumbus attempts table: %w", err)
 		}
 	} else {
-		log.Printf("Plumbuses table already exists")
+		log.Info("Plumbus attempts table already exists")
+	}
+
+	// Добавляем колонки, появившиеся после создания таблиц
+	if err := addMissingColumns(db, &models.Session{}, "IDToken", "Subject", "KeycloakSessionID"); err != nil {
+		return err
+	}
+	if err := addMissingColumns(db, &models.Plumbus{}, "DeletedAt", "Attempts", "Interrupted", "Broken"); err != nil {
+		return err
 	}
 
 	// Проверяем, что таблицы существуют
-	log.Printf("Verifying table existence...")
+	log.Info("Verifying table existence")
 	if !db.Migrator().HasTable(&models.User{}) {
-		return nil, fmt.Errorf("users table was not created")
+		return fmt.Errorf("users table was not created")
 	}
 	if !db.Migrator().HasTable(&models.Plumbus{}) {
-		return nil, fmt.Errorf("plumbuses table was not created")
+		return fmt.Errorf("plumbuses table was not created")
+	}
+	if !db.Migrator().HasTable(&models.Session{}) {
+		return fmt.Errorf("sessions table was not created")
+	}
+	if !db.Migrator().HasTable(&models.APIToken{}) {
+		return fmt.Errorf("API tokens table was not created")
+	}
+	if !db.Migrator().HasTable(&models.Webhook{}) {
+		return fmt.Errorf("webhooks table was not created")
+	}
+	if !db.Migrator().HasTable(&models.WebhookDelivery{}) {
+		return fmt.Errorf("webhook deliveries table was not created")
+	}
+	if !db.Migrator().HasTable(&models.PlumbusAttempt{}) {
+		return fmt.Errorf("plumbus attempts table was not created")
 	}
 
-	log.Printf("Database migration completed successfully")
+	log.Info("Database migration completed successfully")
+	return nil
+}
 
-	return db, nil
+// addMissingColumns добавляет в существующую таблицу колонки (и их индексы) для указанных полей модели
+func addMissingColumns(db *gorm.DB, model interface{}, fields ...string) error {
+	migrator := db.Migrator()
+	for _, field := range fields {
+		if migrator.HasColumn(model, field) {
+			continue
+		}
+		logger.For("database").WithFields(logrus.Fields{
+			"column": field,
+			"model":  fmt.Sprintf("%T", model),
+		}).Info("Adding column")
+		if err := migrator.AddColumn(model, field); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", field, err)
+		}
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return fmt.Errorf("failed to parse model %T: %w", model, err)
+	}
+	for _, index := range stmt.Schema.ParseIndexes() {
+		if !migrator.HasIndex(model, index.Name) {
+			if err := migrator.CreateIndex(model, index.Name); err != nil {
+				return fmt.Errorf("failed to create index %s: %w", index.Name, err)
+			}
+		}
+	}
+
+	return nil
 }
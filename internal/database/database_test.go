@@ -0,0 +1,63 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestGormLogger_FollowsComponentLevel(t *testing.T) {
+	var out bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&out)
+	log.SetLevel(logrus.InfoLevel)
+	gorm := gormLogger(log)
+
+	query := func() (string, int64) { return "SELECT 42", 1 }
+	gorm.Trace(context.Background(), time.Now(), query, nil)
+	if strings.Contains(out.String(), "SELECT 42") {
+		t.Fatalf("query logged at info level: %q", out.String())
+	}
+
+	// Уровень компонента меняется при перезагрузке конфигурации
+	log.SetLevel(logrus.DebugLevel)
+	gorm.Trace(context.Background(), time.Now(), query, nil)
+	if !strings.Contains(out.String(), "SELECT 42") {
+		t.Errorf("query not logged after switching to debug: %q", out.String())
+	}
+}
+
+func TestGormLogger_SlowAndFailedQueriesAtComponentLevel(t *testing.T) {
+	var out bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&out)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.SetLevel(logrus.WarnLevel)
+	gorm := gormLogger(log)
+
+	query := func() (string, int64) { return "SELECT pg_sleep(1)", 1 }
+	gorm.Trace(context.Background(), time.Now(), query, nil)
+	gorm.Trace(context.Background(), time.Now().Add(-time.Second), query, nil)
+	gorm.Trace(context.Background(), time.Now(), query, errors.New("relation does not exist"))
+
+	var levels []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		if record["sql"] != "SELECT pg_sleep(1)" {
+			t.Errorf("record = %v, want sql field", record)
+		}
+		levels = append(levels, record["level"].(string))
+	}
+	if strings.Join(levels, ",") != "warning,error" {
+		t.Errorf("logged levels = %v, want slow query at warning and failed query at error", levels)
+	}
+}
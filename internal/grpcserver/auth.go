@@ -0,0 +1,100 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"factory/internal/keycloak"
+	"factory/pkg/factorypb"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodRoles - минимальная роль Keycloak для каждого метода (по умолчанию viewer)
+var methodRoles = map[string]string{
+	factorypb.PlumbusService_CreatePlumbus_FullMethodName: keycloak.RoleOperator,
+}
+
+// caller - аутентифицированный пользователь запроса
+type caller struct {
+	userID uuid.UUID
+	claims *keycloak.Claims
+}
+
+type callerKey struct{}
+
+// callerFromContext возвращает пользователя, установленный интерсептором аутентификации
+func callerFromContext(ctx context.Context) *caller {
+	c, _ := ctx.Value(callerKey{}).(*caller)
+	return c
+}
+
+// authenticate проверяет bearer токен Keycloak из метаданных и роль для метода
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "bearer token required")
+	}
+
+	scheme, token, found := strings.Cut(values[0], " ")
+	token = strings.TrimSpace(token)
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "bearer token required")
+	}
+
+	claims, err := s.keycloakClient.VerifyBearerToken(ctx, token)
+	if err != nil {
+		s.logger.WithError(err).Debug("gRPC bearer token verification failed")
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	role, ok := methodRoles[method]
+	if !ok {
+		role = keycloak.RoleViewer
+	}
+	if !claims.HasRole(role) {
+		s.logger.WithField("method", method).WithField("required_role", role).Warn("gRPC access denied: insufficient role")
+		return nil, status.Error(codes.PermissionDenied, "insufficient role")
+	}
+
+	user, err := s.userService.GetOrCreateUser(claims.Subject, claims.PreferredUsername, claims.Email)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to create/get gRPC user")
+		return nil, status.Error(codes.Internal, "database error")
+	}
+
+	return context.WithValue(ctx, callerKey{}, &caller{userID: user.ID, claims: claims}), nil
+}
+
+// unaryAuthInterceptor аутентифицирует унарные вызовы
+func (s *Server) unaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamAuthInterceptor аутентифицирует потоковые вызовы
+func (s *Server) streamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream подменяет контекст потока контекстом с пользователем
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,237 @@
+// Package grpcserver реализует gRPC API фабрики (factory.v1.PlumbusService)
+// поверх тех же сервисов, что и HTTP обработчики.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"factory/internal/keycloak"
+	"factory/internal/logger"
+	"factory/internal/models"
+	"factory/internal/services"
+	"factory/pkg/factorypb"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// DefaultWatchInterval - период опроса статуса плюмбуса в WatchPlumbus
+const DefaultWatchInterval = time.Second
+
+type Server struct {
+	factorypb.UnimplementedPlumbusServiceServer
+
+	userService       *services.UserService
+	generationService *services.GenerationService
+	keycloakClient    *keycloak.Client
+	logger            *logrus.Logger
+
+	// WatchInterval - период опроса статуса плюмбуса в WatchPlumbus
+	WatchInterval time.Duration
+}
+
+func New(us *services.UserService, gs *services.GenerationService, kc *keycloak.Client) *Server {
+	return &Server{
+		userService:       us,
+		generationService: gs,
+		keycloakClient:    kc,
+		logger:            logger.For("grpc"),
+		WatchInterval:     DefaultWatchInterval,
+	}
+}
+
+// NewGRPCServer создает gRPC сервер с аутентификацией и зарегистрированным PlumbusService
+func (s *Server) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(s.unaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(s.streamAuthInterceptor),
+	)
+	server := grpc.NewServer(opts...)
+	factorypb.RegisterPlumbusServiceServer(server, s)
+	return server
+}
+
+func (s *Server) CreatePlumbus(ctx context.Context, req *factorypb.CreatePlumbusRequest) (*factorypb.CreatePlumbusResponse, error) {
+	plumbusReq := models.PlumbusRequest{
+		Name:     req.GetName(),
+		Size:     req.GetSize(),
+		Color:    req.GetColor(),
+		Shape:    req.GetShape(),
+		Weight:   req.GetWeight(),
+		Wrapping: req.GetWrapping(),
+	}
+	for field, value := range map[string]string{
+		"name":     plumbusReq.Name,
+		"size":     plumbusReq.Size,
+		"color":    plumbusReq.Color,
+		"shape":    plumbusReq.Shape,
+		"weight":   plumbusReq.Weight,
+		"wrapping": plumbusReq.Wrapping,
+	} {
+		if value == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is required", field)
+		}
+	}
+
+	c := callerFromContext(ctx)
+	plumbus, err := s.generationService.Start(ctx, c.userID, plumbusReq)
+	if errors.Is(err, services.ErrRateLimited) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", c.userID).Error("Failed to create plumbus")
+		return nil, status.Error(codes.Internal, "database error")
+	}
+
+	return &factorypb.CreatePlumbusResponse{Plumbus: toProto(plumbus)}, nil
+}
+
+func (s *Server) GetPlumbus(ctx context.Context, req *factorypb.GetPlumbusRequest) (*factorypb.GetPlumbusResponse, error) {
+	plumbus, err := s.getPlumbus(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &factorypb.GetPlumbusResponse{Plumbus: toProto(plumbus)}, nil
+}
+
+func (s *Server) ListPlumbuses(ctx context.Context, _ *factorypb.ListPlumbusesRequest) (*factorypb.ListPlumbusesResponse, error) {
+	c := callerFromContext(ctx)
+	plumbuses, err := s.userService.GetUserPlumbuses(c.userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", c.userID).Error("Failed to get user plumbuses")
+		return nil, status.Error(codes.Internal, "database error")
+	}
+
+	resp := &factorypb.ListPlumbusesResponse{Plumbuses: make([]*factorypb.Plumbus, len(plumbuses))}
+	for i := range plumbuses {
+		resp.Plumbuses[i] = toProto(&plumbuses[i])
+	}
+	return resp, nil
+}
+
+func (s *Server) WatchPlumbus(req *factorypb.WatchPlumbusRequest, stream factorypb.PlumbusService_WatchPlumbusServer) error {
+	ctx := stream.Context()
+	plumbus, err := s.getPlumbus(ctx, req.GetId())
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.WatchInterval)
+	defer ticker.Stop()
+
+	var last models.PlumbusStatus
+	for {
+		// Отправляем плюмбус только при изменении статуса
+		if plumbus.Status != last {
+			if err := stream.Send(&factorypb.WatchPlumbusResponse{Plumbus: toProto(plumbus)}); err != nil {
+				return err
+			}
+			last = plumbus.Status
+		}
+		if plumbus.Status == models.StatusCompleted || plumbus.Status == models.StatusFailed ||
+			plumbus.Status == models.StatusCancelled {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+
+		plumbus, err = s.userService.GetPlumbus(plumbus.ID)
+		if err != nil {
+			// Плюмбус удалили во время наблюдения
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.logger.WithContext(ctx).WithField("plumbus_id", req.GetId()).Info("Watched plumbus deleted")
+				return status.Error(codes.NotFound, "plumbus not found")
+			}
+			s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", req.GetId()).Error("Failed to poll plumbus status")
+			return status.Error(codes.Internal, "database error")
+		}
+	}
+}
+
+func (s *Server) VerifyPlumbus(ctx context.Context, req *factorypb.VerifyPlumbusRequest) (*factorypb.VerifyPlumbusResponse, error) {
+	plumbus, err := s.getPlumbus(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if plumbus.Status != models.StatusCompleted || plumbus.Signature == nil {
+		return nil, status.Error(codes.FailedPrecondition, "plumbus is not signed")
+	}
+
+	valid, err := s.generationService.Verify(ctx, plumbus)
+	if err != nil {
+		s.logger.WithError(err).WithField("plumbus_id", plumbus.ID).Error("Failed to verify plumbus signature")
+		return nil, status.Error(codes.Unavailable, "signature verification failed")
+	}
+
+	return &factorypb.VerifyPlumbusResponse{Valid: valid}, nil
+}
+
+// getPlumbus загружает плюмбус и проверяет, что он принадлежит пользователю запроса.
+// Чужие плюмбусы доступны только администраторам, остальным возвращается NotFound.
+func (s *Server) getPlumbus(ctx context.Context, rawID string) (*models.Plumbus, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid plumbus id")
+	}
+
+	plumbus, err := s.userService.GetPlumbus(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "plumbus not found")
+		}
+		s.logger.WithError(err).WithField("plumbus_id", id).Error("Failed to get plumbus")
+		return nil, status.Error(codes.Internal, "database error")
+	}
+
+	c := callerFromContext(ctx)
+	if plumbus.UserID != c.userID && !c.claims.HasRole(keycloak.RoleAdmin) {
+		return nil, status.Error(codes.NotFound, "plumbus not found")
+	}
+	return plumbus, nil
+}
+
+var statuses = map[models.PlumbusStatus]factorypb.PlumbusStatus{
+	models.StatusPending:    factorypb.PlumbusStatus_PLUMBUS_STATUS_PENDING,
+	models.StatusGenerating: factorypb.PlumbusStatus_PLUMBUS_STATUS_GENERATING,
+	models.StatusCompleted:  factorypb.PlumbusStatus_PLUMBUS_STATUS_COMPLETED,
+	models.StatusFailed:     factorypb.PlumbusStatus_PLUMBUS_STATUS_FAILED,
+	models.StatusCancelled:  factorypb.PlumbusStatus_PLUMBUS_STATUS_CANCELLED,
+}
+
+// toProto преобразует модель плюмбуса в сообщение gRPC
+func toProto(p *models.Plumbus) *factorypb.Plumbus {
+	msg := &factorypb.Plumbus{
+		Id:        p.ID.String(),
+		Name:      p.Name,
+		Size:      p.Size,
+		Color:     p.Color,
+		Shape:     p.Shape,
+		Weight:    p.Weight,
+		Wrapping:  p.Wrapping,
+		Status:    statuses[p.Status],
+		IsRare:    p.IsRare,
+		CreatedAt: timestamppb.New(p.CreatedAt),
+		UpdatedAt: timestamppb.New(p.UpdatedAt),
+	}
+	if p.Signature != nil {
+		msg.Signature = *p.Signature
+	}
+	if p.SignatureDate != nil {
+		msg.SignatureDate = timestamppb.New(*p.SignatureDate)
+	}
+	if p.ErrorMsg != nil {
+		msg.Error = *p.ErrorMsg
+	}
+	return msg
+}
@@ -0,0 +1,349 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/keycloak"
+	"factory/internal/models"
+	"factory/internal/services"
+	"factory/internal/testutils"
+	"factory/pkg/factorypb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type grpcTestEnv struct {
+	provider    *testutils.FakeOIDCProvider
+	client      factorypb.PlumbusServiceClient
+	userService *services.UserService
+	generation  *services.GenerationService
+	// validSignature - ответ фейкового sig-store на проверку подписи
+	validSignature bool
+}
+
+// setupGRPCTest запускает сервер на bufconn с фейковыми Keycloak, генератором и sig-store
+func setupGRPCTest(t *testing.T) *grpcTestEnv {
+	t.Helper()
+	env := &grpcTestEnv{provider: testutils.NewFakeOIDCProvider(t), validSignature: true}
+
+	// Изображения сохраняются в storage/images относительно рабочего каталога
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change working directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(testutils.CreateTestPNGData())
+	}))
+	t.Cleanup(generator.Close)
+
+	sigStore := http.NewServeMux()
+	sigStore.HandleFunc("/api/v1/register", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":         42,
+			"signature":  "c2lnbmF0dXJlLW9mLXRoZS1wbHVtYnVz",
+			"created_at": time.Now().UTC(),
+		})
+	})
+	sigStore.HandleFunc("/api/v1/verify", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		json.NewEncoder(w).Encode(map[string]interface{}{"valid": env.validSignature, "message": "checked"})
+	})
+	sigServer := httptest.NewServer(sigStore)
+	t.Cleanup(sigServer.Close)
+
+	cfg := &config.Config{
+		KeycloakURL:          env.provider.URL,
+		KeycloakInternalURL:  env.provider.URL,
+		KeycloakRealm:        testutils.FakeOIDCRealm,
+		KeycloakClientID:     testutils.FakeOIDCClientID,
+		KeycloakClientSecret: config.NewSecret(testutils.FakeOIDCClientSecret),
+		KeycloakVerifyMode:   keycloak.VerifyModeJWKS,
+		PlumbusServiceURL:    generator.URL,
+		SigStoreURL:          sigServer.URL,
+	}
+
+	db := testutils.SetupTestDB(t)
+	if err := db.AutoMigrate(&models.PlumbusAttempt{}); err != nil {
+		t.Fatalf("Failed to migrate plumbus attempts table: %v", err)
+	}
+	env.userService = services.NewUserService(db)
+	env.generation = services.NewGenerationService(env.userService, services.NewPlumbusService(cfg), services.NewSignatureService(cfg), nil, nil)
+
+	srv := New(env.userService, env.generation, keycloak.NewClient(cfg))
+	srv.WatchInterval = 10 * time.Millisecond
+	server := srv.NewGRPCServer()
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	env.client = factorypb.NewPlumbusServiceClient(conn)
+	return env
+}
+
+// authContext возвращает контекст с access токеном текущего пользователя провайдера
+func (e *grpcTestEnv) authContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+e.provider.AccessToken(t))
+}
+
+func createRequest(name string) *factorypb.CreatePlumbusRequest {
+	return &factorypb.CreatePlumbusRequest{
+		Name: name, Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+	}
+}
+
+func assertCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Errorf("code = %v, want %v (%v)", got, want, err)
+	}
+}
+
+func TestGRPC_RequiresBearerToken(t *testing.T) {
+	env := setupGRPCTest(t)
+	ctx := context.Background()
+
+	_, err := env.client.ListPlumbuses(ctx, &factorypb.ListPlumbusesRequest{})
+	assertCode(t, err, codes.Unauthenticated)
+
+	for _, value := range []string{"Bearer not-a-jwt", "Basic cmljazpwaWNrbGU=", "Bearer "} {
+		md := metadata.AppendToOutgoingContext(ctx, "authorization", value)
+		_, err := env.client.ListPlumbuses(md, &factorypb.ListPlumbusesRequest{})
+		assertCode(t, err, codes.Unauthenticated)
+	}
+
+	stream, err := env.client.WatchPlumbus(ctx, &factorypb.WatchPlumbusRequest{Id: "x"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	assertCode(t, err, codes.Unauthenticated)
+}
+
+func TestGRPC_CreateRequiresOperator(t *testing.T) {
+	env := setupGRPCTest(t)
+	env.provider.User.Roles = []string{keycloak.RoleViewer}
+	ctx := env.authContext(t)
+
+	_, err := env.client.CreatePlumbus(ctx, createRequest("Viewer"))
+	assertCode(t, err, codes.PermissionDenied)
+
+	resp, err := env.client.ListPlumbuses(ctx, &factorypb.ListPlumbusesRequest{})
+	if err != nil {
+		t.Fatalf("ListPlumbuses() as viewer error = %v", err)
+	}
+	if len(resp.GetPlumbuses()) != 0 {
+		t.Errorf("ListPlumbuses() = %d plumbuses, want 0", len(resp.GetPlumbuses()))
+	}
+
+	env.provider.User.Roles = nil
+	_, err = env.client.ListPlumbuses(env.authContext(t), &factorypb.ListPlumbusesRequest{})
+	assertCode(t, err, codes.PermissionDenied)
+}
+
+func TestGRPC_CreateRateLimited(t *testing.T) {
+	env := setupGRPCTest(t)
+	env.generation.RateLimiter = services.NewRateLimiter(1)
+	ctx := env.authContext(t)
+
+	if _, err := env.client.CreatePlumbus(ctx, createRequest("First")); err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+	_, err := env.client.CreatePlumbus(ctx, createRequest("Second"))
+	assertCode(t, err, codes.ResourceExhausted)
+}
+
+func TestGRPC_CreateAndWatchPlumbus(t *testing.T) {
+	env := setupGRPCTest(t)
+	ctx := env.authContext(t)
+
+	_, err := env.client.CreatePlumbus(ctx, &factorypb.CreatePlumbusRequest{Name: "incomplete"})
+	assertCode(t, err, codes.InvalidArgument)
+
+	created, err := env.client.CreatePlumbus(ctx, createRequest("Streamed"))
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+	id := created.GetPlumbus().GetId()
+
+	stream, err := env.client.WatchPlumbus(ctx, &factorypb.WatchPlumbusRequest{Id: id})
+	if err != nil {
+		t.Fatalf("WatchPlumbus() error = %v", err)
+	}
+
+	var last *factorypb.Plumbus
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if last != nil && resp.GetPlumbus().GetStatus() == last.GetStatus() {
+			t.Errorf("WatchPlumbus() sent status %v twice", last.GetStatus())
+		}
+		last = resp.GetPlumbus()
+	}
+
+	if last.GetStatus() != factorypb.PlumbusStatus_PLUMBUS_STATUS_COMPLETED {
+		t.Fatalf("final status = %v (%s), want COMPLETED", last.GetStatus(), last.GetError())
+	}
+	if last.GetSignature() == "" || last.GetSignatureDate() == nil {
+		t.Errorf("completed plumbus has no signature: %v", last)
+	}
+
+	got, err := env.client.GetPlumbus(ctx, &factorypb.GetPlumbusRequest{Id: id})
+	if err != nil {
+		t.Fatalf("GetPlumbus() error = %v", err)
+	}
+	if got.GetPlumbus().GetName() != "Streamed" {
+		t.Errorf("Name = %q, want Streamed", got.GetPlumbus().GetName())
+	}
+
+	list, err := env.client.ListPlumbuses(ctx, &factorypb.ListPlumbusesRequest{})
+	if err != nil || len(list.GetPlumbuses()) != 1 {
+		t.Fatalf("ListPlumbuses() = %v, %v, want one plumbus", list, err)
+	}
+
+	verified, err := env.client.VerifyPlumbus(ctx, &factorypb.VerifyPlumbusRequest{Id: id})
+	if err != nil || !verified.GetValid() {
+		t.Errorf("VerifyPlumbus() = %v, %v, want valid", verified, err)
+	}
+
+	env.validSignature = false
+	verified, err = env.client.VerifyPlumbus(ctx, &factorypb.VerifyPlumbusRequest{Id: id})
+	if err != nil || verified.GetValid() {
+		t.Errorf("VerifyPlumbus() with tampered signature = %v, %v, want invalid", verified, err)
+	}
+}
+
+func TestGRPC_WatchStopsOnCancel(t *testing.T) {
+	env := setupGRPCTest(t)
+	ctx := env.authContext(t)
+
+	// Плюмбус без запущенной генерации навсегда остается в статусе pending
+	user, err := env.userService.GetOrCreateUser(env.provider.User.Subject, env.provider.User.Username, env.provider.User.Email)
+	if err != nil {
+		t.Fatalf("GetOrCreateUser() error = %v", err)
+	}
+	plumbus, err := env.userService.CreatePlumbus(user.ID, models.PlumbusRequest{
+		Name: "Stuck", Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+	})
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	stream, err := env.client.WatchPlumbus(watchCtx, &factorypb.WatchPlumbusRequest{Id: plumbus.ID.String()})
+	if err != nil {
+		t.Fatalf("WatchPlumbus() error = %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil || resp.GetPlumbus().GetStatus() != factorypb.PlumbusStatus_PLUMBUS_STATUS_PENDING {
+		t.Fatalf("first Recv() = %v, %v, want PENDING", resp, err)
+	}
+
+	cancel()
+	_, err = stream.Recv()
+	assertCode(t, err, codes.Canceled)
+
+	_, err = env.client.VerifyPlumbus(ctx, &factorypb.VerifyPlumbusRequest{Id: plumbus.ID.String()})
+	assertCode(t, err, codes.FailedPrecondition)
+}
+
+func TestGRPC_WatchEndsWhenPlumbusDeleted(t *testing.T) {
+	env := setupGRPCTest(t)
+	ctx := env.authContext(t)
+
+	user, err := env.userService.GetOrCreateUser(env.provider.User.Subject, env.provider.User.Username, env.provider.User.Email)
+	if err != nil {
+		t.Fatalf("GetOrCreateUser() error = %v", err)
+	}
+	plumbus, err := env.userService.CreatePlumbus(user.ID, models.PlumbusRequest{
+		Name: "Doomed", Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+	})
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+
+	stream, err := env.client.WatchPlumbus(ctx, &factorypb.WatchPlumbusRequest{Id: plumbus.ID.String()})
+	if err != nil {
+		t.Fatalf("WatchPlumbus() error = %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first Recv() error = %v", err)
+	}
+
+	if err := env.userService.DeletePlumbus(plumbus.ID); err != nil {
+		t.Fatalf("DeletePlumbus() error = %v", err)
+	}
+	_, err = stream.Recv()
+	assertCode(t, err, codes.NotFound)
+}
+
+func TestGRPC_PlumbusOwnership(t *testing.T) {
+	env := setupGRPCTest(t)
+
+	created, err := env.client.CreatePlumbus(env.authContext(t), createRequest("Rick's"))
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+	id := created.GetPlumbus().GetId()
+
+	env.provider.User = testutils.FakeOIDCUser{
+		Subject: "kc-user-2", Username: "morty", Email: "morty@citadel.test", Roles: []string{keycloak.RoleOperator},
+	}
+	ctx := env.authContext(t)
+
+	_, err = env.client.GetPlumbus(ctx, &factorypb.GetPlumbusRequest{Id: id})
+	assertCode(t, err, codes.NotFound)
+	_, err = env.client.VerifyPlumbus(ctx, &factorypb.VerifyPlumbusRequest{Id: id})
+	assertCode(t, err, codes.NotFound)
+	_, err = env.client.GetPlumbus(ctx, &factorypb.GetPlumbusRequest{Id: "not-a-uuid"})
+	assertCode(t, err, codes.InvalidArgument)
+
+	list, err := env.client.ListPlumbuses(ctx, &factorypb.ListPlumbusesRequest{})
+	if err != nil || len(list.GetPlumbuses()) != 0 {
+		t.Errorf("ListPlumbuses() for another user = %v, %v, want empty", list, err)
+	}
+
+	env.provider.User.Roles = []string{keycloak.RoleAdmin}
+	if _, err := env.client.GetPlumbus(env.authContext(t), &factorypb.GetPlumbusRequest{Id: id}); err != nil {
+		t.Errorf("GetPlumbus() as admin error = %v", err)
+	}
+}
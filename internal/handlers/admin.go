@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/models"
+	"factory/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// plumbusStatuses - статусы для фильтра панели администратора
+var plumbusStatuses = []models.PlumbusStatus{
+	models.StatusPending,
+	models.StatusGenerating,
+	models.StatusCompleted,
+	models.StatusFailed,
+	models.StatusCancelled,
+}
+
+// AdminDashboard показывает плюмбусы всех пользователей с фильтрами, сводку по
+// пользователям, частые причины ошибок и состояние зависимостей
+func (h *Handler) AdminDashboard(c *gin.Context) {
+	filter := services.PlumbusFilter{
+		Status:     models.PlumbusStatus(c.Query("status")),
+		Name:       strings.TrimSpace(c.Query("name")),
+		Stuck:      c.Query("stuck") == "true",
+		StuckAfter: h.generationService.StuckAfter,
+		Deleted:    c.Query("deleted") == "true",
+	}
+	if raw := c.Query("user"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorBody(c, "Invalid user ID"))
+			return
+		}
+		filter.UserID = id
+	}
+
+	users := h.users(c)
+	plumbuses, err := users.ListPlumbuses(filter, time.Now())
+	if err != nil {
+		h.log(c).WithError(err).Error("Failed to list plumbuses")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		return
+	}
+
+	stats, err := users.GetUserStats()
+	if err != nil {
+		h.log(c).WithError(err).Error("Failed to get user stats")
+		stats = []services.UserStats{}
+	}
+	usernames := make(map[uuid.UUID]string, len(stats))
+	for _, s := range stats {
+		usernames[s.UserID] = s.Username
+	}
+
+	failures, err := users.GetFailureReasons(services.DefaultFailureReasonsLimit)
+	if err != nil {
+		h.log(c).WithError(err).Error("Failed to get failure reasons")
+		failures = []services.FailureReason{}
+	}
+
+	var reloads []config.ReloadResult
+	if h.ConfigReloader != nil {
+		reloads = h.ConfigReloader.History()
+	}
+
+	c.HTML(http.StatusOK, "admin.html", gin.H{
+		"title":       "Администрирование - Rick & Morty Plumbus Factory",
+		"plumbuses":   plumbuses,
+		"usernames":   usernames,
+		"stats":       stats,
+		"failures":    failures,
+		"health":      h.healthService.Check(c.Request.Context()),
+		"reloads":     reloads,
+		"filter":      filter,
+		"filterUser":  c.Query("user"),
+		"statuses":    plumbusStatuses,
+		"maxAttempts": h.generationService.MaxAttempts,
+		"stuckAfter":  h.generationService.StuckAfter,
+		"limit":       services.DefaultAdminListLimit,
+	})
+}
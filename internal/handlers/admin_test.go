@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"factory/internal/keycloak"
+)
+
+func TestAdminDashboard_AdminOnly(t *testing.T) {
+	env := setupAuthTest(t)
+	operator := env.login(t)
+
+	resp, _ := do(t, operator, http.MethodGet, env.server.URL+"/admin", "", "")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Admin dashboard as operator status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestAdminDashboard(t *testing.T) {
+	env := setupAuthTest(t)
+
+	// Плюмбус оператора должен быть виден администратору
+	operator := env.login(t)
+	id := env.generatePlumbus(t, operator)
+	env.waitForAttempts(t, operator, id, 1)
+
+	env.provider.User.Subject = "kc-admin"
+	env.provider.User.Username = "rick"
+	env.provider.User.Roles = []string{keycloak.RoleAdmin}
+	admin := env.login(t)
+
+	resp, data := do(t, admin, http.MethodGet, env.server.URL+"/admin", "", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Admin dashboard status = %d: %s", resp.StatusCode, data)
+	}
+	page := string(data)
+	for _, want := range []string{id, "Disposable", "database", `data-action="retry"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Admin dashboard does not contain %q", want)
+		}
+	}
+
+	// Сервис генерации в тестах недоступен: плюмбус завершился ошибкой
+	resp, data = do(t, admin, http.MethodGet, env.server.URL+"/admin?status=completed", "", "")
+	if resp.StatusCode != http.StatusOK || strings.Contains(string(data), id) {
+		t.Errorf("Admin dashboard filtered by completed = %d, contains failed plumbus %v", resp.StatusCode, strings.Contains(string(data), id))
+	}
+
+	resp, _ = do(t, admin, http.MethodGet, env.server.URL+"/admin?user=not-a-uuid", "", "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Admin dashboard with invalid user status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	// Администратор управляет чужими плюмбусами через общие маршруты
+	resp, data = do(t, admin, http.MethodPost, env.server.URL+"/plumbus/"+id+"/retry", "", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Retry of another user's plumbus as admin = %d: %s", resp.StatusCode, data)
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"factory/internal/keycloak"
+	"factory/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// scopesKey - ключ контекста gin с разрешениями запроса к API
+const scopesKey = "scopes"
+
+// maxTokenTTLDays - максимальный срок действия персонального токена
+const maxTokenTTLDays = 365
+
+// scopesForClaims возвращает разрешения API, соответствующие ролям пользователя Keycloak
+func scopesForClaims(claims *keycloak.Claims) []string {
+	var scopes []string
+	if claims.HasRole(keycloak.RoleViewer) {
+		scopes = append(scopes, services.ScopePlumbusRead)
+	}
+	if claims.HasRole(keycloak.RoleOperator) {
+		scopes = append(scopes, services.ScopePlumbusWrite)
+	}
+	return scopes
+}
+
+// getScopes возвращает разрешения текущего запроса к API
+func getScopes(c *gin.Context) []string {
+	if value, ok := c.Get(scopesKey); ok {
+		if scopes, ok := value.([]string); ok {
+			return scopes
+		}
+	}
+	return nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// abortUnauthorized отвечает 401 с заголовком WWW-Authenticate (RFC 6750)
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="factory", error="invalid_token"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, errorBody(c, message))
+}
+
+// APIAuthMiddleware аутентифицирует запросы к API по заголовку Authorization: Bearer.
+// Принимаются персональные токены (pat_...) и access токены Keycloak.
+func (h *Handler) APIAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="factory"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorBody(c, "Bearer token required"))
+			return
+		}
+		token = strings.TrimSpace(token)
+
+		if strings.HasPrefix(token, services.TokenPrefix) {
+			apiToken, err := h.tokenService.Authenticate(token)
+			if err != nil {
+				if !errors.Is(err, services.ErrTokenInvalid) {
+					h.log(c).WithError(err).Error("Failed to authenticate API token")
+				}
+				abortUnauthorized(c, "Invalid token")
+				return
+			}
+
+			setUserID(c, apiToken.UserID)
+			c.Set(scopesKey, services.TokenScopes(apiToken))
+			c.Next()
+			return
+		}
+
+		claims, err := h.keycloakClient.VerifyBearerToken(c.Request.Context(), token)
+		if err != nil {
+			h.log(c).WithError(err).Debug("Bearer token verification failed")
+			abortUnauthorized(c, "Invalid token")
+			return
+		}
+
+		// Сервисные аккаунты client credentials получают запись пользователя так же, как люди
+		user, err := h.users(c).GetOrCreateUser(claims.Subject, claims.PreferredUsername, claims.Email)
+		if err != nil {
+			h.log(c).WithError(err).Error("Failed to create/get API user")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		setUserID(c, user.ID)
+		c.Set(scopesKey, scopesForClaims(claims))
+		c.Next()
+	}
+}
+
+// RequireScope пропускает запрос к API, только если у токена есть разрешение.
+// Должен использоваться после APIAuthMiddleware.
+func (h *Handler) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes := getScopes(c)
+		if !containsScope(scopes, scope) {
+			h.log(c).WithFields(logrus.Fields{
+				"path":           c.Request.URL.Path,
+				"required_scope": scope,
+				"token_scopes":   scopes,
+			}).Warn("Access denied: missing scope")
+			c.Header("WWW-Authenticate", `Bearer realm="factory", error="insufficient_scope", scope="`+scope+`"`)
+			c.AbortWithStatusJSON(http.StatusForbidden, errorBody(c, "Insufficient scope"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// APIMe возвращает пользователя и разрешения, с которыми выполняется запрос
+func (h *Handler) APIMe(c *gin.Context) {
+	userID, _ := currentUserID(c)
+
+	user, err := h.users(c).GetUserByID(userID)
+	if err != nil {
+		h.log(c).WithError(err).WithField("user_id", userID).Error("Failed to get user info")
+		c.JSON(http.StatusNotFound, errorBody(c, "User not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":       user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"scopes":   getScopes(c),
+	})
+}
+
+// createTokenRequest - запрос на выпуск персонального токена
+type createTokenRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// ExpiresInDays - срок действия в днях, 0 - бессрочный
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+// ListTokens возвращает персональные токены текущего пользователя
+func (h *Handler) ListTokens(c *gin.Context) {
+	userID, _ := currentUserID(c)
+
+	tokens, err := h.tokenService.ListTokens(userID)
+	if err != nil {
+		h.log(c).WithError(err).WithField("user_id", userID).Error("Failed to list API tokens")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken выпускает персональный токен. Токен получает только те разрешения,
+// которые есть у пользователя по его ролям Keycloak.
+func (h *Handler) CreateToken(c *gin.Context) {
+	var req createTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, err.Error()))
+		return
+	}
+
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxTokenTTLDays {
+		c.JSON(http.StatusBadRequest, errorBody(c, "expires_in_days must be between 0 and 365"))
+		return
+	}
+
+	allowed := scopesForClaims(getClaims(c))
+	for _, scope := range req.Scopes {
+		if !containsScope(allowed, scope) {
+			c.JSON(http.StatusForbidden, errorBody(c, "Scope not allowed: "+scope))
+			return
+		}
+	}
+
+	userID, _ := currentUserID(c)
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+
+	plain, token, err := h.tokenService.CreateToken(userID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		h.log(c).WithError(err).WithField("user_id", userID).Warn("Failed to create API token")
+		c.JSON(http.StatusBadRequest, errorBody(c, err.Error()))
+		return
+	}
+
+	h.log(c).WithFields(logrus.Fields{
+		"user_id":  userID,
+		"token_id": token.ID,
+		"scopes":   token.Scopes,
+	}).Info("API token created")
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":      plain,
+		"id":         token.ID,
+		"name":       token.Name,
+		"prefix":     token.Prefix,
+		"scopes":     token.Scopes,
+		"expires_at": token.ExpiresAt,
+	})
+}
+
+// RevokeToken отзывает персональный токен текущего пользователя
+func (h *Handler) RevokeToken(c *gin.Context) {
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, "Invalid ID"))
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	if err := h.tokenService.RevokeToken(userID, tokenID); err != nil {
+		if errors.Is(err, services.ErrTokenNotFound) {
+			c.JSON(http.StatusNotFound, errorBody(c, "Token not found"))
+			return
+		}
+		h.log(c).WithError(err).WithField("token_id", tokenID).Error("Failed to revoke API token")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		return
+	}
+
+	h.log(c).WithFields(logrus.Fields{
+		"user_id":  userID,
+		"token_id": tokenID,
+	}).Info("API token revoked")
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"factory/internal/keycloak"
+	"factory/internal/services"
+)
+
+// do выполняет запрос с JSON телом и необязательным bearer токеном
+func do(t *testing.T, client *http.Client, method, rawURL, bearer, body string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, rawURL, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return resp, data
+}
+
+// createToken выпускает персональный токен из браузерной сессии
+func (e *authTestEnv) createToken(t *testing.T, browser *http.Client, scopes ...string) (string, string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"name": "ci", "scopes": scopes, "expires_in_days": 30})
+
+	resp, data := do(t, browser, http.MethodPost, e.server.URL+"/tokens", "", string(body))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Create token status = %d, want %d: %s", resp.StatusCode, http.StatusCreated, data)
+	}
+
+	var created struct {
+		Token string `json:"token"`
+		ID    string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		t.Fatalf("Invalid create token response: %v", err)
+	}
+	return created.Token, created.ID
+}
+
+func TestAPI_RequiresBearerToken(t *testing.T) {
+	env := setupAuthTest(t)
+
+	for _, bearer := range []string{"", "not-a-jwt", services.TokenPrefix + "unknown"} {
+		resp, _ := do(t, http.DefaultClient, http.MethodGet, env.server.URL+"/api/v1/me", bearer, "")
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("bearer %q: status = %d, want %d", bearer, resp.StatusCode, http.StatusUnauthorized)
+		}
+		if !strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Bearer") {
+			t.Errorf("bearer %q: WWW-Authenticate = %q, want Bearer challenge", bearer, resp.Header.Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestAPI_SessionCookieIsNotAccepted(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	resp, _ := do(t, browser, http.MethodGet, env.server.URL+"/api/v1/plumbuses", "", "")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("API with session cookie status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestAPI_KeycloakAccessToken(t *testing.T) {
+	env := setupAuthTest(t)
+
+	resp, data := do(t, http.DefaultClient, http.MethodGet, env.server.URL+"/api/v1/me", env.provider.AccessToken(t), "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Me status = %d, want %d: %s", resp.StatusCode, http.StatusOK, data)
+	}
+
+	var me struct {
+		Username string   `json:"username"`
+		Scopes   []string `json:"scopes"`
+	}
+	if err := json.Unmarshal(data, &me); err != nil {
+		t.Fatalf("Invalid me response: %v", err)
+	}
+	if me.Username != env.provider.User.Username {
+		t.Errorf("username = %q, want %q", me.Username, env.provider.User.Username)
+	}
+	if !containsScope(me.Scopes, services.ScopePlumbusRead) || !containsScope(me.Scopes, services.ScopePlumbusWrite) {
+		t.Errorf("scopes = %v, want operator scopes", me.Scopes)
+	}
+}
+
+func TestAPI_PersonalAccessToken(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	token, id := env.createToken(t, browser, services.ScopePlumbusRead)
+
+	resp, data := do(t, http.DefaultClient, http.MethodGet, env.server.URL+"/api/v1/plumbuses", token, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("List status = %d, want %d: %s", resp.StatusCode, http.StatusOK, data)
+	}
+
+	// Токен только для чтения не позволяет создавать плюмбусы
+	resp, _ = do(t, http.DefaultClient, http.MethodPost, env.server.URL+"/api/v1/plumbuses", token,
+		`{"name":"p","size":"M","color":"pink","shape":"smooth","weight":"light","wrapping":"default"}`)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Create with read-only token status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	// Токен не дает доступа к управлению токенами
+	resp, _ = do(t, newBrowser(t), http.MethodGet, env.server.URL+"/tokens", token, "")
+	if resp.StatusCode == http.StatusOK {
+		t.Error("Token management is accessible with a personal access token")
+	}
+
+	resp, _ = do(t, browser, http.MethodDelete, env.server.URL+"/tokens/"+id, "", "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Revoke status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+
+	resp, _ = do(t, http.DefaultClient, http.MethodGet, env.server.URL+"/api/v1/plumbuses", token, "")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Revoked token status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestCreateToken_ScopesLimitedByRole(t *testing.T) {
+	env := setupAuthTest(t)
+	env.provider.User.Roles = []string{keycloak.RoleViewer}
+	browser := env.login(t)
+
+	resp, _ := do(t, browser, http.MethodPost, env.server.URL+"/tokens", "",
+		`{"name":"ci","scopes":["plumbus:read","plumbus:write"]}`)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Create write token as viewer status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	resp, _ = do(t, browser, http.MethodPost, env.server.URL+"/tokens", "",
+		`{"name":"ci","scopes":["plumbus:read"],"expires_in_days":1000}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Create token with too long expiry status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	env.createToken(t, browser, services.ScopePlumbusRead)
+}
+
+func TestAPI_OtherUsersPlumbusIsNotFound(t *testing.T) {
+	env := setupAuthTest(t)
+	owner := env.login(t)
+	id := env.generatePlumbus(t, owner)
+	token, _ := env.createToken(t, owner, services.ScopePlumbusRead)
+
+	resp, data := do(t, http.DefaultClient, http.MethodGet, env.server.URL+"/api/v1/plumbuses/"+id, token, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Owner status = %d, want %d: %s", resp.StatusCode, http.StatusOK, data)
+	}
+
+	// Другой пользователь не видит плюмбус ни через Keycloak токен, ни через свой персональный токен
+	env.provider.User.Subject = "kc-morty"
+	env.provider.User.Username = "morty"
+	stranger := env.login(t)
+	strangerToken, _ := env.createToken(t, stranger, services.ScopePlumbusRead)
+
+	for _, bearer := range []string{env.provider.AccessToken(t), strangerToken} {
+		for _, path := range []string{"/api/v1/plumbuses/" + id, "/api/v1/plumbuses/" + id + "/image"} {
+			resp, _ := do(t, http.DefaultClient, http.MethodGet, env.server.URL+path, bearer, "")
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("GET %s as another user status = %d, want %d", path, resp.StatusCode, http.StatusNotFound)
+			}
+		}
+	}
+
+	// Администратор видит чужие плюмбусы
+	env.provider.User.Subject = "kc-admin"
+	env.provider.User.Username = "rick"
+	env.provider.User.Roles = []string{keycloak.RoleAdmin}
+	resp, data = do(t, http.DefaultClient, http.MethodGet, env.server.URL+"/api/v1/plumbuses/"+id, env.provider.AccessToken(t), "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Admin status = %d, want %d: %s", resp.StatusCode, http.StatusOK, data)
+	}
+}
@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/keycloak"
+	"factory/internal/models"
+	"factory/internal/services"
+	"factory/internal/session"
+	"factory/internal/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// authTestEnv - фабрика, подключенная к фейковому OIDC провайдеру
+type authTestEnv struct {
+	provider *testutils.FakeOIDCProvider
+	server   *httptest.Server
+	handler  *Handler
+}
+
+func setupAuthTest(t *testing.T) *authTestEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	provider := testutils.NewFakeOIDCProvider(t)
+	cfg := &config.Config{
+		KeycloakURL:          provider.URL,
+		KeycloakInternalURL:  provider.URL,
+		KeycloakRealm:        testutils.FakeOIDCRealm,
+		KeycloakClientID:     testutils.FakeOIDCClientID,
+		KeycloakClientSecret: config.NewSecret(testutils.FakeOIDCClientSecret),
+		KeycloakVerifyMode:   keycloak.VerifyModeJWKS,
+		SessionSecret:        config.NewSecret("test-session-secret"),
+	}
+
+	kc := keycloak.NewClient(cfg)
+	sm := session.NewManager(session.NewMemoryStore(), cfg.SessionSecret, kc)
+	db := testutils.SetupTestDB(t)
+	if err := db.AutoMigrate(&models.APIToken{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.PlumbusAttempt{}); err != nil {
+		t.Fatalf("Failed to migrate API tokens and webhooks tables: %v", err)
+	}
+	us := services.NewUserService(db)
+	ws := services.NewWebhookService(db)
+	ws.AllowedHosts = []string{"127.0.0.1"}
+	gs := services.NewGenerationService(us, services.NewPlumbusService(cfg), services.NewSignatureService(cfg), nil, ws)
+	ds := services.NewDeletionService(us, nil, time.Hour)
+	ds.Generation = gs
+	hs := services.NewHealthService(time.Second, 0)
+	hs.Register(services.DependencyDatabase, true, func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	h := NewHandler(us, gs, ds, services.NewTokenService(db), ws, hs, kc, sm)
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.LoadHTMLGlob("../../web/templates/*")
+	h.RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return &authTestEnv{provider: provider, server: server, handler: h}
+}
+
+// newBrowser возвращает HTTP клиент с cookie jar, не следующий за редиректами
+func newBrowser(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("Failed to create cookie jar: %v", err)
+	}
+	return &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func get(t *testing.T, client *http.Client, rawURL string) *http.Response {
+	t.Helper()
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", rawURL, err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// startLogin выполняет /auth/login и возвращает URL авторизации в провайдере
+func (e *authTestEnv) startLogin(t *testing.T, browser *http.Client, returnTo string) *url.URL {
+	t.Helper()
+	loginURL := e.server.URL + "/auth/login"
+	if returnTo != "" {
+		loginURL += "?return_to=" + url.QueryEscape(returnTo)
+	}
+
+	resp := get(t, browser, loginURL)
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("Login status = %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Invalid authorization URL: %v", err)
+	}
+	return authURL
+}
+
+// login выполняет полный вход и возвращает браузер с активной сессией
+func (e *authTestEnv) login(t *testing.T) *http.Client {
+	t.Helper()
+	browser := newBrowser(t)
+	callback := e.provider.Authorize(t, e.startLogin(t, browser, "").String())
+	if resp := get(t, browser, callback.String()); resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("Callback status = %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+	return browser
+}
+
+// isLoggedIn проверяет, пускает ли фабрика браузер к защищенным маршрутам
+func (e *authTestEnv) isLoggedIn(t *testing.T, browser *http.Client) bool {
+	t.Helper()
+	return get(t, browser, e.server.URL+"/plumbus/list").StatusCode == http.StatusOK
+}
+
+func TestAuthFlow_Success(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := newBrowser(t)
+
+	authURL := env.startLogin(t, browser, "/plumbus/list")
+
+	q := authURL.Query()
+	for _, param := range []string{"state", "nonce", "code_challenge"} {
+		if q.Get(param) == "" {
+			t.Errorf("authorization URL has no %s", param)
+		}
+	}
+	if q.Get("code_challenge_method") != "S256" {
+		t.Errorf("code_challenge_method = %q, want S256", q.Get("code_challenge_method"))
+	}
+
+	callback := env.provider.Authorize(t, authURL.String())
+	resp := get(t, browser, callback.String())
+
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("Callback status = %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+	if location := resp.Header.Get("Location"); location != "/plumbus/list" {
+		t.Errorf("Callback redirect = %q, want /plumbus/list", location)
+	}
+
+	// Обмен кода должен сопровождаться PKCE verifier
+	if len(env.provider.TokenRequests) != 1 || env.provider.TokenRequests[0].Get("code_verifier") == "" {
+		t.Errorf("token request = %v, want code_verifier", env.provider.TokenRequests)
+	}
+
+	// Сессия позволяет обращаться к защищенным маршрутам
+	resp = get(t, browser, env.server.URL+"/plumbus/list")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Protected route status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestAuthMiddleware_RefreshReissuesSessionCookie(t *testing.T) {
+	env := setupAuthTest(t)
+	// Access токен истекает раньше запаса обновления, поэтому следующий запрос его обновит
+	env.provider.AccessTokenTTL = 10 * time.Second
+	browser := env.login(t)
+	requests := len(env.provider.TokenRequests)
+
+	resp := get(t, browser, env.server.URL+"/plumbus/list")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Protected route status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if len(env.provider.TokenRequests) != requests+1 || env.provider.TokenRequests[requests].Get("grant_type") != "refresh_token" {
+		t.Fatalf("token requests = %v, want a refresh", env.provider.TokenRequests)
+	}
+
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == session.CookieName {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatalf("Set-Cookie = %v, want reissued %s", resp.Header.Values("Set-Cookie"), session.CookieName)
+	}
+	if until := time.Until(cookie.Expires); until < 29*time.Minute || !cookie.HttpOnly {
+		t.Errorf("reissued cookie = %+v, want HttpOnly cookie for the refreshed session", cookie)
+	}
+}
+
+func TestAuthCallback_StateMismatch(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := newBrowser(t)
+
+	callback := env.provider.Authorize(t, env.startLogin(t, browser, "").String())
+	q := callback.Query()
+	q.Set("state", "forged-state")
+	callback.RawQuery = q.Encode()
+
+	resp := get(t, browser, callback.String())
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Callback status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if len(env.provider.TokenRequests) != 0 {
+		t.Error("code must not be exchanged when state is invalid")
+	}
+}
+
+func TestAuthCallback_WithoutLoginCookie(t *testing.T) {
+	env := setupAuthTest(t)
+
+	// Callback открыт в браузере, который не начинал вход (CSRF)
+	callback := env.provider.Authorize(t, env.startLogin(t, newBrowser(t), "").String())
+
+	resp := get(t, newBrowser(t), callback.String())
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Callback status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestAuthCallback_InjectedCode(t *testing.T) {
+	env := setupAuthTest(t)
+	victim := newBrowser(t)
+	attacker := newBrowser(t)
+
+	victimCallback := env.provider.Authorize(t, env.startLogin(t, victim, "").String())
+	attackerCallback := env.provider.Authorize(t, env.startLogin(t, attacker, "").String())
+
+	// Код злоумышленника подставлен в callback жертвы с ее корректным state:
+	// PKCE verifier жертвы не совпадает с challenge кода злоумышленника
+	q := victimCallback.Query()
+	q.Set("code", attackerCallback.Query().Get("code"))
+	victimCallback.RawQuery = q.Encode()
+
+	resp := get(t, victim, victimCallback.String())
+	if resp.StatusCode == http.StatusTemporaryRedirect {
+		t.Fatal("Callback accepted injected authorization code")
+	}
+
+	resp = get(t, victim, env.server.URL+"/plumbus/list")
+	if resp.StatusCode == http.StatusOK {
+		t.Error("Session was created from injected authorization code")
+	}
+}
+
+func TestAuthCallback_NonceMismatch(t *testing.T) {
+	env := setupAuthTest(t)
+	env.provider.NonceOverride = "replayed-nonce"
+	browser := newBrowser(t)
+
+	callback := env.provider.Authorize(t, env.startLogin(t, browser, "").String())
+
+	resp := get(t, browser, callback.String())
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Callback status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthCallback_StateIsSingleUse(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := newBrowser(t)
+
+	callback := env.provider.Authorize(t, env.startLogin(t, browser, "").String())
+	if resp := get(t, browser, callback.String()); resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("First callback status = %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+
+	if resp := get(t, browser, callback.String()); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Replayed callback status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestAuthMiddleware_RedirectsToLoginWithReturnURL(t *testing.T) {
+	env := setupAuthTest(t)
+
+	resp := get(t, newBrowser(t), env.server.URL+"/plumbus/list?page=2")
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+
+	want := "/auth/login?return_to=" + url.QueryEscape("/plumbus/list?page=2")
+	if location := resp.Header.Get("Location"); location != want {
+		t.Errorf("Location = %q, want %q", location, want)
+	}
+}
+
+func TestSafeReturnURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"", defaultReturnURL},
+		{"/plumbus/list", "/plumbus/list"},
+		{"/plumbus/list?page=2", "/plumbus/list?page=2"},
+		{"https://evil.test/", defaultReturnURL},
+		{"//evil.test/path", defaultReturnURL},
+		{"/\\evil.test", defaultReturnURL},
+		{"dashboard", defaultReturnURL},
+		{"/auth/logout", defaultReturnURL},
+	}
+
+	for _, tt := range tests {
+		if got := safeReturnURL(tt.raw); got != tt.want {
+			t.Errorf("safeReturnURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestLogout_RedirectsToKeycloakEndSession(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	resp := get(t, browser, env.server.URL+"/auth/logout")
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("Logout status = %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Invalid logout URL: %v", err)
+	}
+
+	if !strings.HasPrefix(location.String(), env.provider.Issuer()+"/protocol/openid-connect/logout") {
+		t.Errorf("Logout redirect = %s, want Keycloak end-session endpoint", location)
+	}
+	if location.Query().Get("id_token_hint") == "" {
+		t.Error("Logout redirect has no id_token_hint")
+	}
+	if got := location.Query().Get("post_logout_redirect_uri"); got != env.server.URL+"/" {
+		t.Errorf("post_logout_redirect_uri = %q, want %q", got, env.server.URL+"/")
+	}
+
+	if env.isLoggedIn(t, browser) {
+		t.Error("Session is still active after logout")
+	}
+}
+
+func (e *authTestEnv) backchannelLogout(t *testing.T, claims jwt.MapClaims) int {
+	t.Helper()
+	token, err := e.provider.SignToken(claims)
+	if err != nil {
+		t.Fatalf("Failed to sign logout token: %v", err)
+	}
+
+	resp, err := http.PostForm(e.server.URL+"/auth/backchannel-logout", url.Values{"logout_token": {token}})
+	if err != nil {
+		t.Fatalf("Back-channel logout request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func logoutTokenClaims(e *authTestEnv) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":    e.provider.Issuer(),
+		"aud":    testutils.FakeOIDCClientID,
+		"iat":    time.Now().Unix(),
+		"jti":    "logout-1",
+		"sid":    "kc-session-1",
+		"events": map[string]interface{}{"http://schemas.openid.net/event/backchannel-logout": map[string]interface{}{}},
+	}
+}
+
+func TestBackchannelLogout_RevokesSession(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	if status := env.backchannelLogout(t, logoutTokenClaims(env)); status != http.StatusOK {
+		t.Fatalf("Back-channel logout status = %d, want %d", status, http.StatusOK)
+	}
+
+	if env.isLoggedIn(t, browser) {
+		t.Error("Session is still active after back-channel logout")
+	}
+}
+
+func TestBackchannelLogout_RevokesBySubject(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	claims := logoutTokenClaims(env)
+	delete(claims, "sid")
+	claims["sub"] = env.provider.User.Subject
+
+	if status := env.backchannelLogout(t, claims); status != http.StatusOK {
+		t.Fatalf("Back-channel logout status = %d, want %d", status, http.StatusOK)
+	}
+
+	if env.isLoggedIn(t, browser) {
+		t.Error("Session is still active after back-channel logout by subject")
+	}
+}
+
+func TestBackchannelLogout_RejectsInvalidTokens(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	tests := []struct {
+		name   string
+		mutate func(jwt.MapClaims)
+	}{
+		{"with nonce", func(c jwt.MapClaims) { c["nonce"] = "n" }},
+		{"without event", func(c jwt.MapClaims) { delete(c, "events") }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "http://evil.test/realms/factory" }},
+		{"without sid and sub", func(c jwt.MapClaims) { delete(c, "sid") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := logoutTokenClaims(env)
+			tt.mutate(claims)
+
+			if status := env.backchannelLogout(t, claims); status != http.StatusBadRequest {
+				t.Errorf("Back-channel logout status = %d, want %d", status, http.StatusBadRequest)
+			}
+		})
+	}
+
+	if !env.isLoggedIn(t, browser) {
+		t.Error("Session was revoked by an invalid logout token")
+	}
+}
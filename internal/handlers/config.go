@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"factory/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configReloader возвращает Reloader или отвечает 404, если перезагрузка настроек не подключена
+func (h *Handler) configReloader(c *gin.Context) (*config.Reloader, bool) {
+	if h.ConfigReloader == nil {
+		c.JSON(http.StatusNotFound, errorBody(c, "Configuration reload is not enabled"))
+		return nil, false
+	}
+	return h.ConfigReloader, true
+}
+
+// ListConfigReloads возвращает результаты последних перезагрузок настроек
+func (h *Handler) ListConfigReloads(c *gin.Context) {
+	reloader, ok := h.configReloader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reloads": reloader.History()})
+}
+
+// ReloadConfig перечитывает настройки и применяет те, что меняются без перезапуска.
+// Неверные настройки не применяются и возвращаются со статусом 422.
+func (h *Handler) ReloadConfig(c *gin.Context) {
+	reloader, ok := h.configReloader(c)
+	if !ok {
+		return
+	}
+	h.log(c).Info("Configuration reload requested")
+	result := reloader.Reload(config.TriggerAPI)
+	if result.Status == config.ReloadFailed {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
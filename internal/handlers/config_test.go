@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"factory/internal/config"
+	"factory/internal/keycloak"
+)
+
+func TestReloadConfig_AdminOnly(t *testing.T) {
+	env := setupAuthTest(t)
+	env.handler.ConfigReloader = config.NewReloader("", config.Default())
+
+	operator := env.login(t)
+	resp, _ := do(t, operator, http.MethodPost, env.server.URL+"/admin/config/reload", "", "")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Reload as operator status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	env := setupAuthTest(t)
+	env.provider.User.Roles = []string{keycloak.RoleAdmin}
+	admin := env.login(t)
+
+	resp, _ := do(t, admin, http.MethodPost, env.server.URL+"/admin/config/reload", "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Reload without reloader status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	path := filepath.Join(t.TempDir(), "factory.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+	write("rare_chance: 0.05\n")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	reloader := config.NewReloader(path, cfg)
+	reloader.Subscribe("rarity", func(cfg *config.Config) {
+		env.handler.userService.Rarity().SetChance(cfg.RareChance)
+	})
+	env.handler.ConfigReloader = reloader
+
+	write("rare_chance: 0.75\n")
+	resp, data := do(t, admin, http.MethodPost, env.server.URL+"/admin/config/reload", "", "")
+	var result config.ReloadResult
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(data, &result) != nil || result.Status != config.ReloadApplied {
+		t.Fatalf("Reload = %d %s, want applied", resp.StatusCode, data)
+	}
+	if chance := env.handler.userService.Rarity().Chance(); chance != 0.75 {
+		t.Errorf("rare chance after reload = %v, want 0.75", chance)
+	}
+
+	// Неверные настройки не применяются
+	write("rare_chance: 3\n")
+	resp, data = do(t, admin, http.MethodPost, env.server.URL+"/admin/config/reload", "", "")
+	if resp.StatusCode != http.StatusUnprocessableEntity || json.Unmarshal(data, &result) != nil || result.Status != config.ReloadFailed {
+		t.Fatalf("Reload of invalid config = %d %s, want 422 failed", resp.StatusCode, data)
+	}
+	if chance := env.handler.userService.Rarity().Chance(); chance != 0.75 {
+		t.Errorf("rare chance after failed reload = %v, want 0.75", chance)
+	}
+
+	resp, data = do(t, admin, http.MethodGet, env.server.URL+"/admin/config/reloads", "", "")
+	var history struct {
+		Reloads []config.ReloadResult `json:"reloads"`
+	}
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(data, &history) != nil || len(history.Reloads) != 2 {
+		t.Fatalf("Reload history = %d %s, want 2 reloads", resp.StatusCode, data)
+	}
+	if history.Reloads[0].Status != config.ReloadFailed || history.Reloads[1].Status != config.ReloadApplied {
+		t.Errorf("Reload history = %+v, want newest first", history.Reloads)
+	}
+}
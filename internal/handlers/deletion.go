@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"factory/internal/keycloak"
+	"factory/internal/models"
+	"factory/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// managedPlumbus загружает плюмбус (в том числе удаленный), которым может управлять
+// текущий пользователь: свой или любой для администратора. Иначе отвечает 404.
+func (h *Handler) managedPlumbus(c *gin.Context) (*models.Plumbus, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, "Invalid ID"))
+		return nil, false
+	}
+	setPlumbusID(c, id)
+
+	plumbus, err := h.users(c).GetPlumbusIncludingDeleted(id)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			h.log(c).WithError(err).WithField("plumbus_id", id).Error("Failed to get plumbus")
+			c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+			return nil, false
+		}
+		c.JSON(http.StatusNotFound, errorBody(c, "Plumbus not found"))
+		return nil, false
+	}
+
+	userID, _ := currentUserID(c)
+	if plumbus.UserID != userID && !getClaims(c).HasRole(keycloak.RoleAdmin) {
+		c.JSON(http.StatusNotFound, errorBody(c, "Plumbus not found"))
+		return nil, false
+	}
+	return plumbus, true
+}
+
+// visiblePlumbus загружает неудаленный плюмбус текущего пользователя, как managedPlumbus
+func (h *Handler) visiblePlumbus(c *gin.Context) (*models.Plumbus, bool) {
+	plumbus, ok := h.managedPlumbus(c)
+	if !ok {
+		return nil, false
+	}
+	if plumbus.DeletedAt.Valid {
+		c.JSON(http.StatusNotFound, errorBody(c, "Plumbus not found"))
+		return nil, false
+	}
+	return plumbus, true
+}
+
+// DeletePlumbus мягко удаляет плюмбус. С параметром hard=true администратор
+// удаляет плюмбус безвозвратно вместе с изображением.
+func (h *Handler) DeletePlumbus(c *gin.Context) {
+	hard, _ := strconv.ParseBool(c.Query("hard"))
+	if hard && !getClaims(c).HasRole(keycloak.RoleAdmin) {
+		c.JSON(http.StatusForbidden, errorBody(c, "Access denied"))
+		return
+	}
+
+	plumbus, ok := h.managedPlumbus(c)
+	if !ok {
+		return
+	}
+	userID, _ := currentUserID(c)
+
+	if hard {
+		if err := h.deletionService.HardDelete(c.Request.Context(), plumbus); err != nil {
+			h.log(c).WithError(err).WithField("plumbus_id", plumbus.ID).Error("Failed to hard-delete plumbus")
+			c.JSON(http.StatusInternalServerError, errorBody(c, "Failed to delete plumbus"))
+			return
+		}
+
+		h.log(c).WithFields(logrus.Fields{
+			"plumbus_id": plumbus.ID,
+			"owner_id":   plumbus.UserID,
+			"admin_id":   userID,
+		}).Warn("Plumbus hard-deleted by admin")
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if plumbus.DeletedAt.Valid {
+		c.JSON(http.StatusNotFound, errorBody(c, "Plumbus not found"))
+		return
+	}
+
+	restoreUntil, err := h.deletionService.Delete(c.Request.Context(), plumbus)
+	if err != nil {
+		h.log(c).WithError(err).WithField("plumbus_id", plumbus.ID).Error("Failed to delete plumbus")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":            plumbus.ID,
+		"restore_until": restoreUntil,
+	})
+}
+
+// RestorePlumbus восстанавливает мягко удаленный плюмбус в пределах окна восстановления
+func (h *Handler) RestorePlumbus(c *gin.Context) {
+	plumbus, ok := h.managedPlumbus(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deletionService.Restore(c.Request.Context(), plumbus); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPlumbusNotDeleted):
+			c.JSON(http.StatusConflict, errorBody(c, "Plumbus is not deleted"))
+		case errors.Is(err, services.ErrRestoreWindowExpired):
+			c.JSON(http.StatusGone, errorBody(c, "Restore window has expired"))
+		default:
+			h.log(c).WithError(err).WithField("plumbus_id", plumbus.ID).Error("Failed to restore plumbus")
+			c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
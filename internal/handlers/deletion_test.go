@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"factory/internal/keycloak"
+)
+
+// generatePlumbus создает плюмбус из браузерной сессии и возвращает его ID
+func (e *authTestEnv) generatePlumbus(t *testing.T, browser *http.Client) string {
+	t.Helper()
+	resp, data := do(t, browser, http.MethodPost, e.server.URL+"/plumbus/generate", "",
+		`{"name":"Disposable","size":"M","color":"pink","shape":"smooth","weight":"light","wrapping":"default"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Generate status = %d: %s", resp.StatusCode, data)
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil || created.ID == "" {
+		t.Fatalf("Invalid generate response %s: %v", data, err)
+	}
+	return created.ID
+}
+
+func TestDeletePlumbus_SoftDeleteAndRestore(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+	id := env.generatePlumbus(t, browser)
+
+	resp, data := do(t, browser, http.MethodDelete, env.server.URL+"/plumbus/"+id, "", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Delete status = %d: %s", resp.StatusCode, data)
+	}
+	var deleted struct {
+		RestoreUntil time.Time `json:"restore_until"`
+	}
+	if err := json.Unmarshal(data, &deleted); err != nil || !deleted.RestoreUntil.After(time.Now()) {
+		t.Errorf("Delete response = %s, want restore_until in the future", data)
+	}
+
+	// Удаленный плюмбус пропадает из списка и статуса
+	resp, data = do(t, browser, http.MethodGet, env.server.URL+"/plumbus/list", "", "")
+	var plumbuses []map[string]interface{}
+	if err := json.Unmarshal(data, &plumbuses); err != nil || len(plumbuses) != 0 {
+		t.Errorf("List after delete = %d %s, want empty list", resp.StatusCode, data)
+	}
+	resp, _ = do(t, browser, http.MethodGet, env.server.URL+"/plumbus/status/"+id, "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Status of deleted plumbus = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	resp, _ = do(t, browser, http.MethodPost, env.server.URL+"/plumbus/"+id+"/restore", "", "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Restore status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	resp, _ = do(t, browser, http.MethodPost, env.server.URL+"/plumbus/"+id+"/restore", "", "")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Restore of live plumbus status = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+	resp, _ = do(t, browser, http.MethodGet, env.server.URL+"/plumbus/status/"+id, "", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Status after restore = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestDeletePlumbus_OwnerOnly(t *testing.T) {
+	env := setupAuthTest(t)
+	owner := env.login(t)
+	id := env.generatePlumbus(t, owner)
+
+	env.provider.User.Subject = "kc-user-2"
+	env.provider.User.Username = "morty"
+	stranger := env.login(t)
+
+	resp, _ := do(t, stranger, http.MethodDelete, env.server.URL+"/plumbus/"+id, "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Delete foreign plumbus status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	do(t, owner, http.MethodDelete, env.server.URL+"/plumbus/"+id, "", "")
+	resp, _ = do(t, stranger, http.MethodPost, env.server.URL+"/plumbus/"+id+"/restore", "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Restore foreign plumbus status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// Безвозвратное удаление доступно только администратору
+	resp, _ = do(t, owner, http.MethodDelete, env.server.URL+"/plumbus/"+id+"?hard=true", "", "")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Hard delete as operator status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	env.provider.User.Subject = "kc-admin"
+	env.provider.User.Username = "rick"
+	env.provider.User.Roles = []string{keycloak.RoleAdmin}
+	admin := env.login(t)
+
+	resp, _ = do(t, admin, http.MethodDelete, env.server.URL+"/plumbus/"+id+"?hard=true", "", "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Hard delete as admin status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	resp, _ = do(t, owner, http.MethodPost, env.server.URL+"/plumbus/"+id+"/restore", "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Restore of hard-deleted plumbus status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
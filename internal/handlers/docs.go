@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"factory/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+	specErr  error
+)
+
+// OpenAPISpec отдает OpenAPI спецификацию API в формате JSON
+func (h *Handler) OpenAPISpec(c *gin.Context) {
+	specOnce.Do(func() {
+		specJSON, specErr = api.SpecJSON()
+	})
+	if specErr != nil {
+		h.log(c).WithError(specErr).Error("Failed to load OpenAPI spec")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "OpenAPI spec unavailable"))
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", specJSON)
+}
+
+// APIDocs отображает интерактивную документацию API (Swagger UI)
+func (h *Handler) APIDocs(c *gin.Context) {
+	c.HTML(http.StatusOK, "api_docs.html", gin.H{
+		"title":   "API - Rick & Morty Plumbus Factory",
+		"specURL": "/api/openapi.json",
+	})
+}
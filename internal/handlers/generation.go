@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"factory/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetryPlumbus повторно запускает генерацию плюмбуса, завершившуюся ошибкой,
+// с сохраненными параметрами
+func (h *Handler) RetryPlumbus(c *gin.Context) {
+	plumbus, ok := h.managedPlumbus(c)
+	if !ok {
+		return
+	}
+	if plumbus.DeletedAt.Valid {
+		c.JSON(http.StatusNotFound, errorBody(c, "Plumbus not found"))
+		return
+	}
+
+	retried, err := h.generationService.Retry(c.Request.Context(), plumbus)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPlumbusNotFailed):
+			c.JSON(http.StatusConflict, errorBody(c, "Only failed plumbuses can be retried"))
+		case errors.Is(err, services.ErrRetryLimitReached):
+			c.JSON(http.StatusUnprocessableEntity, errorBody(c, "Retry limit reached"))
+		case errors.Is(err, services.ErrRateLimited):
+			rateLimited(c, err)
+		default:
+			h.log(c).WithError(err).WithField("plumbus_id", plumbus.ID).Error("Failed to retry plumbus generation")
+			c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":       retried.ID,
+		"status":   retried.Status,
+		"attempts": retried.Attempts,
+	})
+}
+
+// rateLimited отвечает 429 с заголовком Retry-After, если лимит частоты
+// генераций сообщил время ожидания
+func rateLimited(c *gin.Context, err error) {
+	var limited *services.RateLimitError
+	if errors.As(err, &limited) {
+		c.Header("Retry-After", strconv.Itoa(int(limited.RetryAfter.Seconds())))
+	}
+	c.JSON(http.StatusTooManyRequests, errorBody(c, "Generation rate limit exceeded"))
+}
+
+// CancelPlumbus останавливает незавершенную генерацию плюмбуса
+func (h *Handler) CancelPlumbus(c *gin.Context) {
+	plumbus, ok := h.managedPlumbus(c)
+	if !ok {
+		return
+	}
+	if plumbus.DeletedAt.Valid {
+		c.JSON(http.StatusNotFound, errorBody(c, "Plumbus not found"))
+		return
+	}
+
+	if err := h.generationService.Cancel(c.Request.Context(), plumbus); err != nil {
+		if errors.Is(err, services.ErrPlumbusNotRunning) {
+			c.JSON(http.StatusConflict, errorBody(c, "Plumbus generation is not running"))
+			return
+		}
+		h.log(c).WithError(err).WithField("plumbus_id", plumbus.ID).Error("Failed to cancel plumbus generation")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListPlumbusAttempts возвращает историю попыток генерации плюмбуса
+func (h *Handler) ListPlumbusAttempts(c *gin.Context) {
+	plumbus, ok := h.visiblePlumbus(c)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.generationService.Attempts(plumbus)
+	if err != nil {
+		h.log(c).WithError(err).WithField("plumbus_id", plumbus.ID).Error("Failed to list plumbus attempts")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"factory/internal/models"
+	"factory/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// waitForAttempts ждет, пока в истории плюмбуса появится count завершенных попыток
+func (e *authTestEnv) waitForAttempts(t *testing.T, browser *http.Client, id string, count int) []models.PlumbusAttempt {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp, data := do(t, browser, http.MethodGet, e.server.URL+"/plumbus/"+id+"/attempts", "", "")
+		var attempts []models.PlumbusAttempt
+		json.Unmarshal(data, &attempts)
+		if resp.StatusCode == http.StatusOK && len(attempts) == count {
+			return attempts
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Attempts = %d %s, want %d attempts", resp.StatusCode, data, count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRetryPlumbus_UntilLimit(t *testing.T) {
+	env := setupAuthTest(t)
+	env.handler.generationService.MaxAttempts = 2
+	browser := env.login(t)
+
+	// Сервис генерации в тестах недоступен, поэтому каждая попытка завершается ошибкой
+	id := env.generatePlumbus(t, browser)
+	attempts := env.waitForAttempts(t, browser, id, 1)
+	if attempts[0].Attempt != 1 || attempts[0].Status != models.StatusFailed || attempts[0].Error == "" {
+		t.Errorf("first attempt = %+v, want failed attempt 1 with error", attempts[0])
+	}
+
+	resp, data := do(t, browser, http.MethodPost, env.server.URL+"/plumbus/"+id+"/retry", "", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Retry status = %d: %s", resp.StatusCode, data)
+	}
+	var retried struct {
+		Attempts int `json:"attempts"`
+	}
+	if err := json.Unmarshal(data, &retried); err != nil || retried.Attempts != 2 {
+		t.Errorf("Retry response = %s, want attempts 2", data)
+	}
+
+	attempts = env.waitForAttempts(t, browser, id, 2)
+	if attempts[1].Attempt != 2 || attempts[1].Status != models.StatusFailed {
+		t.Errorf("second attempt = %+v, want failed attempt 2", attempts[1])
+	}
+
+	resp, _ = do(t, browser, http.MethodPost, env.server.URL+"/plumbus/"+id+"/retry", "", "")
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Retry beyond limit status = %d, want %d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestRetryPlumbus_Rejected(t *testing.T) {
+	env := setupAuthTest(t)
+	owner := env.login(t)
+	id := env.generatePlumbus(t, owner)
+	env.waitForAttempts(t, owner, id, 1)
+
+	env.provider.User.Subject = "kc-user-2"
+	env.provider.User.Username = "morty"
+	stranger := env.login(t)
+	resp, _ := do(t, stranger, http.MethodPost, env.server.URL+"/plumbus/"+id+"/retry", "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Retry foreign plumbus status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	resp, _ = do(t, stranger, http.MethodGet, env.server.URL+"/plumbus/"+id+"/attempts", "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Attempts of foreign plumbus status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// Плюмбус без ошибки генерации повторить нельзя
+	plumbusID := env.generatePlumbus(t, stranger)
+	env.waitForAttempts(t, stranger, plumbusID, 1)
+	env.handler.userService.UpdatePlumbusStatus(uuid.MustParse(plumbusID), models.StatusCompleted, nil, nil, nil, nil)
+	resp, _ = do(t, stranger, http.MethodPost, env.server.URL+"/plumbus/"+plumbusID+"/retry", "", "")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Retry of completed plumbus status = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+}
+
+func TestCancelPlumbus_Rejected(t *testing.T) {
+	env := setupAuthTest(t)
+	owner := env.login(t)
+	id := env.generatePlumbus(t, owner)
+	env.waitForAttempts(t, owner, id, 1)
+
+	// Завершившуюся генерацию отменить нельзя
+	resp, _ := do(t, owner, http.MethodPost, env.server.URL+"/plumbus/"+id+"/cancel", "", "")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Cancel of failed plumbus status = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	env.provider.User.Subject = "kc-user-2"
+	env.provider.User.Username = "morty"
+	stranger := env.login(t)
+	resp, _ = do(t, stranger, http.MethodPost, env.server.URL+"/plumbus/"+id+"/cancel", "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Cancel foreign plumbus status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestGeneratePlumbus_RateLimited(t *testing.T) {
+	env := setupAuthTest(t)
+	env.handler.generationService.RateLimiter = services.NewRateLimiter(1)
+	browser := env.login(t)
+
+	env.generatePlumbus(t, browser)
+
+	body := `{"name":"Limited","size":"M","color":"pink","shape":"smooth","weight":"light","wrapping":"default"}`
+	resp, data := do(t, browser, http.MethodPost, env.server.URL+"/plumbus/generate", "", body)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Generate over limit status = %d: %s, want %d", resp.StatusCode, data, http.StatusTooManyRequests)
+	}
+	if resp.Header.Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q, want 60", resp.Header.Get("Retry-After"))
+	}
+}
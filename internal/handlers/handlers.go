@@ -1,40 +1,70 @@
 package handlers
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
+	"factory/internal/config"
 	"factory/internal/keycloak"
 	"factory/internal/logger"
 	"factory/internal/models"
 	"factory/internal/services"
+	"factory/internal/session"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+// Ключи контекста gin, заполняемые AuthMiddleware
+const (
+	claimsKey  = "claims"
+	userIDKey  = "user_id"
+	sessionKey = "session"
+)
+
 type Handler struct {
-	plumbusService   *services.PlumbusService
-	userService      *services.UserService
-	signatureService *services.SignatureService
-	eventsService    *services.EventsService
-	keycloakClient   *keycloak.Client
-	logger           *logrus.Logger
+	userService       *services.UserService
+	generationService *services.GenerationService
+	deletionService   *services.DeletionService
+	tokenService      *services.TokenService
+	webhookService    *services.WebhookService
+	healthService     *services.HealthService
+	keycloakClient    *keycloak.Client
+	sessions          *session.Manager
+	logger            *logrus.Logger
+
+	// ConfigReloader перезагружает настройки по запросу администратора.
+	// nil - перезагрузка через API недоступна.
+	ConfigReloader *config.Reloader
 }
 
-func NewHandler(ps *services.PlumbusService, us *services.UserService, ss *services.SignatureService, es *services.EventsService, kc *keycloak.Client) *Handler {
+func NewHandler(us *services.UserService, gs *services.GenerationService, ds *services.DeletionService, ts *services.TokenService, ws *services.WebhookService, hs *services.HealthService, kc *keycloak.Client, sm *session.Manager) *Handler {
 	return &Handler{
-		plumbusService:   ps,
-		userService:      us,
-		signatureService: ss,
-		eventsService:    es,
-		keycloakClient:   kc,
-		logger:           logger.Init(),
+		userService:       us,
+		generationService: gs,
+		deletionService:   ds,
+		tokenService:      ts,
+		webhookService:    ws,
+		healthService:     hs,
+		keycloakClient:    kc,
+		sessions:          sm,
+		logger:            logger.For("handlers"),
 	}
 }
 
+// log возвращает логгер, добавляющий в записи ID запроса, пользователя и плюмбуса
+func (h *Handler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+// users возвращает сервис пользователей, запросы которого попадают в трейс HTTP запроса
+func (h *Handler) users(c *gin.Context) *services.UserService {
+	return h.userService.WithContext(c.Request.Context())
+}
+
 func (h *Handler) HomePage(c *gin.Context) {
 	c.HTML(http.StatusOK, "index.html", gin.H{
 		"title": "Rick & Morty Plumbus Factory",
@@ -42,190 +72,341 @@ func (h *Handler) HomePage(c *gin.Context) {
 }
 
 func (h *Handler) Login(c *gin.Context) {
+	state, err := newLoginState(c.Query("return_to"))
+	if err != nil {
+		h.log(c).WithError(err).Error("Failed to generate login state")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Authentication failed"))
+		return
+	}
+
+	if err := writeLoginState(c.Writer, h.sessions.Signer(), state); err != nil {
+		h.log(c).WithError(err).Error("Failed to save login state")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Authentication failed"))
+		return
+	}
+
 	redirectURI := fmt.Sprintf("http://%s/auth/callback", c.Request.Host)
-	loginURL := h.keycloakClient.GetLoginURL(redirectURI)
+	loginURL := h.keycloakClient.GetLoginURL(redirectURI, keycloak.AuthRequest{
+		State:         state.State,
+		Nonce:         state.Nonce,
+		CodeChallenge: state.codeChallenge(),
+	})
 	c.Redirect(http.StatusTemporaryRedirect, loginURL)
 }
 
 func (h *Handler) AuthCallback(c *gin.Context) {
+	// Проверяем state до любых обращений к Keycloak (защита от CSRF)
+	state, err := consumeLoginState(c.Writer, c.Request, h.sessions.Signer(), c.Query("state"))
+	if err != nil {
+		h.log(c).WithError(err).Warn("Auth callback rejected: invalid state")
+		c.JSON(http.StatusBadRequest, errorBody(c, "Invalid login state"))
+		return
+	}
+
+	if errCode := c.Query("error"); errCode != "" {
+		h.log(c).WithFields(logrus.Fields{
+			"error":             errCode,
+			"error_description": c.Query("error_description"),
+		}).Warn("Keycloak returned authorization error")
+		c.JSON(http.StatusBadRequest, errorBody(c, "Authentication failed"))
+		return
+	}
+
 	code := c.Query("code")
 	if code == "" {
-		h.logger.WithField("error", "missing_authorization_code").Error("Auth callback failed")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing authorization code"})
+		h.log(c).WithField("error", "missing_authorization_code").Error("Auth callback failed")
+		c.JSON(http.StatusBadRequest, errorBody(c, "Missing authorization code"))
 		return
 	}
 
 	redirectURI := fmt.Sprintf("http://%s/auth/callback", c.Request.Host)
 
-	token, err := h.keycloakClient.ExchangeCodeForToken(context.Background(), code, redirectURI)
+	token, err := h.keycloakClient.ExchangeCodeForToken(c.Request.Context(), code, redirectURI, state.CodeVerifier)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to exchange code for token")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Authentication failed"})
+		h.log(c).WithError(err).Error("Failed to exchange code for token")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Authentication failed"))
 		return
 	}
 
-	// Получаем информацию о пользователе
-	tokenPreview := token.AccessToken
-	if len(tokenPreview) > 50 {
-		tokenPreview = tokenPreview[:50] + "..."
+	// ID токен должен содержать nonce, выданный этому браузеру
+	if err := h.keycloakClient.VerifyIDToken(c.Request.Context(), token.IDToken, state.Nonce); err != nil {
+		h.log(c).WithError(err).Warn("ID token verification failed")
+		c.JSON(http.StatusUnauthorized, errorBody(c, "Authentication failed"))
+		return
 	}
-	h.logger.WithField("token_preview", tokenPreview).Debug("Token received")
 
-	userInfo, err := h.keycloakClient.VerifyToken(context.Background(), token.AccessToken)
+	// Получаем информацию о пользователе
+	h.log(c).Debug("Token received")
+
+	claims, err := h.keycloakClient.VerifyToken(c.Request.Context(), token.AccessToken)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to get user info")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
+		h.log(c).WithError(err).Error("Failed to verify access token")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Failed to get user info"))
 		return
 	}
 
-	h.logger.WithField("user_info", userInfo).Debug("UserInfo received")
+	h.log(c).WithFields(logrus.Fields{
+		"sub":          claims.Subject,
+		"username":     claims.PreferredUsername,
+		"email":        claims.Email,
+		"realm_roles":  claims.RealmRoles,
+		"client_roles": claims.ClientRoles,
+	}).Debug("Token claims received")
 
 	// Проверяем, что все необходимые поля присутствуют
-	if userInfo.Sub == nil || userInfo.PreferredUsername == nil || userInfo.Email == nil {
-		h.logger.WithFields(logrus.Fields{
-			"sub":      userInfo.Sub,
-			"username": userInfo.PreferredUsername,
-			"email":    userInfo.Email,
+	if claims.Subject == "" || claims.PreferredUsername == "" || claims.Email == "" {
+		h.log(c).WithFields(logrus.Fields{
+			"sub":      claims.Subject,
+			"username": claims.PreferredUsername,
+			"email":    claims.Email,
 		}).Error("Missing required user info fields")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Incomplete user information"})
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Incomplete user information"))
 		return
 	}
 
 	// Создаем или получаем пользователя в БД
-	user, err := h.userService.GetOrCreateUser(*userInfo.Sub, *userInfo.PreferredUsername, *userInfo.Email)
+	user, err := h.users(c).GetOrCreateUser(claims.Subject, claims.PreferredUsername, claims.Email)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to create/get user")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		h.log(c).WithError(err).Error("Failed to create/get user")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
 		return
 	}
 
-	// Сохраняем токен в сессии
-	c.SetCookie("access_token", token.AccessToken, 3600, "/", "", false, true)
-	c.SetCookie("user_id", user.ID.String(), 3600, "/", "", false, false)
+	// Сохраняем токены в серверной сессии, браузер получает только подписанный ID
+	owner := session.Owner{
+		UserID:            user.ID,
+		Subject:           claims.Subject,
+		KeycloakSessionID: claims.SessionID,
+	}
+	if _, err := h.sessions.Start(c.Writer, c.Request, owner, token); err != nil {
+		h.log(c).WithError(err).Error("Failed to start session")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Session error"))
+		return
+	}
 
-	h.logger.WithFields(logrus.Fields{
+	h.log(c).WithFields(logrus.Fields{
 		"user_id":  user.ID,
 		"username": user.Username,
 	}).Info("User authenticated successfully")
 
-	c.Redirect(http.StatusTemporaryRedirect, "/dashboard")
+	c.Redirect(http.StatusTemporaryRedirect, state.ReturnURL)
 }
 
 func (h *Handler) Logout(c *gin.Context) {
-	c.SetCookie("access_token", "", -1, "/", "", false, true)
-	c.SetCookie("user_id", "", -1, "/", "", false, false)
-	h.logger.Info("User logged out")
-	c.Redirect(http.StatusTemporaryRedirect, "/")
+	sess := h.sessions.Destroy(c.Writer, c.Request)
+	if sess == nil {
+		c.Redirect(http.StatusTemporaryRedirect, "/")
+		return
+	}
+
+	h.log(c).WithField("user_id", sess.UserID).Info("User logged out")
+
+	// Завершаем SSO сессию в Keycloak, иначе следующий вход пройдет без пароля
+	postLogoutURI := fmt.Sprintf("http://%s/", c.Request.Host)
+	c.Redirect(http.StatusTemporaryRedirect, h.keycloakClient.GetLogoutURL(sess.IDToken, postLogoutURI))
+}
+
+// BackchannelLogout принимает logout токен от Keycloak, когда пользователь
+// вышел в другом приложении или сессия завершена администратором
+func (h *Handler) BackchannelLogout(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	logoutToken := c.PostForm("logout_token")
+	if logoutToken == "" {
+		c.JSON(http.StatusBadRequest, errorBody(c, "invalid_request"))
+		return
+	}
+
+	claims, err := h.keycloakClient.VerifyLogoutToken(c.Request.Context(), logoutToken)
+	if err != nil {
+		h.log(c).WithError(err).Warn("Back-channel logout rejected")
+		c.JSON(http.StatusBadRequest, errorBody(c, "invalid_request"))
+		return
+	}
+
+	revoked, err := h.sessions.RevokeKeycloakSession(c.Request.Context(), claims.SessionID, claims.Subject)
+	if err != nil {
+		h.log(c).WithError(err).Error("Failed to revoke sessions")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "server_error"))
+		return
+	}
+
+	h.log(c).WithFields(logrus.Fields{
+		"sid":      claims.SessionID,
+		"sub":      claims.Subject,
+		"sessions": revoked,
+	}).Info("Sessions revoked by back-channel logout")
+
+	c.Status(http.StatusOK)
 }
 
 func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token, err := c.Cookie("access_token")
+		sess, err := h.sessions.Load(c.Request)
+		if err != nil {
+			// После входа возвращаем пользователя на запрошенную страницу
+			if c.Request.Method == http.MethodGet {
+				c.Redirect(http.StatusTemporaryRedirect, "/auth/login?return_to="+url.QueryEscape(c.Request.URL.RequestURI()))
+			} else {
+				c.Redirect(http.StatusTemporaryRedirect, "/")
+			}
+			c.Abort()
+			return
+		}
+
+		// Обновляем токены заранее, чтобы пользователя не выбрасывало на страницу входа
+		sess, err = h.sessions.EnsureFresh(c.Writer, c.Request, sess)
 		if err != nil {
+			h.log(c).WithError(err).Info("Session refresh failed")
+			h.sessions.Destroy(c.Writer, c.Request)
 			c.Redirect(http.StatusTemporaryRedirect, "/")
 			c.Abort()
 			return
 		}
 
-		// Верифицируем токен
-		_, err = h.keycloakClient.VerifyToken(context.Background(), token)
+		// Верифицируем токен и извлекаем роли пользователя
+		claims, err := h.keycloakClient.VerifyToken(c.Request.Context(), sess.AccessToken)
 		if err != nil {
-			h.logger.WithError(err).Debug("Token verification failed")
-			c.SetCookie("access_token", "", -1, "/", "", false, true)
-			c.SetCookie("user_id", "", -1, "/", "", false, false)
+			h.log(c).WithError(err).Debug("Token verification failed")
+			h.sessions.Destroy(c.Writer, c.Request)
 			c.Redirect(http.StatusTemporaryRedirect, "/")
 			c.Abort()
 			return
 		}
 
+		c.Set(claimsKey, claims)
+		setUserID(c, sess.UserID)
+		c.Set(sessionKey, sess)
+
 		c.Next()
 	}
 }
 
+// RequireRole пропускает запрос, только если у пользователя есть хотя бы одна из ролей.
+// Должен использоваться после AuthMiddleware.
+func (h *Handler) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := getClaims(c)
+		if !claims.HasAnyRole(roles...) {
+			h.log(c).WithFields(logrus.Fields{
+				"path":           c.Request.URL.Path,
+				"required_roles": roles,
+				"user_roles":     claims.Roles(),
+			}).Warn("Access denied: missing role")
+			c.AbortWithStatusJSON(http.StatusForbidden, errorBody(c, "Insufficient permissions"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// getClaims возвращает claims текущего пользователя, сохраненные AuthMiddleware
+func getClaims(c *gin.Context) *keycloak.Claims {
+	if value, ok := c.Get(claimsKey); ok {
+		if claims, ok := value.(*keycloak.Claims); ok {
+			return claims
+		}
+	}
+	return &keycloak.Claims{}
+}
+
+// currentUserID возвращает ID пользователя текущей сессии
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	if value, ok := c.Get(userIDKey); ok {
+		if userID, ok := value.(uuid.UUID); ok {
+			return userID, true
+		}
+	}
+	return uuid.Nil, false
+}
+
+// permissions возвращает набор разрешенных действий для шаблонов
+func permissions(claims *keycloak.Claims) gin.H {
+	return gin.H{
+		"view":     claims.HasRole(keycloak.RoleViewer),
+		"generate": claims.HasRole(keycloak.RoleOperator),
+		"delete":   claims.HasRole(keycloak.RoleOperator),
+		"admin":    claims.HasRole(keycloak.RoleAdmin),
+	}
+}
+
 func (h *Handler) Dashboard(c *gin.Context) {
-	userIDStr, _ := c.Cookie("user_id")
-	userID, _ := uuid.Parse(userIDStr)
+	userID, _ := currentUserID(c)
 
 	// Получаем информацию о пользователе
-	user, err := h.userService.GetUserByID(userID)
+	user, err := h.users(c).GetUserByID(userID)
 	if err != nil {
-		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user info")
+		h.log(c).WithError(err).WithField("user_id", userID).Error("Failed to get user info")
 		c.Redirect(http.StatusTemporaryRedirect, "/")
 		return
 	}
 
-	plumbuses, err := h.userService.GetUserPlumbuses(userID)
+	plumbuses, err := h.users(c).GetUserPlumbuses(userID)
 	if err != nil {
-		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user plumbuses")
+		h.log(c).WithError(err).WithField("user_id", userID).Error("Failed to get user plumbuses")
 		plumbuses = []models.Plumbus{}
 	}
 
+	tokens, err := h.tokenService.ListTokens(userID)
+	if err != nil {
+		h.log(c).WithError(err).WithField("user_id", userID).Error("Failed to get user API tokens")
+		tokens = []models.APIToken{}
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(userID)
+	if err != nil {
+		h.log(c).WithError(err).WithField("user_id", userID).Error("Failed to get user webhooks")
+		webhooks = []models.Webhook{}
+	}
+
+	claims := getClaims(c)
+
 	c.HTML(http.StatusOK, "dashboard.html", gin.H{
 		"title":     "Dashboard - Rick & Morty Plumbus Factory",
 		"user":      user,
 		"plumbuses": plumbuses,
+		"tokens":    tokens,
+		"webhooks":  webhooks,
+		"scopes":    scopesForClaims(claims),
+		"roles":     claims.Roles(),
+		"can":       permissions(claims),
+		// Кнопка повтора показывается, пока не исчерпан лимит попыток генерации
+		"maxAttempts": h.generationService.MaxAttempts,
 	})
 }
 
 func (h *Handler) GeneratePlumbus(c *gin.Context) {
 	var req models.PlumbusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid plumbus request")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.log(c).WithError(err).Error("Invalid plumbus request")
+		c.JSON(http.StatusBadRequest, errorBody(c, err.Error()))
 		return
 	}
 
-	userIDStr, _ := c.Cookie("user_id")
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		h.logger.WithError(err).Error("Invalid user ID")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	userID, ok := currentUserID(c)
+	if !ok {
+		h.log(c).Error("Invalid user ID")
+		c.JSON(http.StatusBadRequest, errorBody(c, "Invalid user ID"))
 		return
 	}
 
-	// Создаем запись плюмбуса в БД
-	plumbus, err := h.userService.CreatePlumbus(userID, req)
+	// Создаем запись плюмбуса и запускаем генерацию
+	plumbus, err := h.generationService.Start(c.Request.Context(), userID, req)
+	if errors.Is(err, services.ErrRateLimited) {
+		h.log(c).WithField("user_id", userID).Warn("Plumbus generation rate limited")
+		rateLimited(c, err)
+		return
+	}
 	if err != nil {
-		h.logger.WithError(err).WithFields(logrus.Fields{
+		h.log(c).WithError(err).WithFields(logrus.Fields{
 			"user_id": userID,
 			"request": req,
 		}).Error("Failed to create plumbus")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
 		return
 	}
 
-	h.logger.WithFields(logrus.Fields{
-		"plumbus_id": plumbus.ID,
-		"user_id":    userID,
-		"is_rare":    plumbus.IsRare,
-		"name":       req.Name,
-	}).Info("Plumbus created successfully")
-
-	// Отправляем событие о создании плюмбуса в NATS
-	if h.eventsService != nil {
-		// Получаем информацию о пользователе для события
-		user, err := h.userService.GetUserByID(userID)
-		if err != nil {
-			h.logger.WithError(err).WithField("user_id", userID).Warn("Failed to get user info for event")
-		} else {
-			// Отправляем событие в горутине чтобы не блокировать основной поток
-			go func() {
-				if err := h.eventsService.PublishPlumbusCreated(user, plumbus, req); err != nil {
-					h.logger.WithError(err).WithField("plumbus_id", plumbus.ID).Warn("Failed to publish plumbus created event")
-				}
-			}()
-		}
-	}
-
-	// Запускаем генерацию в горутине
-	go h.generatePlumbusAsync(plumbus.ID, models.PlumbusGenerationRequest{
-		Size:     req.Size,
-		Color:    req.Color,
-		Shape:    req.Shape,
-		Weight:   req.Weight,
-		Wrapping: req.Wrapping,
-	})
-
 	c.JSON(http.StatusOK, gin.H{
 		"id":      plumbus.ID,
 		"status":  "generating",
@@ -233,62 +414,10 @@ func (h *Handler) GeneratePlumbus(c *gin.Context) {
 	})
 }
 
-func (h *Handler) generatePlumbusAsync(plumbusID uuid.UUID, req models.PlumbusGenerationRequest) {
-	// Обновляем статус на "generating"
-	h.userService.UpdatePlumbusStatus(plumbusID, models.StatusGenerating, nil, nil, nil, nil)
-
-	h.logger.WithFields(logrus.Fields{
-		"plumbus_id": plumbusID,
-		"request":    req,
-	}).Info("Starting plumbus generation")
-
-	// Генерируем плюмбус
-	imagePath, err := h.plumbusService.GeneratePlumbus(req)
-	if err != nil {
-		h.logger.WithError(err).WithField("plumbus_id", plumbusID).Error("Failed to generate plumbus")
-		errorMsg := err.Error()
-		h.userService.UpdatePlumbusStatus(plumbusID, models.StatusFailed, nil, &errorMsg, nil, nil)
-		return
-	}
-
-	// Подписываем изображение плюмбуса
-	h.logger.WithFields(logrus.Fields{
-		"plumbus_id": plumbusID,
-		"image_path": imagePath,
-	}).Info("Signing plumbus image")
-
-	signatureResponse, err := h.signatureService.SignFile(imagePath)
-	if err != nil {
-		h.logger.WithError(err).WithField("plumbus_id", plumbusID).Error("Failed to sign plumbus image")
-		// Не считаем это критической ошибкой, продолжаем без подписи
-		h.userService.UpdatePlumbusStatus(plumbusID, models.StatusCompleted, &imagePath, nil, nil, nil)
-		return
-	}
-
-	h.logger.WithFields(logrus.Fields{
-		"plumbus_id":    plumbusID,
-		"signature":     signatureResponse.Signature[:20] + "...",
-		"serial_number": signatureResponse.SerialNumber,
-	}).Info("Plumbus signed successfully")
-
-	// Обновляем статус на "completed" с подписью
-	h.userService.UpdatePlumbusStatus(plumbusID, models.StatusCompleted, &imagePath, nil,
-		&signatureResponse.Signature, &signatureResponse.CreatedAt)
-}
-
 func (h *Handler) GetPlumbusStatus(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		h.logger.WithError(err).WithField("id_string", idStr).Error("Invalid plumbus ID")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
-		return
-	}
-
-	plumbus, err := h.userService.GetPlumbus(id)
-	if err != nil {
-		h.logger.WithError(err).WithField("plumbus_id", id).Error("Plumbus not found")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Plumbus not found"})
+	// Чужие плюмбусы видит только администратор
+	plumbus, ok := h.visiblePlumbus(c)
+	if !ok {
 		return
 	}
 
@@ -299,32 +428,25 @@ func (h *Handler) GetPlumbusStatus(c *gin.Context) {
 		"is_rare":        plumbus.IsRare,
 		"signature":      plumbus.Signature,
 		"signature_date": plumbus.SignatureDate,
+		"attempts":       plumbus.Attempts,
 	})
 }
 
 func (h *Handler) GetPlumbusImage(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		h.logger.WithError(err).WithField("id_string", idStr).Error("Invalid plumbus ID")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+	plumbus, ok := h.visiblePlumbus(c)
+	if !ok {
 		return
 	}
+	id := plumbus.ID
 
-	plumbus, err := h.userService.GetPlumbus(id)
-	if err != nil {
-		h.logger.WithError(err).WithField("plumbus_id", id).Error("Plumbus not found")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Plumbus not found"})
-		return
-	}
-
-	if plumbus.Status != models.StatusCompleted || plumbus.ImagePath == nil {
-		h.logger.WithFields(logrus.Fields{
+	if plumbus.Status != models.StatusCompleted || plumbus.ImagePath == nil || plumbus.Broken {
+		h.log(c).WithFields(logrus.Fields{
 			"plumbus_id": id,
 			"status":     plumbus.Status,
 			"has_image":  plumbus.ImagePath != nil,
+			"broken":     plumbus.Broken,
 		}).Warn("Image not available")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Image not available"})
+		c.JSON(http.StatusNotFound, errorBody(c, "Image not available"))
 		return
 	}
 
@@ -332,18 +454,17 @@ func (h *Handler) GetPlumbusImage(c *gin.Context) {
 }
 
 func (h *Handler) GetUserPlumbuses(c *gin.Context) {
-	userIDStr, _ := c.Cookie("user_id")
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		h.logger.WithError(err).WithField("user_id_string", userIDStr).Error("Invalid user ID")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+	userID, ok := currentUserID(c)
+	if !ok {
+		h.log(c).Error("Invalid user ID")
+		c.JSON(http.StatusBadRequest, errorBody(c, "Invalid user ID"))
 		return
 	}
 
-	plumbuses, err := h.userService.GetUserPlumbuses(userID)
+	plumbuses, err := h.users(c).GetUserPlumbuses(userID)
 	if err != nil {
-		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user plumbuses")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		h.log(c).WithError(err).WithField("user_id", userID).Error("Failed to get user plumbuses")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
 		return
 	}
 
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"factory/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Liveness отвечает, пока процесс способен обслуживать запросы. Зависимости
+// не проверяются, чтобы их недоступность не приводила к перезапуску фабрики.
+func (h *Handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": services.HealthOK})
+}
+
+// Readiness проверяет зависимости и возвращает 503, если недоступна хотя бы одна критичная
+func (h *Handler) Readiness(c *gin.Context) {
+	report := h.healthService.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == services.HealthUnavailable {
+		status = http.StatusServiceUnavailable
+		h.log(c).WithField("dependencies", report.Dependencies).Warn("Readiness check failed")
+	}
+	c.JSON(status, report)
+}
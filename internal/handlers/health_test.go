@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"factory/internal/services"
+)
+
+func readiness(t *testing.T, env *authTestEnv) (int, services.HealthReport) {
+	t.Helper()
+	resp, err := http.Get(env.server.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("GET /readyz failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var report services.HealthReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode readiness report: %v", err)
+	}
+	return resp.StatusCode, report
+}
+
+func TestReadiness_DependencyBreakdown(t *testing.T) {
+	env := setupAuthTest(t)
+
+	status, report := readiness(t, env)
+	if status != http.StatusOK || report.Status != services.HealthOK {
+		t.Fatalf("readiness = %d %s, want 200 ok", status, report.Status)
+	}
+	if db := report.Dependencies[services.DependencyDatabase]; db.Status != services.DependencyUp || !db.Critical {
+		t.Errorf("database = %+v, want critical and up", db)
+	}
+
+	// Недоступность необязательной зависимости не снимает готовность
+	env.handler.healthService.Register(services.DependencySigStore, false, func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	status, report = readiness(t, env)
+	if status != http.StatusOK || report.Status != services.HealthDegraded {
+		t.Errorf("readiness with optional dependency down = %d %s, want 200 degraded", status, report.Status)
+	}
+	if sig := report.Dependencies[services.DependencySigStore]; sig.Status != services.DependencyDown || sig.Error != "connection refused" {
+		t.Errorf("sigstore = %+v, want down with error", sig)
+	}
+
+	env.handler.healthService.Register(services.DependencyNATS, true, func(ctx context.Context) error {
+		return errors.New("not connected")
+	})
+	status, report = readiness(t, env)
+	if status != http.StatusServiceUnavailable || report.Status != services.HealthUnavailable {
+		t.Errorf("readiness with critical dependency down = %d %s, want 503 unavailable", status, report.Status)
+	}
+
+	// Liveness не зависит от зависимостей
+	resp, err := http.Get(env.server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("GET /healthz failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("liveness status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"factory/internal/session"
+)
+
+const (
+	// loginStateCookie хранит подписанное состояние незавершенного входа
+	loginStateCookie = "factory_login"
+	// loginStateTTL - время, за которое пользователь должен завершить вход в Keycloak
+	loginStateTTL = 10 * time.Minute
+	// defaultReturnURL - страница после входа, если адрес возврата не указан
+	defaultReturnURL = "/dashboard"
+)
+
+var errInvalidLoginState = errors.New("invalid login state")
+
+// loginState - параметры OIDC запроса, которые нужно проверить в callback
+type loginState struct {
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	ReturnURL    string    `json:"return_url"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// newLoginState генерирует state, nonce и PKCE code verifier
+func newLoginState(returnURL string) (*loginState, error) {
+	state, err := session.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := session.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := session.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ReturnURL:    safeReturnURL(returnURL),
+		ExpiresAt:    time.Now().Add(loginStateTTL),
+	}, nil
+}
+
+// codeChallenge возвращает S256 PKCE challenge для code verifier
+func (s *loginState) codeChallenge() string {
+	sum := sha256.Sum256([]byte(s.CodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// writeLoginState сохраняет состояние входа в подписанной cookie
+func writeLoginState(w http.ResponseWriter, signer *session.Signer, state *loginState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginStateCookie,
+		Value:    signer.Sign(string(data)),
+		Path:     "/auth",
+		MaxAge:   int(loginStateTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// consumeLoginState читает состояние входа, удаляет cookie и сверяет state из callback
+func consumeLoginState(w http.ResponseWriter, r *http.Request, signer *session.Signer, state string) (*loginState, error) {
+	// Состояние одноразовое: удаляем cookie при любом исходе
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginStateCookie,
+		Value:    "",
+		Path:     "/auth",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	cookie, err := r.Cookie(loginStateCookie)
+	if err != nil {
+		return nil, errInvalidLoginState
+	}
+
+	data, err := signer.Verify(cookie.Value)
+	if err != nil {
+		return nil, errInvalidLoginState
+	}
+
+	var stored loginState
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, errInvalidLoginState
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, errInvalidLoginState
+	}
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(stored.State), []byte(state)) != 1 {
+		return nil, errInvalidLoginState
+	}
+
+	return &stored, nil
+}
+
+// safeReturnURL допускает только относительные пути внутри фабрики (защита от open redirect)
+func safeReturnURL(raw string) string {
+	if raw == "" || !strings.HasPrefix(raw, "/") || strings.HasPrefix(raw, "//") || strings.Contains(raw, "\\") {
+		return defaultReturnURL
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "" || parsed.Host != "" || strings.HasPrefix(parsed.Path, "/auth/") {
+		return defaultReturnURL
+	}
+
+	return parsed.RequestURI()
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"factory/api"
+	"factory/internal/config"
+	"factory/internal/keycloak"
+	"factory/internal/services"
+	"factory/pkg/factoryclient"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// pathParam - параметр пути OpenAPI ({id}), соответствующий параметру gin (:id)
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(api.Spec)
+	if err != nil {
+		t.Fatalf("Failed to load OpenAPI spec: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("OpenAPI spec is invalid: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPI_SpecJSON(t *testing.T) {
+	loadSpec(t)
+
+	data, err := api.SpecJSON()
+	if err != nil {
+		t.Fatalf("SpecJSON() error = %v", err)
+	}
+	if _, err := openapi3.NewLoader().LoadFromData(data); err != nil {
+		t.Errorf("JSON spec cannot be loaded: %v", err)
+	}
+}
+
+// TestOpenAPI_RoutesMatchSpec проверяет, что каждый маршрут описан в спецификации и наоборот
+func TestOpenAPI_RoutesMatchSpec(t *testing.T) {
+	doc := loadSpec(t)
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+pathParam.ReplaceAllString(path, ":$1")] = true
+		}
+	}
+
+	router := gin.New()
+	(&Handler{}).RegisterRoutes(router)
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	var missing, stale []string
+	for route := range registered {
+		if !documented[route] {
+			missing = append(missing, route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			stale = append(stale, route)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+
+	if len(missing) > 0 {
+		t.Errorf("Routes missing from api/openapi.yaml: %v", missing)
+	}
+	if len(stale) > 0 {
+		t.Errorf("Routes documented in api/openapi.yaml but not registered: %v", stale)
+	}
+}
+
+// contractEnv проверяет запросы и ответы фабрики по спецификации
+type contractEnv struct {
+	*authTestEnv
+	router routers.Router
+}
+
+func setupContractTest(t *testing.T) *contractEnv {
+	t.Helper()
+	env := setupAuthTest(t)
+
+	doc := loadSpec(t)
+	doc.Servers = openapi3.Servers{{URL: env.server.URL}}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("Failed to build OpenAPI router: %v", err)
+	}
+
+	return &contractEnv{authTestEnv: env, router: router}
+}
+
+// call выполняет запрос и проверяет, что запрос и ответ соответствуют спецификации
+func (e *contractEnv) call(t *testing.T, client *http.Client, method, path, bearer, contentType, body string) (int, []byte) {
+	t.Helper()
+	return e.exchange(t, client, method, path, bearer, contentType, body, true)
+}
+
+// callInvalid отправляет заведомо некорректный запрос и проверяет только ответ
+func (e *contractEnv) callInvalid(t *testing.T, client *http.Client, method, path, bearer, contentType, body string) (int, []byte) {
+	t.Helper()
+	return e.exchange(t, client, method, path, bearer, contentType, body, false)
+}
+
+func (e *contractEnv) exchange(t *testing.T, client *http.Client, method, path, bearer, contentType, body string, validateRequest bool) (int, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, e.server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	route, pathParams, err := e.router.FindRoute(req)
+	if err != nil {
+		t.Fatalf("%s %s is not described in the spec: %v", method, path, err)
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+	}
+	if err := openapi3filter.ValidateRequest(context.Background(), input); validateRequest && err != nil {
+		t.Fatalf("%s %s: request does not match the spec: %v", method, path, err)
+	}
+	req.Body = io.NopCloser(strings.NewReader(body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 resp.StatusCode,
+		Header:                 resp.Header,
+		Body:                   io.NopCloser(bytes.NewReader(data)),
+		Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+	})
+	if err != nil {
+		t.Errorf("%s %s: response %d does not match the spec: %v\n%s", method, path, resp.StatusCode, err, data)
+	}
+
+	return resp.StatusCode, data
+}
+
+// TestOpenAPI_ResponsesMatchSpec проверяет фактические ответы обработчиков по спецификации
+func TestOpenAPI_ResponsesMatchSpec(t *testing.T) {
+	env := setupContractTest(t)
+	browser := env.login(t)
+	bearer := env.provider.AccessToken(t)
+	client := http.DefaultClient
+	const jsonType = "application/json"
+
+	env.call(t, client, http.MethodGet, "/health", "", "", "")
+	env.call(t, client, http.MethodGet, "/healthz", "", "", "")
+	env.call(t, client, http.MethodGet, "/readyz", "", "", "")
+	env.call(t, client, http.MethodGet, "/metrics", "", "", "")
+	env.call(t, client, http.MethodGet, "/api/openapi.json", "", "", "")
+
+	// API v1
+	env.call(t, client, http.MethodGet, "/api/v1/me", "", "", "")
+	env.call(t, client, http.MethodGet, "/api/v1/me", bearer, "", "")
+
+	_, data := env.call(t, client, http.MethodPost, "/api/v1/plumbuses", bearer, jsonType,
+		`{"name":"Contract","size":"M","color":"pink","shape":"smooth","weight":"light","wrapping":"default"}`)
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil || created.ID == "" {
+		t.Fatalf("Invalid create response %s: %v", data, err)
+	}
+
+	env.callInvalid(t, client, http.MethodPost, "/api/v1/plumbuses", bearer, jsonType, `{"name":"incomplete"}`)
+	env.call(t, client, http.MethodGet, "/api/v1/plumbuses", bearer, "", "")
+	env.call(t, client, http.MethodGet, "/api/v1/plumbuses/"+created.ID, bearer, "", "")
+	env.call(t, client, http.MethodGet, "/api/v1/plumbuses/"+uuid.NewString(), bearer, "", "")
+	env.call(t, client, http.MethodGet, "/api/v1/plumbuses/"+created.ID+"/image", bearer, "", "")
+
+	// Браузерная сессия
+	env.call(t, browser, http.MethodGet, "/plumbus/list", "", "", "")
+	env.call(t, browser, http.MethodGet, "/plumbus/status/"+created.ID, "", "", "")
+	env.call(t, newBrowser(t), http.MethodGet, "/plumbus/list", "", "", "")
+	env.call(t, browser, http.MethodPost, "/plumbus/"+created.ID+"/retry", "", "", "")
+	env.call(t, browser, http.MethodPost, "/plumbus/"+created.ID+"/cancel", "", "", "")
+	env.call(t, browser, http.MethodGet, "/plumbus/"+created.ID+"/attempts", "", "", "")
+	env.call(t, browser, http.MethodDelete, "/plumbus/"+created.ID+"?hard=true", "", "", "")
+	env.call(t, browser, http.MethodDelete, "/plumbus/"+created.ID, "", "", "")
+	env.call(t, browser, http.MethodDelete, "/plumbus/"+created.ID, "", "", "")
+	env.call(t, browser, http.MethodPost, "/plumbus/"+created.ID+"/restore", "", "", "")
+	env.call(t, browser, http.MethodPost, "/plumbus/"+created.ID+"/restore", "", "", "")
+	env.call(t, browser, http.MethodPost, "/plumbus/not-a-uuid/restore", "", "", "")
+
+	_, data = env.call(t, browser, http.MethodPost, "/tokens", "", jsonType,
+		`{"name":"ci","scopes":["`+services.ScopePlumbusRead+`"],"expires_in_days":30}`)
+	var token struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.ID == "" {
+		t.Fatalf("Invalid create token response %s: %v", data, err)
+	}
+	env.call(t, browser, http.MethodGet, "/tokens", "", "", "")
+	env.call(t, browser, http.MethodDelete, "/tokens/"+token.ID, "", "", "")
+	env.call(t, browser, http.MethodDelete, "/tokens/"+token.ID, "", "", "")
+
+	_, data = env.call(t, browser, http.MethodPost, "/webhooks", "", jsonType,
+		`{"url":"https://partner.example/hooks","events":["`+services.EventPlumbusCompleted+`"]}`)
+	var webhook struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &webhook); err != nil || webhook.ID == "" {
+		t.Fatalf("Invalid create webhook response %s: %v", data, err)
+	}
+	env.callInvalid(t, browser, http.MethodPost, "/webhooks", "", jsonType, `{"url":"ftp://partner.example","events":["plumbus.completed"]}`)
+	env.call(t, browser, http.MethodGet, "/webhooks", "", "", "")
+	env.call(t, browser, http.MethodGet, "/webhooks/"+webhook.ID+"/deliveries", "", "", "")
+	env.call(t, browser, http.MethodPost, "/webhooks/"+webhook.ID+"/enable", "", "", "")
+	env.call(t, browser, http.MethodDelete, "/webhooks/"+webhook.ID, "", "", "")
+	env.call(t, browser, http.MethodDelete, "/webhooks/"+webhook.ID, "", "", "")
+
+	// Лимит частоты генераций
+	env.handler.generationService.RateLimiter = services.NewRateLimiter(1)
+	for i := 0; i < 2; i++ {
+		env.call(t, client, http.MethodPost, "/api/v1/plumbuses", bearer, jsonType,
+			`{"name":"Limited","size":"M","color":"pink","shape":"smooth","weight":"light","wrapping":"default"}`)
+	}
+
+	// Перезагрузка настроек
+	env.call(t, browser, http.MethodGet, "/admin/config/reloads", "", "", "")
+	env.provider.User.Roles = []string{keycloak.RoleAdmin}
+	admin := env.login(t)
+	env.call(t, admin, http.MethodGet, "/admin/config/reloads", "", "", "")
+	env.call(t, browser, http.MethodGet, "/admin", "", "", "")
+	env.call(t, admin, http.MethodGet, "/admin?user=not-a-uuid", "", "", "")
+	env.handler.ConfigReloader = config.NewReloader("", config.Default())
+	env.call(t, admin, http.MethodPost, "/admin/config/reload", "", "", "")
+	env.call(t, admin, http.MethodGet, "/admin/config/reloads", "", "", "")
+
+	// Авторизация
+	env.call(t, newBrowser(t), http.MethodGet, "/auth/login?return_to=/plumbus/list", "", "", "")
+	env.call(t, newBrowser(t), http.MethodGet, "/auth/callback?state=forged&code=x", "", "", "")
+	env.callInvalid(t, client, http.MethodPost, "/auth/backchannel-logout", "", "application/x-www-form-urlencoded", "")
+	env.call(t, browser, http.MethodGet, "/auth/logout", "", "", "")
+}
+
+// TestGeneratedClient проверяет сгенерированный клиент против настоящих обработчиков
+func TestGeneratedClient(t *testing.T) {
+	env := setupAuthTest(t)
+
+	client, err := factoryclient.NewClientWithResponses(env.server.URL,
+		factoryclient.WithBearerToken(env.provider.AccessToken(t)))
+	if err != nil {
+		t.Fatalf("NewClientWithResponses() error = %v", err)
+	}
+	ctx := context.Background()
+
+	me, err := client.GetMeWithResponse(ctx)
+	if err != nil || me.JSON200 == nil {
+		t.Fatalf("GetMe() = %v, %v, want 200", me.Status(), err)
+	}
+	if me.JSON200.Username != env.provider.User.Username {
+		t.Errorf("Username = %q, want %q", me.JSON200.Username, env.provider.User.Username)
+	}
+
+	created, err := client.CreatePlumbusWithResponse(ctx, factoryclient.PlumbusRequest{
+		Name: "Client", Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+	})
+	if err != nil || created.JSON200 == nil {
+		t.Fatalf("CreatePlumbus() = %v, %v, want 200", created.Status(), err)
+	}
+
+	status, err := client.GetPlumbusWithResponse(ctx, created.JSON200.Id)
+	if err != nil || status.JSON200 == nil {
+		t.Fatalf("GetPlumbus() = %v, %v, want 200", status.Status(), err)
+	}
+	if status.JSON200.Name != "Client" {
+		t.Errorf("Name = %q, want Client", status.JSON200.Name)
+	}
+
+	list, err := client.ListPlumbusesWithResponse(ctx)
+	if err != nil || list.JSON200 == nil || len(*list.JSON200) != 1 {
+		t.Fatalf("ListPlumbuses() = %v, %v, want one plumbus", list.Status(), err)
+	}
+
+	unauthorized, err := factoryclient.NewClientWithResponses(env.server.URL)
+	if err != nil {
+		t.Fatalf("NewClientWithResponses() error = %v", err)
+	}
+	resp, err := unauthorized.GetMeWithResponse(ctx)
+	if err != nil || resp.JSON401 == nil {
+		t.Errorf("GetMe() without token = %v, %v, want 401", resp.Status(), err)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"regexp"
+
+	"factory/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader - заголовок с ID запроса во входящих запросах и ответах
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID ограничивает принимаемые ID, чтобы в логи не попадал произвольный текст
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID принимает ID запроса из X-Request-ID или создает новый, возвращает его
+// в ответе и сохраняет в контексте запроса для логов и сервисов
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+// setUserID сохраняет пользователя запроса в контексте gin и в контексте запроса для логов
+func setUserID(c *gin.Context, userID uuid.UUID) {
+	c.Set(userIDKey, userID)
+	c.Request = c.Request.WithContext(logger.WithUserID(c.Request.Context(), userID.String()))
+}
+
+// setPlumbusID сохраняет плюмбус, с которым работает запрос, в контексте запроса для логов
+func setPlumbusID(c *gin.Context, plumbusID uuid.UUID) {
+	c.Request = c.Request.WithContext(logger.WithPlumbusID(c.Request.Context(), plumbusID.String()))
+}
+
+// errorBody возвращает тело ответа с ошибкой и ID запроса для поиска в логах
+func errorBody(c *gin.Context, message string) gin.H {
+	return gin.H{"error": message, "request_id": logger.RequestID(c.Request.Context())}
+}
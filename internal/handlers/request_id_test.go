@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func getWithRequestID(t *testing.T, client *http.Client, rawURL, requestID string) (*http.Response, map[string]string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, rawURL, nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]string
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp, body
+}
+
+func TestRequestID_AcceptedOrGenerated(t *testing.T) {
+	env := setupAuthTest(t)
+
+	resp, _ := getWithRequestID(t, http.DefaultClient, env.server.URL+"/healthz", "trace-42.a:b")
+	if got := resp.Header.Get(RequestIDHeader); got != "trace-42.a:b" {
+		t.Errorf("%s = %q, want the incoming ID", RequestIDHeader, got)
+	}
+
+	resp, _ = getWithRequestID(t, http.DefaultClient, env.server.URL+"/healthz", "")
+	if _, err := uuid.Parse(resp.Header.Get(RequestIDHeader)); err != nil {
+		t.Errorf("generated %s = %q, want UUID", RequestIDHeader, resp.Header.Get(RequestIDHeader))
+	}
+
+	// Произвольный текст из заголовка не попадает в логи и ответы
+	resp, _ = getWithRequestID(t, http.DefaultClient, env.server.URL+"/healthz", "bad id\" injected")
+	if got := resp.Header.Get(RequestIDHeader); got == "bad id\" injected" {
+		t.Errorf("%s = %q, want invalid ID replaced", RequestIDHeader, got)
+	} else if _, err := uuid.Parse(got); err != nil {
+		t.Errorf("replaced %s = %q, want UUID", RequestIDHeader, got)
+	}
+}
+
+func TestRequestID_InErrorBody(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	resp, body := getWithRequestID(t, browser, env.server.URL+"/plumbus/status/not-a-uuid", "req-123")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if body["error"] != "Invalid ID" || body["request_id"] != "req-123" {
+		t.Errorf("error body = %v, want error with request_id req-123", body)
+	}
+
+	resp, body = getWithRequestID(t, browser, env.server.URL+"/plumbus/status/"+uuid.NewString(), "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if body["request_id"] == "" || body["request_id"] != resp.Header.Get(RequestIDHeader) {
+		t.Errorf("error body request_id = %q, want %q", body["request_id"], resp.Header.Get(RequestIDHeader))
+	}
+}
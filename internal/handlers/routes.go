@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"factory/internal/keycloak"
+	"factory/internal/metrics"
+	"factory/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes регистрирует все маршруты фабрики
+func (h *Handler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/health", func(c *gin.Context) {
+		c.String(200, "OK")
+	})
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.Readiness)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/", h.HomePage)
+	router.GET("/auth/login", h.Login)
+	router.GET("/auth/callback", h.AuthCallback)
+	router.GET("/auth/logout", h.Logout)
+	router.POST("/auth/backchannel-logout", h.BackchannelLogout)
+
+	// Спецификация и документация API
+	router.GET("/api/openapi.json", h.OpenAPISpec)
+	router.GET("/api/docs", h.APIDocs)
+
+	// Защищенные маршруты
+	protected := router.Group("/")
+	protected.Use(h.AuthMiddleware(), h.RequireRole(keycloak.RoleViewer))
+	{
+		protected.GET("/dashboard", h.Dashboard)
+		protected.POST("/plumbus/generate", h.RequireRole(keycloak.RoleOperator), h.GeneratePlumbus)
+		protected.GET("/plumbus/status/:id", h.GetPlumbusStatus)
+		protected.GET("/plumbus/image/:id", h.GetPlumbusImage)
+		protected.GET("/plumbus/list", h.GetUserPlumbuses)
+		protected.DELETE("/plumbus/:id", h.RequireRole(keycloak.RoleOperator), h.DeletePlumbus)
+		protected.POST("/plumbus/:id/restore", h.RequireRole(keycloak.RoleOperator), h.RestorePlumbus)
+		protected.POST("/plumbus/:id/retry", h.RequireRole(keycloak.RoleOperator), h.RetryPlumbus)
+		protected.POST("/plumbus/:id/cancel", h.RequireRole(keycloak.RoleOperator), h.CancelPlumbus)
+		protected.GET("/plumbus/:id/attempts", h.ListPlumbusAttempts)
+
+		// Управление персональными токенами доступна только из браузерной сессии
+		protected.GET("/tokens", h.ListTokens)
+		protected.POST("/tokens", h.CreateToken)
+		protected.DELETE("/tokens/:id", h.RevokeToken)
+
+		// Webhook о завершении генерации
+		protected.GET("/webhooks", h.ListWebhooks)
+		protected.POST("/webhooks", h.CreateWebhook)
+		protected.DELETE("/webhooks/:id", h.DeleteWebhook)
+		protected.POST("/webhooks/:id/enable", h.EnableWebhook)
+		protected.GET("/webhooks/:id/deliveries", h.ListWebhookDeliveries)
+
+		// Панель администратора
+		protected.GET("/admin", h.RequireRole(keycloak.RoleAdmin), h.AdminDashboard)
+
+		// Перезагрузка настроек без перезапуска
+		protected.GET("/admin/config/reloads", h.RequireRole(keycloak.RoleAdmin), h.ListConfigReloads)
+		protected.POST("/admin/config/reload", h.RequireRole(keycloak.RoleAdmin), h.ReloadConfig)
+	}
+
+	// Версионированный JSON API с аутентификацией по bearer токену
+	api := router.Group("/api/v1")
+	api.Use(h.APIAuthMiddleware())
+	{
+		api.GET("/me", h.APIMe)
+		api.GET("/plumbuses", h.RequireScope(services.ScopePlumbusRead), h.GetUserPlumbuses)
+		api.POST("/plumbuses", h.RequireScope(services.ScopePlumbusWrite), h.GeneratePlumbus)
+		api.GET("/plumbuses/:id", h.RequireScope(services.ScopePlumbusRead), h.GetPlumbusStatus)
+		api.GET("/plumbuses/:id/image", h.RequireScope(services.ScopePlumbusRead), h.GetPlumbusImage)
+	}
+}
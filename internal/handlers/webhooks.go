@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"factory/internal/models"
+	"factory/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// webhookDeliveriesLimit - сколько последних попыток доставки возвращается в журнале
+const webhookDeliveriesLimit = 50
+
+// createWebhookRequest - запрос на регистрацию webhook
+type createWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+}
+
+// webhookResponse - webhook в ответах API (события списком, без секрета)
+func webhookResponse(webhook *models.Webhook) gin.H {
+	return gin.H{
+		"id":            webhook.ID,
+		"url":           webhook.URL,
+		"events":        services.WebhookEvents(webhook),
+		"enabled":       webhook.Enabled,
+		"failure_count": webhook.FailureCount,
+		"disabled_at":   webhook.DisabledAt,
+		"created_at":    webhook.CreatedAt,
+	}
+}
+
+// ListWebhooks возвращает webhook текущего пользователя
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	userID, _ := currentUserID(c)
+
+	webhooks, err := h.webhookService.ListWebhooks(userID)
+	if err != nil {
+		h.log(c).WithError(err).WithField("user_id", userID).Error("Failed to list webhooks")
+		c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+		return
+	}
+
+	resp := make([]gin.H, len(webhooks))
+	for i := range webhooks {
+		resp[i] = webhookResponse(&webhooks[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateWebhook регистрирует webhook. Секрет подписи показывается только в ответе на создание.
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req createWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, err.Error()))
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	secret, webhook, err := h.webhookService.CreateWebhook(userID, req.URL, req.Events)
+	if err != nil {
+		h.log(c).WithError(err).WithField("user_id", userID).Warn("Failed to create webhook")
+		c.JSON(http.StatusBadRequest, errorBody(c, err.Error()))
+		return
+	}
+
+	h.log(c).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"webhook_id": webhook.ID,
+		"events":     webhook.Events,
+	}).Info("Webhook created")
+
+	resp := webhookResponse(webhook)
+	resp["secret"] = secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+// DeleteWebhook удаляет webhook текущего пользователя
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, "Invalid ID"))
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	if err := h.webhookService.DeleteWebhook(userID, webhookID); err != nil {
+		h.webhookError(c, err, webhookID, "Failed to delete webhook")
+		return
+	}
+
+	h.log(c).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"webhook_id": webhookID,
+	}).Info("Webhook deleted")
+
+	c.Status(http.StatusNoContent)
+}
+
+// EnableWebhook снова включает webhook, отключенный после ошибок доставки
+func (h *Handler) EnableWebhook(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, "Invalid ID"))
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	if err := h.webhookService.EnableWebhook(userID, webhookID); err != nil {
+		h.webhookError(c, err, webhookID, "Failed to enable webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries возвращает журнал последних попыток доставки webhook
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(c, "Invalid ID"))
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	deliveries, err := h.webhookService.ListDeliveries(userID, webhookID, webhookDeliveriesLimit)
+	if err != nil {
+		h.webhookError(c, err, webhookID, "Failed to list webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// webhookError отвечает 404 для чужого или удаленного webhook и 500 для остальных ошибок
+func (h *Handler) webhookError(c *gin.Context, err error, webhookID uuid.UUID, message string) {
+	if errors.Is(err, services.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, errorBody(c, "Webhook not found"))
+		return
+	}
+	h.log(c).WithError(err).WithField("webhook_id", webhookID).Error(message)
+	c.JSON(http.StatusInternalServerError, errorBody(c, "Database error"))
+}
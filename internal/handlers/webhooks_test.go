@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"factory/internal/services"
+)
+
+// createWebhook регистрирует webhook из браузерной сессии
+func (e *authTestEnv) createWebhook(t *testing.T, browser *http.Client, url string, events ...string) (string, string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"url": url, "events": events})
+
+	resp, data := do(t, browser, http.MethodPost, e.server.URL+"/webhooks", "", string(body))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Create webhook status = %d, want %d: %s", resp.StatusCode, http.StatusCreated, data)
+	}
+
+	var created struct {
+		ID     string `json:"id"`
+		Secret string `json:"secret"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		t.Fatalf("Invalid create webhook response: %v", err)
+	}
+	return created.ID, created.Secret
+}
+
+func TestWebhooks_DeliveredWhenGenerationFinishes(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan delivery, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{header: r.Header, body: body}
+	}))
+	t.Cleanup(receiver.Close)
+
+	// Сервис генерации в тестах недоступен, поэтому генерация завершается ошибкой
+	id, secret := env.createWebhook(t, browser, receiver.URL, services.EventPlumbusFailed)
+
+	resp, data := do(t, browser, http.MethodPost, env.server.URL+"/plumbus/generate", "",
+		`{"name":"Hooked","size":"M","color":"pink","shape":"smooth","weight":"light","wrapping":"default"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Generate status = %d: %s", resp.StatusCode, data)
+	}
+
+	var got delivery
+	select {
+	case got = <-received:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Webhook was not delivered")
+	}
+
+	if sig := got.header.Get(services.WebhookSignatureHeader); sig != services.SignWebhookPayload(secret, got.body) {
+		t.Errorf("signature = %q does not match the payload", sig)
+	}
+	var payload services.WebhookPayload
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("Invalid payload: %v", err)
+	}
+	if payload.Event != services.EventPlumbusFailed || payload.Plumbus.Name != "Hooked" || payload.Plumbus.Error == nil {
+		t.Errorf("payload = %+v, want failed event with error", payload)
+	}
+
+	// Попытка доставки попадает в журнал
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, data = do(t, browser, http.MethodGet, env.server.URL+"/webhooks/"+id+"/deliveries", "", "")
+		var deliveries []map[string]interface{}
+		json.Unmarshal(data, &deliveries)
+		if resp.StatusCode == http.StatusOK && len(deliveries) == 1 {
+			if deliveries[0]["success"] != true {
+				t.Errorf("delivery = %v, want success", deliveries[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Delivery log = %d %s, want one delivery", resp.StatusCode, data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhooks_Management(t *testing.T) {
+	env := setupAuthTest(t)
+	browser := env.login(t)
+
+	resp, _ := do(t, browser, http.MethodPost, env.server.URL+"/webhooks", "",
+		`{"url":"javascript:alert(1)","events":["plumbus.completed"]}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Create webhook with invalid URL status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	id, _ := env.createWebhook(t, browser, "https://partner.example/hooks", services.EventPlumbusCompleted)
+
+	resp, data := do(t, browser, http.MethodGet, env.server.URL+"/webhooks", "", "")
+	var webhooks []map[string]interface{}
+	if err := json.Unmarshal(data, &webhooks); err != nil || resp.StatusCode != http.StatusOK || len(webhooks) != 1 {
+		t.Fatalf("List webhooks = %d %s, want one webhook", resp.StatusCode, data)
+	}
+	if _, ok := webhooks[0]["secret"]; ok {
+		t.Error("webhook secret is exposed in the list")
+	}
+
+	// Другой пользователь не видит и не может удалить webhook
+	env.provider.User.Subject = "kc-user-2"
+	env.provider.User.Username = "morty"
+	stranger := env.login(t)
+	resp, _ = do(t, stranger, http.MethodDelete, env.server.URL+"/webhooks/"+id, "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Delete foreign webhook status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	resp, _ = do(t, browser, http.MethodPost, env.server.URL+"/webhooks/"+id+"/enable", "", "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Enable status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	resp, _ = do(t, browser, http.MethodDelete, env.server.URL+"/webhooks/"+id, "", "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Delete status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	resp, _ = do(t, browser, http.MethodGet, env.server.URL+"/webhooks/"+id+"/deliveries", "", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Deliveries of deleted webhook status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// Webhook недоступны по bearer токену
+	resp, _ = do(t, newBrowser(t), http.MethodGet, env.server.URL+"/webhooks", env.provider.AccessToken(t), "")
+	if resp.StatusCode == http.StatusOK {
+		t.Error("Webhook management is accessible with a bearer token")
+	}
+}
@@ -0,0 +1,121 @@
+package keycloak
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Роли фабрики, назначаемые в Keycloak (realm или client роли)
+const (
+	RoleAdmin    = "factory-admin"
+	RoleOperator = "factory-operator"
+	RoleViewer   = "factory-viewer"
+)
+
+// impliedRoles описывает иерархию ролей: старшая роль включает младшие
+var impliedRoles = map[string][]string{
+	RoleAdmin:    {RoleOperator, RoleViewer},
+	RoleOperator: {RoleViewer},
+}
+
+// Claims содержит данные пользователя и роли из access токена
+type Claims struct {
+	Subject           string   `json:"sub"`
+	PreferredUsername string   `json:"preferred_username"`
+	Email             string   `json:"email"`
+	SessionID         string   `json:"sid"`
+	RealmRoles        []string `json:"realm_roles"`
+	ClientRoles       []string `json:"client_roles"`
+}
+
+// accessTokenPayload - часть полезной нагрузки access токена Keycloak
+type accessTokenPayload struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+	SessionID         string `json:"sid"`
+	RealmAccess       struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+	ResourceAccess map[string]struct {
+		Roles []string `json:"roles"`
+	} `json:"resource_access"`
+}
+
+// ParseClaims извлекает данные пользователя и роли из access токена.
+// Подпись токена не проверяется: вызывающий код должен сначала проверить токен.
+func ParseClaims(token, clientID string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token: expected 3 parts, got %d", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token payload: %w", err)
+	}
+
+	var raw accessTokenPayload
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse token payload: %w", err)
+	}
+
+	return raw.claims(clientID), nil
+}
+
+func (p *accessTokenPayload) claims(clientID string) *Claims {
+	claims := &Claims{
+		Subject:           p.Subject,
+		PreferredUsername: p.PreferredUsername,
+		Email:             p.Email,
+		SessionID:         p.SessionID,
+		RealmRoles:        p.RealmAccess.Roles,
+	}
+	if access, ok := p.ResourceAccess[clientID]; ok {
+		claims.ClientRoles = access.Roles
+	}
+	return claims
+}
+
+// Roles возвращает объединение realm и client ролей без повторов
+func (c *Claims) Roles() []string {
+	seen := make(map[string]bool)
+	roles := make([]string, 0, len(c.RealmRoles)+len(c.ClientRoles))
+	for _, role := range append(append([]string{}, c.RealmRoles...), c.ClientRoles...) {
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// HasRole проверяет наличие роли с учетом иерархии (admin > operator > viewer)
+func (c *Claims) HasRole(role string) bool {
+	if c == nil {
+		return false
+	}
+	for _, granted := range c.Roles() {
+		if granted == role {
+			return true
+		}
+		for _, implied := range impliedRoles[granted] {
+			if implied == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasAnyRole проверяет наличие хотя бы одной из ролей
+func (c *Claims) HasAnyRole(roles ...string) bool {
+	for _, role := range roles {
+		if c.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
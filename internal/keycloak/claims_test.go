@@ -0,0 +1,109 @@
+package keycloak
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+// makeUnsignedToken собирает JWT с заданной полезной нагрузкой и фиктивной подписью
+func makeUnsignedToken(t *testing.T, payload map[string]interface{}) string {
+	t.Helper()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Failed to marshal payload: %v", err)
+	}
+	return header + "." + base64.RawURLEncoding.EncodeToString(body) + ".signature"
+}
+
+func TestParseClaims_RealmAndClientRoles(t *testing.T) {
+	token := makeUnsignedToken(t, map[string]interface{}{
+		"sub":                "user-1",
+		"preferred_username": "rick",
+		"email":              "rick@citadel.test",
+		"realm_access": map[string]interface{}{
+			"roles": []string{"offline_access", RoleViewer},
+		},
+		"resource_access": map[string]interface{}{
+			"factory": map[string]interface{}{"roles": []string{RoleOperator}},
+			"other":   map[string]interface{}{"roles": []string{RoleAdmin}},
+		},
+	})
+
+	claims, err := ParseClaims(token, "factory")
+	if err != nil {
+		t.Fatalf("ParseClaims() error = %v, want nil", err)
+	}
+
+	if claims.Subject != "user-1" || claims.PreferredUsername != "rick" || claims.Email != "rick@citadel.test" {
+		t.Errorf("ParseClaims() user fields = %+v", claims)
+	}
+
+	if !claims.HasRole(RoleOperator) {
+		t.Error("HasRole(operator) = false, want true (client role)")
+	}
+
+	if !claims.HasRole(RoleViewer) {
+		t.Error("HasRole(viewer) = false, want true (realm role)")
+	}
+
+	// Роль другого клиента не должна учитываться
+	if claims.HasRole(RoleAdmin) {
+		t.Error("HasRole(admin) = true, want false (role belongs to another client)")
+	}
+}
+
+func TestParseClaims_MalformedToken(t *testing.T) {
+	if _, err := ParseClaims("not-a-jwt", "factory"); err == nil {
+		t.Error("ParseClaims() error = nil, want error for malformed token")
+	}
+
+	if _, err := ParseClaims("a.!!!.c", "factory"); err == nil {
+		t.Error("ParseClaims() error = nil, want error for invalid base64")
+	}
+}
+
+func TestClaims_HasRole_Hierarchy(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		role    string
+		want    bool
+	}{
+		{"admin implies operator", []string{RoleAdmin}, RoleOperator, true},
+		{"admin implies viewer", []string{RoleAdmin}, RoleViewer, true},
+		{"operator implies viewer", []string{RoleOperator}, RoleViewer, true},
+		{"operator is not admin", []string{RoleOperator}, RoleAdmin, false},
+		{"viewer is not operator", []string{RoleViewer}, RoleOperator, false},
+		{"no roles", nil, RoleViewer, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{RealmRoles: tt.granted}
+			if got := claims.HasRole(tt.role); got != tt.want {
+				t.Errorf("HasRole(%s) = %v, want %v", tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaims_Roles_Deduplicated(t *testing.T) {
+	claims := &Claims{
+		RealmRoles:  []string{RoleViewer, RoleOperator},
+		ClientRoles: []string{RoleOperator},
+	}
+
+	roles := claims.Roles()
+	if len(roles) != 2 {
+		t.Errorf("Roles() = %v, want 2 unique roles", roles)
+	}
+}
+
+func TestClaims_HasRole_NilClaims(t *testing.T) {
+	var claims *Claims
+	if claims.HasRole(RoleViewer) {
+		t.Error("HasRole() on nil claims = true, want false")
+	}
+}
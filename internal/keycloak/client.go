@@ -12,33 +12,115 @@ import (
 	"github.com/Nerzal/gocloak/v13"
 )
 
+// Режимы проверки access токенов
+const (
+	// VerifyModeJWKS - локальная проверка подписи по ключам realm (по умолчанию)
+	VerifyModeJWKS = "jwks"
+	// VerifyModeUserInfo - проверка запросом к userinfo endpoint Keycloak
+	VerifyModeUserInfo = "userinfo"
+)
+
 type Client struct {
 	gocloak      *gocloak.GoCloak
 	config       *config.Config
 	clientID     string
-	clientSecret string
+	clientSecret *config.Secret
 	realm        string
+	verifyMode   string
+	verifier     *TokenVerifier
+	// apiClients - клиенты Keycloak, чьи токены дополнительно принимает API
+	apiClients []string
 }
 
 func NewClient(cfg *config.Config) *Client {
+	issuer := cfg.KeycloakIssuer
+	if issuer == "" {
+		// Токены выпускаются для браузера, поэтому issuer строится по внешнему URL
+		issuer = fmt.Sprintf("%s/realms/%s", cfg.KeycloakURL, cfg.KeycloakRealm)
+	}
+
+	audience := cfg.KeycloakAudience
+	if audience == "" {
+		audience = cfg.KeycloakClientID
+	}
+
+	verifyMode := cfg.KeycloakVerifyMode
+	if verifyMode == "" {
+		verifyMode = VerifyModeJWKS
+	}
+
+	jwksURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/certs", cfg.KeycloakInternalURL, cfg.KeycloakRealm)
+
 	return &Client{
 		gocloak:      gocloak.NewClient(cfg.KeycloakInternalURL),
 		config:       cfg,
 		clientID:     cfg.KeycloakClientID,
 		clientSecret: cfg.KeycloakClientSecret,
 		realm:        cfg.KeycloakRealm,
+		verifyMode:   verifyMode,
+		verifier:     NewTokenVerifier(NewJWKSCache(jwksURL, nil), issuer, audience, cfg.KeycloakClientID),
+		apiClients:   splitList(cfg.KeycloakAPIClients),
+	}
+}
+
+// splitList разбирает список значений, разделенных запятыми
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
-func (c *Client) VerifyToken(ctx context.Context, token string) (*gocloak.UserInfo, error) {
+// VerifyToken проверяет access токен и возвращает данные пользователя с ролями.
+// В режиме jwks токен проверяется локально, в режиме userinfo - запросом к Keycloak.
+func (c *Client) VerifyToken(ctx context.Context, token string) (*Claims, error) {
+	if c.verifyMode == VerifyModeUserInfo {
+		return c.verifyWithUserInfo(ctx, token)
+	}
+	return c.verifier.Verify(ctx, token)
+}
+
+// VerifyBearerToken проверяет access токен, предъявленный API в заголовке Authorization.
+// В отличие от VerifyToken принимает также токены клиентов из KEYCLOAK_API_CLIENTS.
+func (c *Client) VerifyBearerToken(ctx context.Context, token string) (*Claims, error) {
+	if c.verifyMode == VerifyModeUserInfo {
+		return c.verifyWithUserInfo(ctx, token)
+	}
+	return c.verifier.VerifyBearer(ctx, token, c.apiClients)
+}
+
+func (c *Client) verifyWithUserInfo(ctx context.Context, token string) (*Claims, error) {
 	userInfo, err := c.gocloak.GetUserInfo(ctx, token, c.realm)
 	if err != nil {
 		return nil, err
 	}
-	return userInfo, nil
+
+	// Роли доступны только в самом токене, userinfo их не возвращает
+	claims, err := ParseClaims(token, c.clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims.Subject = gocloak.PString(userInfo.Sub)
+	claims.PreferredUsername = gocloak.PString(userInfo.PreferredUsername)
+	claims.Email = gocloak.PString(userInfo.Email)
+	return claims, nil
 }
 
-func (c *Client) GetLoginURL(redirectURI string) string {
+// AuthRequest - параметры защиты OIDC входа
+type AuthRequest struct {
+	// State связывает callback с браузером, начавшим вход (защита от CSRF)
+	State string
+	// Nonce связывает ID токен с запросом авторизации (защита от подмены кода)
+	Nonce string
+	// CodeChallenge - S256 хэш PKCE code verifier
+	CodeChallenge string
+}
+
+func (c *Client) GetLoginURL(redirectURI string, authReq AuthRequest) string {
 	// Строим URL авторизации для браузера (используем внешний URL)
 	baseURL := c.config.KeycloakURL
 	authURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/auth", baseURL, c.realm)
@@ -48,20 +130,25 @@ func (c *Client) GetLoginURL(redirectURI string) string {
 	params.Add("redirect_uri", redirectURI)
 	params.Add("response_type", "code")
 	params.Add("scope", "openid profile email")
+	params.Add("state", authReq.State)
+	params.Add("nonce", authReq.Nonce)
+	params.Add("code_challenge", authReq.CodeChallenge)
+	params.Add("code_challenge_method", "S256")
 
 	return fmt.Sprintf("%s?%s", authURL, params.Encode())
 }
 
-func (c *Client) ExchangeCodeForToken(ctx context.Context, code, redirectURI string) (*gocloak.JWT, error) {
+func (c *Client) ExchangeCodeForToken(ctx context.Context, code, redirectURI, codeVerifier string) (*gocloak.JWT, error) {
 	// Выполняем HTTP запрос для обмена кода на токен (используем внутренний URL)
 	tokenURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", c.config.KeycloakInternalURL, c.realm)
 
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
 	data.Set("client_id", c.clientID)
-	data.Set("client_secret", c.clientSecret)
+	data.Set("client_secret", c.clientSecret.Value())
 	data.Set("code", code)
 	data.Set("redirect_uri", redirectURI)
+	data.Set("code_verifier", codeVerifier)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
@@ -88,3 +175,51 @@ func (c *Client) ExchangeCodeForToken(ctx context.Context, code, redirectURI str
 
 	return &tokenResp, nil
 }
+
+// RefreshToken обменивает refresh токен на новую пару токенов
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*gocloak.JWT, error) {
+	return c.gocloak.RefreshToken(ctx, refreshToken, c.clientID, c.clientSecret.Value(), c.realm)
+}
+
+// VerifyIDToken проверяет подпись ID токена, его адресата и совпадение nonce
+func (c *Client) VerifyIDToken(ctx context.Context, idToken, nonce string) error {
+	return c.verifier.VerifyIDToken(ctx, idToken, nonce)
+}
+
+// VerifyLogoutToken проверяет logout токен, полученный через back-channel logout
+func (c *Client) VerifyLogoutToken(ctx context.Context, token string) (*LogoutClaims, error) {
+	return c.verifier.VerifyLogoutToken(ctx, token)
+}
+
+// Ping проверяет доступность realm по его OpenID конфигурации
+func (c *Client) Ping(ctx context.Context) error {
+	discoveryURL := fmt.Sprintf("%s/realms/%s/.well-known/openid-configuration", c.config.KeycloakInternalURL, c.realm)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach keycloak: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("keycloak returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetLogoutURL строит URL RP-initiated logout для завершения SSO сессии в Keycloak
+func (c *Client) GetLogoutURL(idTokenHint, postLogoutRedirectURI string) string {
+	logoutURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/logout", c.config.KeycloakURL, c.realm)
+
+	params := url.Values{}
+	params.Add("client_id", c.clientID)
+	params.Add("post_logout_redirect_uri", postLogoutRedirectURI)
+	if idTokenHint != "" {
+		params.Add("id_token_hint", idTokenHint)
+	}
+
+	return fmt.Sprintf("%s?%s", logoutURL, params.Encode())
+}
@@ -0,0 +1,223 @@
+package keycloak
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"factory/internal/logger"
+)
+
+const (
+	// jwksCacheTTL - как долго ключи считаются актуальными без повторной загрузки
+	jwksCacheTTL = 10 * time.Minute
+	// jwksMinRefreshInterval ограничивает частоту загрузки при неизвестном kid и
+	// при недоступном Keycloak: учитываются и неудачные попытки
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+// ErrKeyNotFound возвращается, если ключ с указанным kid отсутствует в JWKS
+var ErrKeyNotFound = errors.New("signing key not found in JWKS")
+
+// jsonWebKey - ключ из набора JWKS (поддерживаются только RSA ключи подписи)
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKSCache загружает и кэширует публичные ключи realm.
+// При появлении неизвестного kid (ротация ключей) набор загружается заново.
+// Устаревший набор обновляется в фоне, а запросы продолжают проверяться известными ключами.
+type JWKSCache struct {
+	url    string
+	client *http.Client
+
+	mu   sync.RWMutex
+	keys map[string]*rsa.PublicKey
+	// fetchedAt - время последней успешной загрузки, attemptedAt - последней попытки
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	lastErr     error
+	// refreshing - идет фоновое обновление
+	refreshing bool
+
+	// fetchMu допускает только одну загрузку набора за раз
+	fetchMu sync.Mutex
+}
+
+// NewJWKSCache создает кэш ключей для указанного JWKS endpoint
+func NewJWKSCache(url string, client *http.Client) *JWKSCache {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &JWKSCache{
+		url:    url,
+		client: client,
+		keys:   make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Key возвращает публичный ключ по kid. Известный ключ возвращается сразу, даже если
+// набор устарел: обновление идет в фоне. Неизвестный kid загружает набор не чаще
+// jwksMinRefreshInterval.
+func (j *JWKSCache) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	stale := time.Since(j.fetchedAt) >= jwksCacheTTL
+	attempted := j.attemptedAt
+	j.mu.RUnlock()
+
+	recent := !attempted.IsZero() && time.Since(attempted) < jwksMinRefreshInterval
+	if ok {
+		if stale && !recent {
+			j.refreshInBackground()
+		}
+		return key, nil
+	}
+
+	// Не перезагружаем набор слишком часто из-за токенов с неизвестным kid
+	if recent {
+		return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+	if err := j.refreshAfter(ctx, attempted); err != nil {
+		return nil, err
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+// Refresh загружает актуальный набор ключей
+func (j *JWKSCache) Refresh(ctx context.Context) error {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+	return j.fetch(ctx)
+}
+
+// refreshAfter загружает набор, если после попытки seen его никто не загружал. Запросы,
+// ждавшие параллельную загрузку, получают ее результат, а не загружают набор снова.
+func (j *JWKSCache) refreshAfter(ctx context.Context, seen time.Time) error {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+
+	j.mu.RLock()
+	attempted, err := j.attemptedAt, j.lastErr
+	j.mu.RUnlock()
+	if attempted.After(seen) {
+		return err
+	}
+	return j.fetch(ctx)
+}
+
+// refreshInBackground обновляет устаревший набор, не задерживая текущий запрос
+func (j *JWKSCache) refreshInBackground() {
+	j.mu.Lock()
+	if j.refreshing {
+		j.mu.Unlock()
+		return
+	}
+	j.refreshing = true
+	seen := j.attemptedAt
+	j.mu.Unlock()
+
+	go func() {
+		defer func() {
+			j.mu.Lock()
+			j.refreshing = false
+			j.mu.Unlock()
+		}()
+		if err := j.refreshAfter(context.Background(), seen); err != nil {
+			logger.For("keycloak").WithError(err).Warn("Failed to refresh JWKS, using cached keys")
+		}
+	}()
+}
+
+// fetch загружает набор ключей и запоминает попытку, в том числе неудачную.
+// Вызывается под fetchMu.
+func (j *JWKSCache) fetch(ctx context.Context) error {
+	keys, err := j.download(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.attemptedAt = time.Now()
+	j.lastErr = err
+	if err != nil {
+		return err
+	}
+	j.keys = keys
+	j.fetchedAt = j.attemptedAt
+	return nil
+}
+
+func (j *JWKSCache) download(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q in JWKS: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k *jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent is too large")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
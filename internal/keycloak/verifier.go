@@ -0,0 +1,187 @@
+package keycloak
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// TokenVerifier проверяет access токены локально по ключам JWKS realm
+type TokenVerifier struct {
+	jwks     *JWKSCache
+	issuer   string
+	audience string
+	clientID string
+}
+
+// NewTokenVerifier создает верификатор токенов.
+// issuer - ожидаемый iss, audience - значение, которое должно входить в aud,
+// clientID - клиент, для которого выпущен токен (azp).
+func NewTokenVerifier(jwks *JWKSCache, issuer, audience, clientID string) *TokenVerifier {
+	return &TokenVerifier{
+		jwks:     jwks,
+		issuer:   issuer,
+		audience: audience,
+		clientID: clientID,
+	}
+}
+
+// tokenClaims - стандартные claims JWT, необходимые для проверки токена
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	AuthorizedParty string `json:"azp"`
+}
+
+// parse проверяет подпись и срок действия токена
+func (v *TokenVerifier) parse(ctx context.Context, token string, claims jwt.Claims) error {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}))
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token has no kid header")
+		}
+		return v.jwks.Key(ctx, kid)
+	})
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+	return nil
+}
+
+// Verify проверяет подпись, срок действия, issuer, audience и azp токена
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	return v.verify(ctx, token, nil)
+}
+
+// VerifyBearer проверяет токен, предъявленный API. Помимо токенов клиента фабрики
+// принимаются токены клиентов из clients (например, client credentials сервисных аккаунтов).
+func (v *TokenVerifier) VerifyBearer(ctx context.Context, token string, clients []string) (*Claims, error) {
+	return v.verify(ctx, token, clients)
+}
+
+func (v *TokenVerifier) verify(ctx context.Context, token string, extraClients []string) (*Claims, error) {
+	var claims tokenClaims
+	if err := v.parse(ctx, token, &claims); err != nil {
+		return nil, err
+	}
+
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("invalid token: missing exp claim")
+	}
+
+	if !claims.VerifyIssuer(v.issuer, true) {
+		return nil, fmt.Errorf("invalid token: unexpected issuer %q", claims.Issuer)
+	}
+
+	if v.audience != "" && !claims.VerifyAudience(v.audience, true) {
+		return nil, fmt.Errorf("invalid token: audience %v does not contain %q", claims.Audience, v.audience)
+	}
+
+	if !v.authorizedParty(claims.AuthorizedParty, extraClients) {
+		return nil, fmt.Errorf("invalid token: unexpected azp %q", claims.AuthorizedParty)
+	}
+
+	// Подпись проверена, теперь можно извлечь данные пользователя и роли
+	return ParseClaims(token, v.clientID)
+}
+
+// idTokenClaims - claims ID токена, необходимые для проверки входа
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce string `json:"nonce"`
+}
+
+// authorizedParty проверяет, что токен выпущен для клиента фабрики или одного из разрешенных клиентов
+func (v *TokenVerifier) authorizedParty(azp string, extraClients []string) bool {
+	if azp == v.clientID {
+		return true
+	}
+	for _, client := range extraClients {
+		if client != "" && azp == client {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyIDToken проверяет ID токен: подпись, срок действия, issuer,
+// адресата (client ID) и совпадение nonce с отправленным в запросе авторизации
+func (v *TokenVerifier) VerifyIDToken(ctx context.Context, token, nonce string) error {
+	var claims idTokenClaims
+	if err := v.parse(ctx, token, &claims); err != nil {
+		return err
+	}
+
+	if claims.ExpiresAt == nil {
+		return errors.New("invalid ID token: missing exp claim")
+	}
+
+	if !claims.VerifyIssuer(v.issuer, true) {
+		return fmt.Errorf("invalid ID token: unexpected issuer %q", claims.Issuer)
+	}
+
+	if !claims.VerifyAudience(v.clientID, true) {
+		return fmt.Errorf("invalid ID token: audience %v does not contain %q", claims.Audience, v.clientID)
+	}
+
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return errors.New("invalid ID token: nonce mismatch")
+	}
+
+	return nil
+}
+
+// backchannelLogoutEvent - обязательное событие logout токена (OpenID Back-Channel Logout)
+const backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// LogoutClaims - данные logout токена, определяющие отзываемые сессии
+type LogoutClaims struct {
+	Subject   string
+	SessionID string
+}
+
+// logoutTokenClaims - claims logout токена back-channel logout
+type logoutTokenClaims struct {
+	jwt.RegisteredClaims
+	SessionID string                 `json:"sid"`
+	Events    map[string]interface{} `json:"events"`
+	Nonce     *string                `json:"nonce"`
+}
+
+// VerifyLogoutToken проверяет logout токен согласно OpenID Connect Back-Channel Logout 1.0
+func (v *TokenVerifier) VerifyLogoutToken(ctx context.Context, token string) (*LogoutClaims, error) {
+	var claims logoutTokenClaims
+	if err := v.parse(ctx, token, &claims); err != nil {
+		return nil, err
+	}
+
+	if !claims.VerifyIssuer(v.issuer, true) {
+		return nil, fmt.Errorf("invalid logout token: unexpected issuer %q", claims.Issuer)
+	}
+
+	if !claims.VerifyAudience(v.clientID, true) {
+		return nil, fmt.Errorf("invalid logout token: audience %v does not contain %q", claims.Audience, v.clientID)
+	}
+
+	if claims.IssuedAt == nil {
+		return nil, errors.New("invalid logout token: missing iat claim")
+	}
+
+	if _, ok := claims.Events[backchannelLogoutEvent]; !ok {
+		return nil, errors.New("invalid logout token: missing back-channel logout event")
+	}
+
+	// nonce запрещен, чтобы ID токен нельзя было выдать за logout токен
+	if claims.Nonce != nil {
+		return nil, errors.New("invalid logout token: nonce is not allowed")
+	}
+
+	if claims.Subject == "" && claims.SessionID == "" {
+		return nil, errors.New("invalid logout token: either sub or sid is required")
+	}
+
+	return &LogoutClaims{Subject: claims.Subject, SessionID: claims.SessionID}, nil
+}
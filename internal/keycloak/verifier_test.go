@@ -0,0 +1,377 @@
+package keycloak
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testIssuer   = "http://keycloak.test/realms/factory"
+	testClientID = "factory"
+)
+
+// fakeJWKSServer - локальный JWKS endpoint с управляемым набором ключей
+type fakeJWKSServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PrivateKey
+	requests int
+	failing  bool
+	// hang, если задан, задерживает ответ до его закрытия, как зависший Keycloak
+	hang chan struct{}
+}
+
+func newFakeJWKSServer(t *testing.T) *fakeJWKSServer {
+	t.Helper()
+	f := &fakeJWKSServer{keys: make(map[string]*rsa.PrivateKey)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests++
+		hang := f.hang
+		f.mu.Unlock()
+		if hang != nil {
+			select {
+			case <-hang:
+			case <-r.Context().Done():
+			}
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		keys := make([]map[string]string, 0, len(f.keys))
+		for kid, key := range f.keys {
+			keys = append(keys, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeJWKSServer) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	f.mu.Lock()
+	f.keys[kid] = key
+	f.mu.Unlock()
+	return key
+}
+
+func (f *fakeJWKSServer) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func (f *fakeJWKSServer) setFailing(failing bool) {
+	f.mu.Lock()
+	f.failing = failing
+	f.mu.Unlock()
+}
+
+// backdate сдвигает последнюю загрузку и попытку загрузки кэша на d в прошлое
+func backdate(cache *JWKSCache, d time.Duration) {
+	cache.mu.Lock()
+	cache.fetchedAt = time.Now().Add(-d)
+	cache.attemptedAt = cache.fetchedAt
+	cache.mu.Unlock()
+}
+
+// waitForRefresh ждет окончания фонового обновления кэша
+func waitForRefresh(t *testing.T, cache *JWKSCache) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cache.mu.RLock()
+		refreshing := cache.refreshing
+		cache.mu.RUnlock()
+		if !refreshing {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Background JWKS refresh did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// validClaims возвращает claims корректного access токена
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":                testIssuer,
+		"aud":                []string{testClientID, "account"},
+		"azp":                testClientID,
+		"sub":                "user-1",
+		"preferred_username": "morty",
+		"email":              "morty@smith.test",
+		"exp":                time.Now().Add(5 * time.Minute).Unix(),
+		"iat":                time.Now().Unix(),
+		"realm_access":       map[string]interface{}{"roles": []string{RoleOperator}},
+	}
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func newTestVerifier(server *fakeJWKSServer) *TokenVerifier {
+	return NewTokenVerifier(NewJWKSCache(server.URL, server.Client()), testIssuer, testClientID, testClientID)
+}
+
+func TestTokenVerifier_Verify_Success(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	verifier := newTestVerifier(server)
+
+	claims, err := verifier.Verify(context.Background(), signToken(t, key, "key-1", validClaims()))
+	if err != nil {
+		t.Fatalf("Verify() error = %v, want nil", err)
+	}
+
+	if claims.Subject != "user-1" {
+		t.Errorf("Subject = %v, want user-1", claims.Subject)
+	}
+
+	if claims.PreferredUsername != "morty" {
+		t.Errorf("PreferredUsername = %v, want morty", claims.PreferredUsername)
+	}
+
+	if !claims.HasRole(RoleOperator) {
+		t.Error("HasRole(operator) = false, want true")
+	}
+}
+
+func TestTokenVerifier_Verify_Rejected(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name   string
+		key    *rsa.PrivateKey
+		mutate func(jwt.MapClaims)
+	}{
+		{"expired", key, func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"missing exp", key, func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"wrong issuer", key, func(c jwt.MapClaims) { c["iss"] = "http://evil.test/realms/factory" }},
+		{"wrong audience", key, func(c jwt.MapClaims) { c["aud"] = "account" }},
+		{"wrong azp", key, func(c jwt.MapClaims) { c["azp"] = "other-client" }},
+		{"foreign signature", otherKey, func(c jwt.MapClaims) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.mutate(claims)
+
+			_, err := newTestVerifier(server).Verify(context.Background(), signToken(t, tt.key, "key-1", claims))
+			if err == nil {
+				t.Error("Verify() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTokenVerifier_VerifyBearer_AllowedClients(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	verifier := newTestVerifier(server)
+
+	claims := validClaims()
+	claims["azp"] = "ci-bot"
+	token := signToken(t, key, "key-1", claims)
+
+	if _, err := verifier.Verify(context.Background(), token); err == nil {
+		t.Error("Verify() accepted token of another client")
+	}
+	if _, err := verifier.VerifyBearer(context.Background(), token, []string{"ci-bot"}); err != nil {
+		t.Errorf("VerifyBearer() error = %v, want nil for allowed client", err)
+	}
+	if _, err := verifier.VerifyBearer(context.Background(), token, []string{"exporter"}); err == nil {
+		t.Error("VerifyBearer() accepted token of client that is not allowed")
+	}
+}
+
+func TestTokenVerifier_Verify_RejectsUnsignedAlgorithm(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	server.addKey(t, "key-1")
+
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims())
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("Failed to build token: %v", err)
+	}
+
+	if _, err := newTestVerifier(server).Verify(context.Background(), signed); err == nil {
+		t.Error("Verify() error = nil, want error for alg=none")
+	}
+}
+
+func TestTokenVerifier_Verify_KeyRotation(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	oldKey := server.addKey(t, "old")
+	verifier := newTestVerifier(server)
+
+	if _, err := verifier.Verify(context.Background(), signToken(t, oldKey, "old", validClaims())); err != nil {
+		t.Fatalf("Verify() with old key error = %v", err)
+	}
+
+	// Keycloak выпускает новый ключ: кэш должен обновиться по неизвестному kid
+	newKey := server.addKey(t, "new")
+	backdate(verifier.jwks, jwksMinRefreshInterval)
+
+	if _, err := verifier.Verify(context.Background(), signToken(t, newKey, "new", validClaims())); err != nil {
+		t.Fatalf("Verify() with rotated key error = %v", err)
+	}
+
+	if got := server.requestCount(); got != 2 {
+		t.Errorf("JWKS requests = %d, want 2", got)
+	}
+}
+
+func TestJWKSCache_CachesKeys(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	verifier := newTestVerifier(server)
+
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(context.Background(), signToken(t, key, "key-1", validClaims())); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	}
+
+	if got := server.requestCount(); got != 1 {
+		t.Errorf("JWKS requests = %d, want 1 (keys must be cached)", got)
+	}
+}
+
+func TestJWKSCache_UnknownKidRateLimited(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	server.addKey(t, "key-1")
+	cache := NewJWKSCache(server.URL, server.Client())
+
+	if _, err := cache.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Key(context.Background(), "unknown")
+		if !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Key(unknown) error = %v, want ErrKeyNotFound", err)
+		}
+	}
+
+	if got := server.requestCount(); got != 1 {
+		t.Errorf("JWKS requests = %d, want 1 (unknown kid must not trigger refetch storm)", got)
+	}
+}
+
+func TestJWKSCache_StaleKeyUsedWhenKeycloakDown(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	verifier := newTestVerifier(server)
+	token := signToken(t, key, "key-1", validClaims())
+
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// Кэш устарел, а Keycloak завис и отвечает ошибкой только после release
+	release := make(chan struct{})
+	server.mu.Lock()
+	server.hang = release
+	server.failing = true
+	server.mu.Unlock()
+	backdate(verifier.jwks, 2*jwksCacheTTL)
+
+	begin := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := verifier.Verify(context.Background(), token); err != nil {
+			t.Fatalf("Verify() error = %v, want cached key while Keycloak is down", err)
+		}
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("Verify() with stale keys took %v, want no wait for Keycloak", elapsed)
+	}
+	close(release)
+	waitForRefresh(t, verifier.jwks)
+
+	// Неудачная попытка учитывается: следующие запросы не обращаются к Keycloak
+	for i := 0; i < 5; i++ {
+		if _, err := verifier.Verify(context.Background(), token); err != nil {
+			t.Fatalf("Verify() after failed refresh error = %v", err)
+		}
+	}
+	if got := server.requestCount(); got != 2 {
+		t.Errorf("JWKS requests = %d, want 2 (initial load and one refresh)", got)
+	}
+}
+
+func TestJWKSCache_UnknownKidRateLimitedWhenKeycloakDown(t *testing.T) {
+	server := newFakeJWKSServer(t)
+	server.addKey(t, "key-1")
+	cache := NewJWKSCache(server.URL, server.Client())
+
+	if _, err := cache.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+	backdate(cache, jwksMinRefreshInterval)
+	server.setFailing(true)
+
+	// Параллельные запросы ждут одну загрузку, а неудача ограничивает следующие
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Key(context.Background(), "unknown"); err == nil {
+				t.Error("Key(unknown) error = nil, want error")
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := cache.Key(context.Background(), "unknown"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Key(unknown) error = %v, want ErrKeyNotFound", err)
+	}
+
+	if got := server.requestCount(); got != 2 {
+		t.Errorf("JWKS requests = %d, want 2 (failed attempts must be rate limited)", got)
+	}
+}
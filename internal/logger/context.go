@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+	plumbusIDKey
+)
+
+// WithRequestID сохраняет ID запроса в контексте
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// WithUserID сохраняет ID пользователя, выполняющего запрос, в контексте
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// WithPlumbusID сохраняет ID плюмбуса, с которым работает запрос, в контексте
+func WithPlumbusID(ctx context.Context, plumbusID string) context.Context {
+	return context.WithValue(ctx, plumbusIDKey, plumbusID)
+}
+
+// RequestID возвращает ID запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// contextHook добавляет в запись поля из контекста, переданного через WithContext.
+// Поля, заданные явно, не перезаписываются.
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (contextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	for field, key := range map[string]contextKey{
+		"request_id": requestIDKey,
+		"user_id":    userIDKey,
+		"plumbus_id": plumbusIDKey,
+	} {
+		if _, ok := entry.Data[field]; ok {
+			continue
+		}
+		if value, ok := entry.Context.Value(key).(string); ok && value != "" {
+			entry.Data[field] = value
+		}
+	}
+	return nil
+}
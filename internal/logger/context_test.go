@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestContextHook_AddsRequestFields(t *testing.T) {
+	var buf bytes.Buffer
+	root, _ := New(Config{Output: &buf})
+	log := root.Component("test")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithUserID(ctx, "user-1")
+	ctx = WithPlumbusID(ctx, "plumbus-1")
+
+	log.WithContext(ctx).WithField("plumbus_id", "explicit").Info("test")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to decode log entry: %v", err)
+	}
+	if entry["request_id"] != "req-1" || entry["user_id"] != "user-1" {
+		t.Errorf("entry = %v, want request_id and user_id from context", entry)
+	}
+	// Явно заданные поля важнее полей из контекста
+	if entry["plumbus_id"] != "explicit" {
+		t.Errorf("plumbus_id = %v, want explicit value", entry["plumbus_id"])
+	}
+
+	buf.Reset()
+	log.Info("no context")
+	if bytes.Contains(buf.Bytes(), []byte("request_id")) {
+		t.Errorf("entry without context = %s, want no request_id", buf.String())
+	}
+}
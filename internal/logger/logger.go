@@ -1,39 +1,208 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
 
-// Init инициализирует глобальный логгер в JSON формате
-func Init() *logrus.Logger {
-	logger := logrus.New()
+// Форматы логов
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+const timestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Config - настройки логирования
+type Config struct {
+	// Format - json или text
+	Format string
+	// Level - уровень логирования по умолчанию
+	Level string
+	// Levels - уровни отдельных компонентов: "database=debug,http=warn"
+	Levels string
+	// Output - куда писать логи, по умолчанию stdout
+	Output io.Writer
+}
+
+// Logger - общий логгер фабрики. Все компоненты пишут через него в одном формате,
+// с полями запроса из контекста и скрытыми чувствительными данными.
+type Logger struct {
+	mu         sync.Mutex
+	out        io.Writer
+	formatter  logrus.Formatter
+	level      logrus.Level
+	levels     map[string]logrus.Level
+	components map[string]*logrus.Logger
+}
 
-	// Устанавливаем JSON формат
-	logger.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
-	})
+// New создает логгер по настройкам
+func New(cfg Config) (*Logger, error) {
+	l := &Logger{
+		out:        cfg.Output,
+		components: make(map[string]*logrus.Logger),
+	}
+	if l.out == nil {
+		l.out = os.Stdout
+	}
+	// Логгеры компонентов пишут в один поток, записи не должны перемешиваться
+	l.out = &syncWriter{w: l.out}
+
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatJSON:
+		l.formatter = &logrus.JSONFormatter{TimestampFormat: timestampFormat}
+	case FormatText:
+		l.formatter = &logrus.TextFormatter{FullTimestamp: true, TimestampFormat: timestampFormat}
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
 
-	// Устанавливаем уровень логирования из переменной окружения
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		logLevel = "info"
+	if err := l.SetLevels(cfg.Level, cfg.Levels); err != nil {
+		return nil, err
 	}
+	return l, nil
+}
 
-	level, err := logrus.ParseLevel(logLevel)
+// SetLevels меняет уровень по умолчанию и уровни компонентов, в том числе у уже
+// созданных логгеров компонентов
+func (l *Logger) SetLevels(level, levels string) error {
+	defaultLevel := logrus.InfoLevel
+	if level != "" {
+		parsed, err := logrus.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("invalid log level %q", level)
+		}
+		defaultLevel = parsed
+	}
+	componentLevels, err := ParseLevels(levels)
 	if err != nil {
-		level = logrus.InfoLevel
+		return err
 	}
-	logger.SetLevel(level)
 
-	// Выводим в stdout
-	logger.SetOutput(os.Stdout)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = defaultLevel
+	l.levels = componentLevels
+	for name, component := range l.components {
+		component.SetLevel(l.levelFor(name))
+	}
+	return nil
+}
+
+// ParseLevels разбирает уровни компонентов в формате "database=debug,http=warn"
+func ParseLevels(value string) (map[string]logrus.Level, error) {
+	levels := make(map[string]logrus.Level)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, level, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid component log level %q, want component=level", item)
+		}
+		parsed, err := logrus.ParseLevel(strings.TrimSpace(level))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level for component %q: %q", name, level)
+		}
+		levels[name] = parsed
+	}
+	return levels, nil
+}
+
+func (l *Logger) levelFor(component string) logrus.Level {
+	if level, ok := l.levels[component]; ok {
+		return level
+	}
+	return l.level
+}
+
+// Component возвращает логгер компонента. Записи получают поле component и
+// пишутся с уровнем, заданным для компонента.
+func (l *Logger) Component(name string) *logrus.Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if component, ok := l.components[name]; ok {
+		return component
+	}
+
+	component := logrus.New()
+	component.SetOutput(l.out)
+	component.SetFormatter(l.formatter)
+	component.SetLevel(l.levelFor(name))
+	// Хуки выполняются по порядку: сначала добавляем поля, затем скрываем данные в них
+	component.AddHook(componentHook{name: name})
+	component.AddHook(contextHook{})
+	component.AddHook(redactHook{})
+
+	l.components[name] = component
+	return component
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = mustNew(Config{Level: os.Getenv("LOG_LEVEL")})
+)
+
+func mustNew(cfg Config) *Logger {
+	l, err := New(cfg)
+	if err != nil {
+		l, _ = New(Config{})
+	}
+	return l
+}
+
+// SetDefault делает логгер общим для всех пакетов. Вызывается при старте до
+// создания сервисов, чтобы они получили логгеры с нужными настройками.
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+// Default возвращает общий логгер фабрики
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// For возвращает логгер компонента из общего логгера фабрики
+func For(component string) *logrus.Logger {
+	return Default().Component(component)
+}
+
+// componentHook добавляет в записи имя компонента
+type componentHook struct {
+	name string
+}
+
+func (componentHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h componentHook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data["component"]; !ok {
+		entry.Data["component"] = h.name
+	}
+	return nil
+}
 
-	return logger
+// syncWriter сериализует записи логгеров разных компонентов
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
 }
 
-// GetGinWriter возвращает writer для Gin в JSON формате
-func GetGinWriter() *logrus.Logger {
-	return Init()
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
 }
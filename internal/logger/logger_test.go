@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func decodeEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to decode log entry %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogger_ComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	root, err := New(Config{Level: "warn", Levels: "database=debug", Output: &buf})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	root.Component("database").Debug("query")
+	root.Component("handlers").Info("request")
+	root.Component("handlers").Warn("slow request")
+
+	entries := decodeEntries(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("entries = %v, want database debug and handlers warn", entries)
+	}
+	if entries[0]["component"] != "database" || entries[0]["msg"] != "query" {
+		t.Errorf("entries[0] = %v, want database query", entries[0])
+	}
+	if entries[1]["component"] != "handlers" || entries[1]["level"] != "warning" {
+		t.Errorf("entries[1] = %v, want handlers warning", entries[1])
+	}
+
+	// Новые уровни применяются к уже созданным логгерам компонентов
+	if err := root.SetLevels("info", ""); err != nil {
+		t.Fatalf("SetLevels() error = %v", err)
+	}
+	if level := root.Component("database").GetLevel(); level != logrus.InfoLevel {
+		t.Errorf("database level = %v, want info", level)
+	}
+	if root.Component("handlers") != root.Component("handlers") {
+		t.Error("Component() returned different loggers for the same component")
+	}
+}
+
+func TestLogger_TextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	root, err := New(Config{Format: FormatText, Output: &buf})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	root.Component("app").WithField("plumbus_id", "p-1").Info("started")
+	out := buf.String()
+	if !strings.Contains(out, `msg=started`) || !strings.Contains(out, "component=app") || !strings.Contains(out, "plumbus_id=p-1") {
+		t.Errorf("text entry = %q, want logfmt with component and fields", out)
+	}
+}
+
+func TestLogger_InvalidConfig(t *testing.T) {
+	tests := []Config{
+		{Format: "xml"},
+		{Level: "loud"},
+		{Levels: "database"},
+		{Levels: "database=loud"},
+	}
+	for _, cfg := range tests {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) error = nil, want error", cfg)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted заменяет значения секретов в логах
+const Redacted = "[REDACTED]"
+
+// secretFields - поля, значения которых никогда не попадают в логи. Поле считается
+// секретом, если его имя совпадает с одним из них или заканчивается на _<имя>.
+var secretFields = []string{"token", "password", "secret", "authorization", "cookie", "api_key"}
+
+var (
+	emailPattern  = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// Персональные токены API начинаются с pat_ (services.TokenPrefix)
+	patPattern = regexp.MustCompile(`pat_[A-Za-z0-9_-]{8,}`)
+)
+
+// isSecretField проверяет, хранит ли поле секрет
+func isSecretField(key string) bool {
+	key = strings.ToLower(key)
+	for _, name := range secretFields {
+		if key == name || strings.HasSuffix(key, "_"+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString скрывает в тексте токены и маскирует адреса почты:
+// rick@citadel.io превращается в r***@citadel.io
+func RedactString(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = patPattern.ReplaceAllString(s, "pat_"+Redacted)
+	return emailPattern.ReplaceAllString(s, "${1}***@${2}")
+}
+
+// redactHook скрывает чувствительные данные в сообщении и полях записи.
+// Секреты заменяются целиком, в остальных строках и ошибках скрываются токены
+// и маскируются адреса почты.
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = RedactString(entry.Message)
+
+	for key, value := range entry.Data {
+		if isSecretField(key) {
+			entry.Data[key] = Redacted
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = RedactString(v)
+		case error:
+			if message := v.Error(); RedactString(message) != message {
+				entry.Data[key] = RedactString(message)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "factory"
+
+// Исходы операций во внешних сервисах
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// Registry содержит все метрики фабрики и отдается на /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration - длительность HTTP запросов по шаблону маршрута
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// GenerationDuration - длительность попытки генерации от запуска до итогового статуса
+	GenerationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "generation_duration_seconds",
+		Help:      "Plumbus generation attempt duration by outcome.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+	}, []string{"outcome"})
+
+	// GenerationsTotal - число завершенных попыток генерации по итоговому статусу
+	GenerationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "generations_total",
+		Help:      "Finished plumbus generation attempts by outcome.",
+	}, []string{"outcome"})
+
+	// GeneratorRequestDuration - длительность запросов к сервису генерации изображений
+	GeneratorRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "generator_request_duration_seconds",
+		Help:      "Image generator request latency by outcome.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30},
+	}, []string{"outcome"})
+
+	// SigningDuration - длительность подписания изображения в sig-store
+	SigningDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "signing_duration_seconds",
+		Help:      "Sig-store signing latency by outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	// NATSPublishFailures - число событий, которые не удалось опубликовать в NATS
+	NATSPublishFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nats_publish_failures_total",
+		Help:      "Events that failed to publish to NATS by event type.",
+	}, []string{"event_type"})
+
+	// RarePlumbuses - число созданных редких плюмбусов
+	RarePlumbuses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rare_plumbuses_total",
+		Help:      "Rare plumbuses created.",
+	})
+
+	// ReapedPlumbuses - число зависших плюмбусов, отправленных на повтор или завершенных ошибкой
+	ReapedPlumbuses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reaped_plumbuses_total",
+		Help:      "Stuck plumbuses reaped by action.",
+	}, []string{"action"})
+
+	// ImageGC - файлы и плюмбусы, обработанные сборщиком изображений: удаленные
+	// файлы-сироты, плюмбусы с пропавшими файлами и вернувшимися файлами
+	ImageGC = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_gc_total",
+		Help:      "Images handled by the image garbage collector by action.",
+	}, []string{"action"})
+
+	// GenerationQueueDepth - число генераций, выполняющихся в этом процессе
+	GenerationQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "generation_queue_depth",
+		Help:      "Plumbus generations currently in flight.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		GenerationDuration,
+		GenerationsTotal,
+		GeneratorRequestDuration,
+		SigningDuration,
+		NATSPublishFailures,
+		RarePlumbuses,
+		ReapedPlumbuses,
+		ImageGC,
+		GenerationQueueDepth,
+	)
+}
+
+// RegisterDB добавляет статистику пула соединений с базой данных
+func RegisterDB(db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, "factory"))
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Outcome возвращает исход операции по ее ошибке
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
+
+// Middleware измеряет длительность HTTP запросов. Запросы к неизвестным
+// маршрутам учитываются вместе, чтобы не плодить метки.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
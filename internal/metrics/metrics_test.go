@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware_LabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/plumbus/status/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/metrics", gin.WrapH(Handler()))
+
+	for _, path := range []string{"/plumbus/status/1", "/plumbus/status/2", "/unknown"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Параметры пути не попадают в метки
+	if count := testutil.CollectAndCount(HTTPRequestDuration); count != 2 {
+		t.Errorf("HTTP duration series = %d, want 2", count)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	for _, want := range []string{
+		`factory_http_request_duration_seconds_count{method="GET",route="/plumbus/status/:id",status="200"} 2`,
+		`factory_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics does not contain %q", want)
+		}
+	}
+}
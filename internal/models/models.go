@@ -42,8 +42,16 @@ type Plumbus struct {
 	Signature     *string       `json:"signature,omitempty"`
 	SignatureDate *time.Time    `json:"signature_date,omitempty"`
 	ErrorMsg      *string       `json:"error_msg,omitempty"`
-	CreatedAt     time.Time     `gorm:"not null" json:"created_at"`
-	UpdatedAt     time.Time     `gorm:"not null" json:"updated_at"`
+	// Attempts - номер текущей попытки генерации, растет при каждом повторе
+	Attempts int `gorm:"not null;default:1" json:"attempts"`
+	// Interrupted - генерация прервана остановкой фабрики и будет продолжена при следующем запуске
+	Interrupted bool `gorm:"not null;default:false;index" json:"-"`
+	// Broken - файл изображения готового плюмбуса пропал из хранилища
+	Broken    bool      `gorm:"not null;default:false" json:"broken,omitempty"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+	// DeletedAt - время мягкого удаления, до окончания окна восстановления плюмбус можно вернуть
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
@@ -53,6 +61,107 @@ func (Plumbus) TableName() string {
 	return "plumbus"
 }
 
+// PlumbusAttempt - запись истории попыток генерации плюмбуса
+type PlumbusAttempt struct {
+	ID         uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	PlumbusID  uuid.UUID     `gorm:"type:uuid;not null;index" json:"plumbus_id"`
+	Attempt    int           `gorm:"not null" json:"attempt"`
+	Status     PlumbusStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Error      string        `json:"error,omitempty"`
+	StartedAt  time.Time     `gorm:"not null" json:"started_at"`
+	FinishedAt time.Time     `gorm:"not null" json:"finished_at"`
+}
+
+// TableName возвращает имя таблицы для модели PlumbusAttempt
+func (PlumbusAttempt) TableName() string {
+	return "plumbus_attempt"
+}
+
+// Серверная сессия пользователя. Браузер хранит только подписанный ID сессии.
+type Session struct {
+	ID           string    `gorm:"primaryKey;size:64" json:"-"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	AccessToken  string    `gorm:"type:text;not null" json:"-"`
+	RefreshToken string    `gorm:"type:text" json:"-"`
+	IDToken      string    `gorm:"type:text" json:"-"`
+	// Subject и KeycloakSessionID нужны для отзыва сессии по back-channel logout
+	Subject           string    `gorm:"index" json:"-"`
+	KeycloakSessionID string    `gorm:"index" json:"-"`
+	AccessExpiresAt   time.Time `gorm:"not null" json:"access_expires_at"`
+	ExpiresAt         time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt         time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// TableName возвращает имя таблицы для модели Session
+func (Session) TableName() string {
+	return "session"
+}
+
+// Персональный токен доступа к API. Хранится только SHA-256 хэш токена.
+type APIToken struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Name   string    `gorm:"not null" json:"name"`
+	// Prefix - начало токена для отображения в списке
+	Prefix    string `gorm:"size:16;not null" json:"prefix"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	// Scopes - разрешения токена через пробел (plumbus:read plumbus:write)
+	Scopes     string     `gorm:"not null" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null" json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели APIToken
+func (APIToken) TableName() string {
+	return "api_token"
+}
+
+// Webhook - HTTP callback пользователя о завершении генерации плюмбусов
+type Webhook struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	URL    string    `gorm:"not null" json:"url"`
+	// Secret - ключ HMAC-SHA256 подписи тела запроса
+	Secret string `gorm:"size:64;not null" json:"-"`
+	// Events - события через пробел (plumbus.completed plumbus.failed)
+	Events  string `gorm:"not null" json:"events"`
+	Enabled bool   `gorm:"not null;default:true" json:"enabled"`
+	// FailureCount - число подряд неуспешных доставок, после порога webhook отключается
+	FailureCount int        `gorm:"not null;default:0" json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+// TableName возвращает имя таблицы для модели Webhook
+func (Webhook) TableName() string {
+	return "webhook"
+}
+
+// WebhookDelivery - журнал попыток доставки webhook
+type WebhookDelivery struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	WebhookID uuid.UUID `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	// EventID одинаков у всех попыток доставки одного события
+	EventID    uuid.UUID `gorm:"type:uuid;not null;index" json:"event_id"`
+	Event      string    `gorm:"not null" json:"event"`
+	PlumbusID  uuid.UUID `gorm:"type:uuid;not null" json:"plumbus_id"`
+	Attempt    int       `gorm:"not null" json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Success    bool      `gorm:"not null" json:"success"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
 // TestUser возвращает структуру User с SQLiteUUID для тестов
 func (u *User) TestUser(db *gorm.DB) interface{} {
 	if db != nil && db.Name() == "sqlite" {
@@ -93,8 +202,12 @@ func (p *Plumbus) TestPlumbus(db *gorm.DB) interface{} {
 			Signature     *string
 			SignatureDate *time.Time
 			ErrorMsg      *string
-			CreatedAt     time.Time `gorm:"not null"`
-			UpdatedAt     time.Time `gorm:"not null"`
+			Attempts      int            `gorm:"not null;default:1"`
+			Interrupted   bool           `gorm:"not null;default:false;index"`
+			Broken        bool           `gorm:"not null;default:false"`
+			CreatedAt     time.Time      `gorm:"not null"`
+			UpdatedAt     time.Time      `gorm:"not null"`
+			DeletedAt     gorm.DeletedAt `gorm:"index"`
 		}{
 			ID:            testutils.SQLiteUUID(p.ID),
 			UserID:        testutils.SQLiteUUID(p.UserID),
@@ -110,8 +223,12 @@ func (p *Plumbus) TestPlumbus(db *gorm.DB) interface{} {
 			Signature:     p.Signature,
 			SignatureDate: p.SignatureDate,
 			ErrorMsg:      p.ErrorMsg,
+			Attempts:      p.Attempts,
+			Interrupted:   p.Interrupted,
+			Broken:        p.Broken,
 			CreatedAt:     p.CreatedAt,
 			UpdatedAt:     p.UpdatedAt,
+			DeletedAt:     p.DeletedAt,
 		}
 	}
 	return p
@@ -124,6 +241,7 @@ const (
 	StatusGenerating PlumbusStatus = "generating"
 	StatusCompleted  PlumbusStatus = "completed"
 	StatusFailed     PlumbusStatus = "failed"
+	StatusCancelled  PlumbusStatus = "cancelled"
 )
 
 // Запрос на генерацию плюмбуса
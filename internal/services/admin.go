@@ -0,0 +1,198 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"factory/internal/models"
+	"factory/internal/testutils"
+
+	"github.com/google/uuid"
+)
+
+// Значения по умолчанию для выборок панели администратора
+const (
+	// DefaultStuckAfter - сколько незавершенный плюмбус может не обновляться, прежде чем считается зависшим
+	DefaultStuckAfter = 15 * time.Minute
+	// DefaultAdminListLimit - сколько плюмбусов показывает панель администратора
+	DefaultAdminListLimit = 200
+	// DefaultFailureReasonsLimit - сколько самых частых причин ошибок показывает панель администратора
+	DefaultFailureReasonsLimit = 20
+)
+
+// PlumbusFilter - условия выборки плюмбусов всех пользователей. Пустые поля не ограничивают выборку.
+type PlumbusFilter struct {
+	Status models.PlumbusStatus
+	UserID uuid.UUID
+	// Name - подстрока названия
+	Name string
+	// Stuck - только плюмбусы в ожидании или генерации, не обновлявшиеся дольше StuckAfter
+	Stuck      bool
+	StuckAfter time.Duration
+	// Deleted - только мягко удаленные плюмбусы
+	Deleted bool
+	Limit   int
+}
+
+// UserStats - сводка по плюмбусам пользователя без учета удаленных
+type UserStats struct {
+	UserID     uuid.UUID
+	Username   string
+	Total      int64
+	Completed  int64
+	Failed     int64
+	InProgress int64
+	Rare       int64
+}
+
+// FailureReason - текст ошибки генерации и число плюмбусов, завершившихся с ней
+type FailureReason struct {
+	Reason string
+	Count  int64
+}
+
+// ListPlumbuses возвращает плюмбусы всех пользователей по фильтру, новые первыми
+func (s *UserService) ListPlumbuses(filter PlumbusFilter, now time.Time) ([]models.Plumbus, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAdminListLimit
+	}
+	if filter.StuckAfter <= 0 {
+		filter.StuckAfter = DefaultStuckAfter
+	}
+
+	query := s.db
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Name != "" {
+		query = query.Where(`LOWER(name) LIKE ? ESCAPE '\'`, "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.Stuck {
+		query = query.Where("status IN ? AND updated_at < ?",
+			[]models.PlumbusStatus{models.StatusPending, models.StatusGenerating}, now.Add(-filter.StuckAfter))
+	}
+	query = query.Order("created_at desc").Limit(filter.Limit)
+
+	if s.db.Name() == "sqlite" {
+		if filter.UserID != uuid.Nil {
+			query = query.Where("user_id = ?", testutils.SQLiteUUID(filter.UserID))
+		}
+		var sqlitePlumbuses []SQLitePlumbus
+		if err := query.Find(&sqlitePlumbuses).Error; err != nil {
+			return nil, err
+		}
+		plumbuses := make([]models.Plumbus, len(sqlitePlumbuses))
+		for i, sp := range sqlitePlumbuses {
+			plumbuses[i] = sp.toModel()
+		}
+		return plumbuses, nil
+	}
+
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	var plumbuses []models.Plumbus
+	err := query.Find(&plumbuses).Error
+	return plumbuses, err
+}
+
+// GetCompletedPlumbuses возвращает неудаленные готовые плюмбусы с изображением, старые первыми
+func (s *UserService) GetCompletedPlumbuses() ([]models.Plumbus, error) {
+	query := s.db.Where("status = ? AND image_path IS NOT NULL", models.StatusCompleted).Order("created_at")
+
+	if s.db.Name() == "sqlite" {
+		var sqlitePlumbuses []SQLitePlumbus
+		if err := query.Find(&sqlitePlumbuses).Error; err != nil {
+			return nil, err
+		}
+		plumbuses := make([]models.Plumbus, len(sqlitePlumbuses))
+		for i, sp := range sqlitePlumbuses {
+			plumbuses[i] = sp.toModel()
+		}
+		return plumbuses, nil
+	}
+
+	var plumbuses []models.Plumbus
+	err := query.Find(&plumbuses).Error
+	return plumbuses, err
+}
+
+// GetPlumbusImages возвращает изображения всех плюмбусов, в том числе мягко удаленных
+func (s *UserService) GetPlumbusImages() ([]PlumbusImage, error) {
+	var images []PlumbusImage
+	model := interface{}(&models.Plumbus{})
+	if s.db.Name() == "sqlite" {
+		model = &SQLitePlumbus{}
+	}
+	err := s.db.Unscoped().Model(model).
+		Select("id, image_path AS path, broken").
+		Where("image_path IS NOT NULL AND image_path <> ''").
+		Order("created_at").
+		Scan(&images).Error
+	return images, err
+}
+
+// SetPlumbusesBroken ставит или снимает пометку о пропавшем файле изображения
+func (s *UserService) SetPlumbusesBroken(ids []uuid.UUID, broken bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if s.db.Name() == "sqlite" {
+		sqliteIDs := make([]testutils.SQLiteUUID, len(ids))
+		for i, id := range ids {
+			sqliteIDs[i] = testutils.SQLiteUUID(id)
+		}
+		return s.db.Unscoped().Model(&SQLitePlumbus{}).Where("id IN ?", sqliteIDs).Update("broken", broken).Error
+	}
+	return s.db.Unscoped().Model(&models.Plumbus{}).Where("id IN ?", ids).Update("broken", broken).Error
+}
+
+// escapeLike экранирует спецсимволы LIKE и приводит строку к нижнему регистру
+func escapeLike(value string) string {
+	var escaped []rune
+	for _, r := range value {
+		if r == '%' || r == '_' || r == '\\' {
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, r)
+	}
+	return strings.ToLower(string(escaped))
+}
+
+// GetUserStats возвращает сводку по плюмбусам каждого пользователя, начиная с самых активных
+func (s *UserService) GetUserStats() ([]UserStats, error) {
+	var stats []UserStats
+	err := s.db.Raw(`
+		SELECT u.id AS user_id, u.username,
+			COUNT(p.id) AS total,
+			COALESCE(SUM(CASE WHEN p.status = ? THEN 1 ELSE 0 END), 0) AS completed,
+			COALESCE(SUM(CASE WHEN p.status = ? THEN 1 ELSE 0 END), 0) AS failed,
+			COALESCE(SUM(CASE WHEN p.status IN (?, ?) THEN 1 ELSE 0 END), 0) AS in_progress,
+			COALESCE(SUM(CASE WHEN p.is_rare THEN 1 ELSE 0 END), 0) AS rare
+		FROM "user" u
+		LEFT JOIN plumbus p ON p.user_id = u.id AND p.deleted_at IS NULL
+		GROUP BY u.id, u.username
+		ORDER BY total DESC, u.username`,
+		models.StatusCompleted, models.StatusFailed, models.StatusPending, models.StatusGenerating,
+	).Scan(&stats).Error
+	return stats, err
+}
+
+// GetFailureReasons группирует неудавшиеся плюмбусы по тексту ошибки, начиная с самых частых
+func (s *UserService) GetFailureReasons(limit int) ([]FailureReason, error) {
+	if limit <= 0 {
+		limit = DefaultFailureReasonsLimit
+	}
+	var reasons []FailureReason
+	err := s.db.Model(&models.Plumbus{}).
+		Select("COALESCE(error_msg, '') AS reason, COUNT(*) AS count").
+		Where("status = ?", models.StatusFailed).
+		Group("error_msg").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&reasons).Error
+	return reasons, err
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"factory/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// setupAdminData создает двух пользователей: у rick три плюмбуса (два неудавшихся
+// с одной ошибкой и один генерирующийся), у morty - один готовый редкий и один
+// удаленный. Возвращаемое время на час позже обновления плюмбусов: триггер
+// тестовой схемы не дает состарить updated_at.
+func setupAdminData(t *testing.T) (*UserService, *models.User, *models.User, time.Time) {
+	t.Helper()
+	db := setupTestDB(t)
+	service := NewUserService(db)
+
+	rick, err := service.GetOrCreateUser("kc-rick", "rick", "rick@citadel.example")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	morty, err := service.GetOrCreateUser("kc-morty", "morty", "morty@citadel.example")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	timeout := "generator timeout"
+	for i := 0; i < 2; i++ {
+		p := createTestPlumbus(t, db, rick.ID)
+		if err := service.UpdatePlumbusStatus(p.ID, models.StatusFailed, nil, &timeout, nil, nil); err != nil {
+			t.Fatalf("Failed to update plumbus: %v", err)
+		}
+	}
+	stuck := createTestPlumbus(t, db, rick.ID)
+	if err := db.Exec("UPDATE plumbus SET status = ?, name = ? WHERE id = ?",
+		models.StatusGenerating, "Stuck 100%", stuck.ID.String()).Error; err != nil {
+		t.Fatalf("Failed to update plumbus: %v", err)
+	}
+
+	rare := createTestPlumbus(t, db, morty.ID)
+	if err := db.Exec("UPDATE plumbus SET status = ?, is_rare = ? WHERE id = ?",
+		models.StatusCompleted, true, rare.ID.String()).Error; err != nil {
+		t.Fatalf("Failed to update plumbus: %v", err)
+	}
+	deleted := createTestPlumbus(t, db, morty.ID)
+	if err := service.DeletePlumbus(deleted.ID); err != nil {
+		t.Fatalf("Failed to delete plumbus: %v", err)
+	}
+
+	return service, rick, morty, time.Now().UTC().Add(time.Hour)
+}
+
+func TestUserService_ListPlumbuses(t *testing.T) {
+	service, rick, morty, now := setupAdminData(t)
+
+	tests := []struct {
+		name   string
+		filter PlumbusFilter
+		want   int
+	}{
+		{"all users", PlumbusFilter{}, 4},
+		{"by status", PlumbusFilter{Status: models.StatusFailed}, 2},
+		{"by user", PlumbusFilter{UserID: morty.ID}, 1},
+		{"by name", PlumbusFilter{Name: "stuck 100%"}, 1},
+		{"like wildcards are literal", PlumbusFilter{Name: "_"}, 0},
+		{"stuck", PlumbusFilter{Stuck: true}, 1},
+		{"stuck after a longer period", PlumbusFilter{Stuck: true, StuckAfter: 2 * time.Hour}, 0},
+		{"deleted", PlumbusFilter{Deleted: true}, 1},
+		{"unknown user", PlumbusFilter{UserID: uuid.New()}, 0},
+		{"limit", PlumbusFilter{UserID: rick.ID, Limit: 2}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plumbuses, err := service.ListPlumbuses(tt.filter, now)
+			if err != nil {
+				t.Fatalf("ListPlumbuses() error = %v", err)
+			}
+			if len(plumbuses) != tt.want {
+				t.Errorf("ListPlumbuses() = %d plumbuses, want %d", len(plumbuses), tt.want)
+			}
+		})
+	}
+}
+
+func TestUserService_GetUserStats(t *testing.T) {
+	service, rick, morty, _ := setupAdminData(t)
+
+	stats, err := service.GetUserStats()
+	if err != nil {
+		t.Fatalf("GetUserStats() error = %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("GetUserStats() = %+v, want 2 users", stats)
+	}
+
+	want := []UserStats{
+		{UserID: rick.ID, Username: "rick", Total: 3, Failed: 2, InProgress: 1},
+		{UserID: morty.ID, Username: "morty", Total: 1, Completed: 1, Rare: 1},
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("GetUserStats()[%d] = %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+}
+
+func TestUserService_GetFailureReasons(t *testing.T) {
+	service, _, _, _ := setupAdminData(t)
+
+	reasons, err := service.GetFailureReasons(0)
+	if err != nil {
+		t.Fatalf("GetFailureReasons() error = %v", err)
+	}
+	if len(reasons) != 1 || reasons[0].Reason != "generator timeout" || reasons[0].Count != 2 {
+		t.Errorf("GetFailureReasons() = %+v, want 2 x generator timeout", reasons)
+	}
+}
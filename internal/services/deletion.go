@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"factory/internal/logger"
+	"factory/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrPlumbusNotDeleted возвращается при восстановлении неудаленного плюмбуса
+	ErrPlumbusNotDeleted = errors.New("plumbus is not deleted")
+	// ErrRestoreWindowExpired возвращается, если окно восстановления уже истекло
+	ErrRestoreWindowExpired = errors.New("restore window has expired")
+)
+
+// purgerLockKey - ключ advisory lock Postgres, под которым удаленные плюмбусы
+// очищает только один экземпляр фабрики
+const purgerLockKey int64 = 0x706c756d62757303
+
+// PurgeResult - итог одного прохода очистки удаленных плюмбусов
+type PurgeResult struct {
+	Purged int
+	// Failed - плюмбусы, которые не удалось очистить; проход повторит их в следующий раз
+	Failed int
+	// Skipped - очистку выполняет другой экземпляр фабрики
+	Skipped bool
+}
+
+// DeletionService удаляет плюмбусы: мягкое удаление с окном восстановления,
+// фоновая очистка по истечении окна и безвозвратное удаление администратором
+type DeletionService struct {
+	userService   *UserService
+	eventsService *EventsService
+	logger        *logrus.Logger
+
+	// RestoreWindow - сколько мягко удаленный плюмбус можно восстановить
+	RestoreWindow time.Duration
+	// Generation отменяет генерацию удаляемого плюмбуса. nil - генерация не отменяется.
+	Generation *GenerationService
+}
+
+func NewDeletionService(us *UserService, es *EventsService, restoreWindow time.Duration) *DeletionService {
+	return &DeletionService{
+		userService:   us,
+		eventsService: es,
+		logger:        logger.For("deletion"),
+		RestoreWindow: restoreWindow,
+	}
+}
+
+// Delete мягко удаляет плюмбус и возвращает время, до которого его можно восстановить.
+// Незавершенная генерация плюмбуса отменяется.
+func (s *DeletionService) Delete(ctx context.Context, plumbus *models.Plumbus) (time.Time, error) {
+	if err := s.cancel(ctx, plumbus); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := s.userService.DeletePlumbus(plumbus.ID); err != nil {
+		return time.Time{}, err
+	}
+
+	restoreUntil := time.Now().Add(s.RestoreWindow)
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id":    plumbus.ID,
+		"user_id":       plumbus.UserID,
+		"restore_until": restoreUntil,
+	}).Info("Plumbus soft-deleted")
+	return restoreUntil, nil
+}
+
+// Restore восстанавливает мягко удаленный плюмбус, пока не истекло окно восстановления
+func (s *DeletionService) Restore(ctx context.Context, plumbus *models.Plumbus) error {
+	if !plumbus.DeletedAt.Valid {
+		return ErrPlumbusNotDeleted
+	}
+	if time.Since(plumbus.DeletedAt.Time) > s.RestoreWindow {
+		return ErrRestoreWindowExpired
+	}
+
+	if err := s.userService.RestorePlumbus(plumbus.ID); err != nil {
+		return err
+	}
+
+	s.logger.WithContext(ctx).WithField("plumbus_id", plumbus.ID).Info("Plumbus restored")
+	return nil
+}
+
+// HardDelete безвозвратно удаляет плюмбус вместе с изображением. Незавершенная
+// генерация плюмбуса отменяется.
+func (s *DeletionService) HardDelete(ctx context.Context, plumbus *models.Plumbus) error {
+	if err := s.cancel(ctx, plumbus); err != nil {
+		return err
+	}
+	if err := s.userService.WithContext(ctx).HardDeletePlumbus(plumbus.ID); err != nil {
+		return fmt.Errorf("failed to delete plumbus: %w", err)
+	}
+	s.deleted(ctx, plumbus, DeleteReasonHardDelete)
+	return nil
+}
+
+// Purge безвозвратно удаляет плюмбусы, у которых истекло окно восстановления. Плюмбус,
+// который не удалось удалить, не мешает очистке остальных: ошибки собираются в одну.
+// Проход выполняется под блокировкой в базе данных, поэтому одновременно его выполняет
+// только один экземпляр.
+func (s *DeletionService) Purge(ctx context.Context, now time.Time) (PurgeResult, error) {
+	var purged []models.Plumbus
+	var errs []error
+	locked, err := s.userService.WithContext(ctx).WithAdvisoryLock(purgerLockKey, func(users *UserService) error {
+		plumbuses, err := users.GetPlumbusesDeletedBefore(now.Add(-s.RestoreWindow))
+		if err != nil {
+			return err
+		}
+		for i := range plumbuses {
+			p := &plumbuses[i]
+			if err := users.HardDeletePlumbus(p.ID); err != nil {
+				s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", p.ID).Warn("Failed to purge deleted plumbus")
+				errs = append(errs, fmt.Errorf("plumbus %s: %w", p.ID, err))
+				continue
+			}
+			purged = append(purged, *p)
+		}
+		return nil
+	})
+	if err != nil {
+		return PurgeResult{}, err
+	}
+	if !locked {
+		s.logger.WithContext(ctx).Debug("Deleted plumbuses are being purged by another instance")
+		return PurgeResult{Skipped: true}, nil
+	}
+
+	// Файлы и события - только после фиксации транзакции
+	for i := range purged {
+		s.deleted(ctx, &purged[i], DeleteReasonPurged)
+	}
+	result := PurgeResult{Purged: len(purged), Failed: len(errs)}
+	if len(errs) > 0 {
+		return result, fmt.Errorf("failed to purge %d deleted plumbuses: %w", len(errs), errors.Join(errs...))
+	}
+	return result, nil
+}
+
+// StartPurger периодически очищает удаленные плюмбусы, см. RunPeriodically
+func (s *DeletionService) StartPurger(ctx context.Context, interval time.Duration, onError func(error)) <-chan struct{} {
+	return RunPeriodically(ctx, interval, func(ctx context.Context) error {
+		_, err := s.Purge(ctx, time.Now())
+		return err
+	}, onError)
+}
+
+// cancel отменяет незавершенную генерацию удаляемого плюмбуса
+func (s *DeletionService) cancel(ctx context.Context, plumbus *models.Plumbus) error {
+	running := plumbus.Status == models.StatusPending || plumbus.Status == models.StatusGenerating
+	if !running || s.Generation == nil {
+		return nil
+	}
+	if err := s.Generation.Cancel(ctx, plumbus); err != nil && !errors.Is(err, ErrPlumbusNotRunning) {
+		return fmt.Errorf("failed to cancel plumbus generation: %w", err)
+	}
+	return nil
+}
+
+// deleted удаляет изображение уже удаленного из базы плюмбуса и сообщает о нем событием
+// plumbus.deleted. Файл, который не удалось удалить, остается сиротой для сборщика изображений.
+func (s *DeletionService) deleted(ctx context.Context, plumbus *models.Plumbus, reason string) {
+	if plumbus.ImagePath != nil && *plumbus.ImagePath != "" {
+		if err := os.Remove(*plumbus.ImagePath); err != nil && !os.IsNotExist(err) {
+			s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbus.ID).Warn("Failed to remove deleted plumbus image")
+		}
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id": plumbus.ID,
+		"user_id":    plumbus.UserID,
+		"reason":     reason,
+	}).Info("Plumbus deleted permanently")
+
+	if s.eventsService != nil {
+		if err := s.eventsService.PublishPlumbusDeleted(ctx, plumbus, reason); err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbus.ID).Warn("Failed to publish plumbus deleted event")
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/models"
+	"factory/internal/testutils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func setupDeletionService(t *testing.T) (*DeletionService, *UserService, *MockNATSConn, *models.User) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.PlumbusAttempt{}); err != nil {
+		t.Fatalf("Failed to migrate plumbus attempts table: %v", err)
+	}
+	user := createTestUser(t, db)
+	userService := NewUserService(db)
+
+	conn := &MockNATSConn{}
+	events := &EventsService{
+		conn:   conn,
+		config: &config.Config{NatsTopic: "test-topic", EventSource: "factory"},
+		logger: logrus.New(),
+	}
+	return NewDeletionService(userService, events, time.Hour), userService, conn, user
+}
+
+// createPlumbusWithImage создает плюмбус с файлом изображения во временном каталоге
+func createPlumbusWithImage(t *testing.T, us *UserService, user *models.User) (*models.Plumbus, string) {
+	t.Helper()
+	plumbus, err := us.CreatePlumbus(user.ID, models.PlumbusRequest{
+		Name: "Disposable", Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+	})
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+
+	imagePath := filepath.Join(t.TempDir(), plumbus.ID.String()+".png")
+	if err := os.WriteFile(imagePath, testutils.CreateTestPNGData(), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+	if err := us.UpdatePlumbusStatus(plumbus.ID, models.StatusCompleted, &imagePath, nil, nil, nil); err != nil {
+		t.Fatalf("UpdatePlumbusStatus() error = %v", err)
+	}
+	return plumbus, imagePath
+}
+
+func TestDeletionService_SoftDeleteAndRestore(t *testing.T) {
+	service, us, conn, user := setupDeletionService(t)
+	plumbus, imagePath := createPlumbusWithImage(t, us, user)
+
+	restoreUntil, err := service.Delete(context.Background(), plumbus)
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if until := time.Until(restoreUntil); until <= 0 || until > time.Hour {
+		t.Errorf("restore until = %v, want within the restore window", restoreUntil)
+	}
+
+	// Удаленный плюмбус скрыт из выборок, но файл изображения на месте
+	if _, err := us.GetPlumbus(plumbus.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetPlumbus() after delete error = %v, want not found", err)
+	}
+	plumbuses, _ := us.GetUserPlumbuses(user.ID)
+	if len(plumbuses) != 0 {
+		t.Errorf("GetUserPlumbuses() after delete = %d plumbuses, want 0", len(plumbuses))
+	}
+	if _, err := os.Stat(imagePath); err != nil {
+		t.Errorf("image removed on soft delete: %v", err)
+	}
+	if _, err := service.Delete(context.Background(), plumbus); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("second Delete() error = %v, want not found", err)
+	}
+
+	deleted, err := us.GetPlumbusIncludingDeleted(plumbus.ID)
+	if err != nil || !deleted.DeletedAt.Valid {
+		t.Fatalf("GetPlumbusIncludingDeleted() = %+v, %v, want deleted plumbus", deleted, err)
+	}
+	if err := service.Restore(context.Background(), deleted); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	restored, err := us.GetPlumbus(plumbus.ID)
+	if err != nil {
+		t.Fatalf("GetPlumbus() after restore error = %v", err)
+	}
+	if err := service.Restore(context.Background(), restored); !errors.Is(err, ErrPlumbusNotDeleted) {
+		t.Errorf("Restore() of live plumbus error = %v, want %v", err, ErrPlumbusNotDeleted)
+	}
+	if len(conn.PublishedMessages) != 0 {
+		t.Errorf("soft delete published %d events, want 0", len(conn.PublishedMessages))
+	}
+}
+
+func TestDeletionService_PurgeAfterRestoreWindow(t *testing.T) {
+	service, us, conn, user := setupDeletionService(t)
+	expired, expiredImage := createPlumbusWithImage(t, us, user)
+	recent, recentImage := createPlumbusWithImage(t, us, user)
+
+	service.Delete(context.Background(), expired)
+	service.Delete(context.Background(), recent)
+
+	// Сдвигаем "сейчас" так, чтобы окно истекло только у первого плюмбуса
+	deleted, _ := us.GetPlumbusIncludingDeleted(expired.ID)
+	if err := service.Restore(context.Background(), &models.Plumbus{ID: expired.ID, DeletedAt: gorm.DeletedAt{Time: deleted.DeletedAt.Time.Add(-2 * time.Hour), Valid: true}}); !errors.Is(err, ErrRestoreWindowExpired) {
+		t.Errorf("Restore() after window error = %v, want %v", err, ErrRestoreWindowExpired)
+	}
+	us.db.Unscoped().Model(&SQLitePlumbus{}).Where("id = ?", testutils.SQLiteUUID(expired.ID)).
+		Update("deleted_at", time.Now().Add(-2*time.Hour))
+
+	result, err := service.Purge(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if result.Purged != 1 || result.Failed != 0 {
+		t.Fatalf("Purge() = %+v, want 1 purged", result)
+	}
+
+	if _, err := us.GetPlumbusIncludingDeleted(expired.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("purged plumbus still exists: %v", err)
+	}
+	if _, err := os.Stat(expiredImage); !os.IsNotExist(err) {
+		t.Errorf("purged image still exists: %v", err)
+	}
+	if _, err := us.GetPlumbusIncludingDeleted(recent.ID); err != nil {
+		t.Errorf("plumbus within restore window was purged: %v", err)
+	}
+	if _, err := os.Stat(recentImage); err != nil {
+		t.Errorf("image within restore window was removed: %v", err)
+	}
+
+	if len(conn.PublishedMessages) != 1 {
+		t.Fatalf("published %d events, want 1", len(conn.PublishedMessages))
+	}
+	var event Event
+	if err := json.Unmarshal(conn.PublishedMessages[0].Data, &event); err != nil {
+		t.Fatalf("Invalid event: %v", err)
+	}
+	if event.Type != "plumbus.deleted" || event.Data["reason"] != DeleteReasonPurged || event.Data["plumbus_id"] != expired.ID.String() {
+		t.Errorf("event = %+v, want plumbus.deleted for %v", event, expired.ID)
+	}
+}
+
+func TestDeletionService_HardDelete(t *testing.T) {
+	service, us, conn, user := setupDeletionService(t)
+	plumbus, imagePath := createPlumbusWithImage(t, us, user)
+	plumbus, _ = us.GetPlumbus(plumbus.ID)
+	attempt := &models.PlumbusAttempt{PlumbusID: plumbus.ID, Attempt: 1, Status: models.StatusCompleted, StartedAt: time.Now(), FinishedAt: time.Now()}
+	if err := us.CreatePlumbusAttempt(attempt); err != nil {
+		t.Fatalf("CreatePlumbusAttempt() error = %v", err)
+	}
+
+	if err := service.HardDelete(context.Background(), plumbus); err != nil {
+		t.Fatalf("HardDelete() error = %v", err)
+	}
+	if _, err := us.GetPlumbusIncludingDeleted(plumbus.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("hard-deleted plumbus still exists: %v", err)
+	}
+	if _, err := os.Stat(imagePath); !os.IsNotExist(err) {
+		t.Errorf("hard-deleted image still exists: %v", err)
+	}
+	if len(conn.PublishedMessages) != 1 {
+		t.Errorf("published %d events, want 1", len(conn.PublishedMessages))
+	}
+	if attempts, _ := us.GetPlumbusAttempts(plumbus.ID); len(attempts) != 0 {
+		t.Errorf("hard-deleted plumbus left %d attempts", len(attempts))
+	}
+
+	// Отсутствующий файл изображения не мешает удалению
+	missing, _ := createPlumbusWithImage(t, us, user)
+	missing, _ = us.GetPlumbus(missing.ID)
+	os.Remove(*missing.ImagePath)
+	if err := service.HardDelete(context.Background(), missing); err != nil {
+		t.Errorf("HardDelete() without image file error = %v", err)
+	}
+}
+
+func TestDeletionService_PurgeContinuesPastFailures(t *testing.T) {
+	service, us, conn, user := setupDeletionService(t)
+	broken, brokenImage := createPlumbusWithImage(t, us, user)
+	expired, expiredImage := createPlumbusWithImage(t, us, user)
+
+	// Запись этого плюмбуса база удалить не даст
+	if err := us.db.Model(&SQLitePlumbus{}).Where("id = ?", testutils.SQLiteUUID(broken.ID)).Update("name", "Undeletable").Error; err != nil {
+		t.Fatalf("Failed to rename plumbus: %v", err)
+	}
+	trigger := `CREATE TRIGGER keep_undeletable BEFORE DELETE ON plumbus
+		WHEN OLD.name = 'Undeletable' BEGIN SELECT RAISE(ABORT, 'plumbus is locked'); END`
+	if err := us.db.Exec(trigger).Error; err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+
+	service.Delete(context.Background(), broken)
+	service.Delete(context.Background(), expired)
+
+	result, err := service.Purge(context.Background(), time.Now().Add(2*time.Hour))
+	if err == nil {
+		t.Error("Purge() error = nil, want failure for the broken plumbus")
+	}
+	if result.Purged != 1 || result.Failed != 1 {
+		t.Errorf("Purge() = %+v, want 1 purged and 1 failed", result)
+	}
+	if _, err := us.GetPlumbusIncludingDeleted(broken.ID); err != nil {
+		t.Errorf("failed plumbus was removed: %v", err)
+	}
+	if _, err := os.Stat(brokenImage); err != nil {
+		t.Errorf("image of the failed plumbus was removed: %v", err)
+	}
+	if _, err := us.GetPlumbusIncludingDeleted(expired.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("plumbus after the failure was not purged: %v", err)
+	}
+	if _, err := os.Stat(expiredImage); !os.IsNotExist(err) {
+		t.Errorf("purged image still exists: %v", err)
+	}
+	if len(conn.PublishedMessages) != 1 {
+		t.Errorf("published %d events, want 1", len(conn.PublishedMessages))
+	}
+}
+
+func TestDeletionService_DeleteCancelsGeneration(t *testing.T) {
+	generator, started, aborted := blockingServer(t)
+	generation, us, user := setupGenerationService(t, generator.URL, "")
+	service := NewDeletionService(us, nil, time.Hour)
+	service.Generation = generation
+
+	plumbus, err := generation.Start(context.Background(), user.ID, cancelRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, started, "generation request")
+
+	if _, err := service.Delete(context.Background(), plumbus); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	waitFor(t, aborted, "generation request to be aborted")
+
+	attempt := waitForAttempt(t, us, plumbus)
+	if attempt.Status != models.StatusCancelled {
+		t.Errorf("attempt status = %v, want %v", attempt.Status, models.StatusCancelled)
+	}
+	deleted, err := us.GetPlumbusIncludingDeleted(plumbus.ID)
+	if err != nil || !deleted.DeletedAt.Valid {
+		t.Errorf("GetPlumbusIncludingDeleted() = %+v, %v, want deleted plumbus", deleted, err)
+	}
+}
+
+func TestDeletionService_HardDeleteCancelsGeneration(t *testing.T) {
+	generator, started, aborted := blockingServer(t)
+	generation, us, user := setupGenerationService(t, generator.URL, "")
+	service := NewDeletionService(us, nil, time.Hour)
+	service.Generation = generation
+
+	plumbus, err := generation.Start(context.Background(), user.ID, cancelRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, started, "generation request")
+
+	if err := service.HardDelete(context.Background(), plumbus); err != nil {
+		t.Fatalf("HardDelete() error = %v", err)
+	}
+	waitFor(t, aborted, "generation request to be aborted")
+
+	if _, err := us.GetPlumbusIncludingDeleted(plumbus.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetPlumbusIncludingDeleted() error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+}
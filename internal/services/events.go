@@ -1,22 +1,31 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"factory/internal/config"
 	"factory/internal/logger"
+	"factory/internal/metrics"
 	"factory/internal/models"
+	"factory/internal/tracing"
 
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // NATSConn - интерфейс для NATS соединения
 type NATSConn interface {
-	Publish(subject string, data []byte) error
+	PublishMsg(msg *nats.Msg) error
+	IsConnected() bool
 	Close()
 }
 
@@ -25,11 +34,19 @@ type natsConnWrapper struct {
 	conn *nats.Conn
 }
 
-func (w *natsConnWrapper) Publish(subject string, data []byte) error {
-	return w.conn.Publish(subject, data)
+func (w *natsConnWrapper) PublishMsg(msg *nats.Msg) error {
+	return w.conn.PublishMsg(msg)
 }
 
+func (w *natsConnWrapper) IsConnected() bool {
+	return w.conn.IsConnected()
+}
+
+// Close отправляет накопленные в буфере события и закрывает соединение
 func (w *natsConnWrapper) Close() {
+	if err := w.conn.FlushTimeout(5 * time.Second); err != nil {
+		logger.For("events").WithError(err).Warn("Failed to flush NATS connection")
+	}
 	w.conn.Close()
 }
 
@@ -39,9 +56,8 @@ type EventsService struct {
 	logger *logrus.Logger
 }
 
-// PlumbusCreatedEvent представляет событие создания плюмбуса
-// в формате совместимом с events-audit
-type PlumbusCreatedEvent struct {
+// Event представляет событие в формате, совместимом с events-audit
+type Event struct {
 	ID        string                 `json:"id"`
 	Type      string                 `json:"type"`
 	Source    string                 `json:"source"`
@@ -49,8 +65,19 @@ type PlumbusCreatedEvent struct {
 	Data      map[string]interface{} `json:"data"`
 }
 
+// PlumbusCreatedEvent представляет событие создания плюмбуса
+type PlumbusCreatedEvent = Event
+
+// Причины удаления плюмбуса в событии plumbus.deleted
+const (
+	// DeleteReasonPurged - истекло окно восстановления после мягкого удаления
+	DeleteReasonPurged = "purged"
+	// DeleteReasonHardDelete - администратор удалил плюмбус безвозвратно
+	DeleteReasonHardDelete = "hard_delete"
+)
+
 func NewEventsService(cfg *config.Config) (*EventsService, error) {
-	logger := logger.Init()
+	logger := logger.For("events")
 
 	// Подключаемся к NATS
 	conn, err := nats.Connect(cfg.NatsURL)
@@ -75,8 +102,16 @@ func (s *EventsService) Close() {
 	}
 }
 
+// Ping проверяет, что соединение с NATS установлено
+func (s *EventsService) Ping(ctx context.Context) error {
+	if s == nil || s.conn == nil || !s.conn.IsConnected() {
+		return errors.New("NATS is not connected")
+	}
+	return nil
+}
+
 // PublishPlumbusCreated отправляет событие о создании плюмбуса в NATS
-func (s *EventsService) PublishPlumbusCreated(user *models.User, plumbus *models.Plumbus, request models.PlumbusRequest) error {
+func (s *EventsService) PublishPlumbusCreated(ctx context.Context, user *models.User, plumbus *models.Plumbus, request models.PlumbusRequest) error {
 	event := PlumbusCreatedEvent{
 		ID:        uuid.New().String(),
 		Type:      "plumbus.created",
@@ -92,19 +127,11 @@ func (s *EventsService) PublishPlumbusCreated(user *models.User, plumbus *models
 		},
 	}
 
-	// Сериализуем событие в JSON
-	eventData, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
-	}
-
-	// Отправляем событие в NATS
-	err = s.conn.Publish(s.config.NatsTopic, eventData)
-	if err != nil {
-		return fmt.Errorf("failed to publish event: %w", err)
+	if err := s.publish(ctx, event); err != nil {
+		return err
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"event_id":   event.ID,
 		"event_type": event.Type,
 		"source":     event.Source,
@@ -117,3 +144,125 @@ func (s *EventsService) PublishPlumbusCreated(user *models.User, plumbus *models
 
 	return nil
 }
+
+// PublishPlumbusDeleted отправляет событие о безвозвратном удалении плюмбуса в NATS
+func (s *EventsService) PublishPlumbusDeleted(ctx context.Context, plumbus *models.Plumbus, reason string) error {
+	event := Event{
+		ID:        uuid.New().String(),
+		Type:      "plumbus.deleted",
+		Source:    s.config.EventSource,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"plumbus_id": plumbus.ID,
+			"user_id":    plumbus.UserID,
+			"name":       plumbus.Name,
+			"is_rare":    plumbus.IsRare,
+			"reason":     reason,
+		},
+	}
+	if plumbus.DeletedAt.Valid {
+		event.Data["deleted_at"] = plumbus.DeletedAt.Time
+	}
+
+	if err := s.publish(ctx, event); err != nil {
+		return err
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"plumbus_id": plumbus.ID,
+		"user_id":    plumbus.UserID,
+		"reason":     reason,
+		"topic":      s.config.NatsTopic,
+	}).Info("Published plumbus.deleted event")
+
+	return nil
+}
+
+// PublishPlumbusReaped отправляет в NATS событие о зависшем плюмбусе, который сборщик
+// отправил на повтор (plumbus.requeued) или завершил ошибкой (plumbus.failed)
+func (s *EventsService) PublishPlumbusReaped(ctx context.Context, plumbus *models.Plumbus, action string, stuckSince time.Time) error {
+	event := Event{
+		ID:        uuid.New().String(),
+		Type:      "plumbus." + action,
+		Source:    s.config.EventSource,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"plumbus_id":  plumbus.ID,
+			"user_id":     plumbus.UserID,
+			"name":        plumbus.Name,
+			"attempts":    plumbus.Attempts,
+			"stuck_since": stuckSince,
+		},
+	}
+	if plumbus.ErrorMsg != nil {
+		event.Data["error"] = *plumbus.ErrorMsg
+	}
+
+	if err := s.publish(ctx, event); err != nil {
+		return err
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"plumbus_id": plumbus.ID,
+		"user_id":    plumbus.UserID,
+		"topic":      s.config.NatsTopic,
+	}).Info("Published stuck plumbus event")
+
+	return nil
+}
+
+// natsHeaderCarrier передает контекст трассировки в заголовках NATS. В отличие от
+// HTTP заголовков, ключи NATS чувствительны к регистру и сохраняются как есть.
+type natsHeaderCarrier nats.Header
+
+func (c natsHeaderCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+func (c natsHeaderCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+func (c natsHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// publish сериализует событие и отправляет его в топик NATS. Контекст трассировки
+// передается в заголовках сообщения, чтобы подписчики продолжили трейс.
+func (s *EventsService) publish(ctx context.Context, event Event) error {
+	ctx, span := tracing.Tracer().Start(ctx, "nats.publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", s.config.NatsTopic),
+			attribute.String("messaging.message.id", event.ID),
+			attribute.String("event.type", event.Type),
+		))
+	defer span.End()
+
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := nats.NewMsg(s.config.NatsTopic)
+	msg.Data = eventData
+	otel.GetTextMapPropagator().Inject(ctx, natsHeaderCarrier(msg.Header))
+
+	if err := s.conn.PublishMsg(msg); err != nil {
+		metrics.NATSPublishFailures.WithLabelValues(event.Type).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
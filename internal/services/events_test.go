@@ -1,14 +1,19 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
 	"factory/internal/config"
 	"factory/internal/models"
+	"factory/internal/testutils"
+	"factory/internal/tracing"
 
 	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
 	"github.com/sirupsen/logrus"
 )
 
@@ -23,16 +28,18 @@ type MockNATSConn struct {
 type MockMessage struct {
 	Subject string
 	Data    []byte
+	Header  nats.Header
 }
 
-func (m *MockNATSConn) Publish(subject string, data []byte) error {
+func (m *MockNATSConn) PublishMsg(msg *nats.Msg) error {
 	if m.ShouldFailPublish {
 		return &mockNATSError{msg: "failed to publish"}
 	}
 
 	m.PublishedMessages = append(m.PublishedMessages, MockMessage{
-		Subject: subject,
-		Data:    data,
+		Subject: msg.Subject,
+		Data:    msg.Data,
+		Header:  msg.Header,
 	})
 	return nil
 }
@@ -40,6 +47,10 @@ func (m *MockNATSConn) Publish(subject string, data []byte) error {
 // Убеждаемся, что MockNATSConn реализует интерфейс NATSConn
 var _ NATSConn = (*MockNATSConn)(nil)
 
+func (m *MockNATSConn) IsConnected() bool {
+	return !m.IsClosed
+}
+
 func (m *MockNATSConn) Close() {
 	m.IsClosed = true
 }
@@ -93,7 +104,7 @@ func TestEventsService_PublishPlumbusCreated_Success(t *testing.T) {
 	}
 
 	// Публикуем событие
-	err := service.PublishPlumbusCreated(user, plumbus, request)
+	err := service.PublishPlumbusCreated(context.Background(), user, plumbus, request)
 
 	// Проверяем что ошибки нет
 	if err != nil {
@@ -209,7 +220,7 @@ func TestEventsService_PublishPlumbusCreated_PublishError(t *testing.T) {
 	}
 
 	// Публикуем событие
-	err := service.PublishPlumbusCreated(user, plumbus, request)
+	err := service.PublishPlumbusCreated(context.Background(), user, plumbus, request)
 
 	// Проверяем что вернулась ошибка
 	if err == nil {
@@ -290,3 +301,25 @@ func TestPlumbusCreatedEvent_Structure(t *testing.T) {
 		t.Errorf("Unmarshaled Source = %v, want %v", unmarshaled.Source, event.Source)
 	}
 }
+
+func TestEventsService_PublishPropagatesTraceContext(t *testing.T) {
+	testutils.SetupTracing(t)
+	mockConn := &MockNATSConn{}
+	service := &EventsService{
+		conn:   mockConn,
+		config: &config.Config{NatsTopic: "test-topic", EventSource: "test-factory"},
+		logger: logrus.New(),
+	}
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "request")
+	defer span.End()
+	plumbus := &models.Plumbus{ID: uuid.New(), UserID: uuid.New(), Name: "Traced"}
+	if err := service.PublishPlumbusDeleted(ctx, plumbus, DeleteReasonPurged); err != nil {
+		t.Fatalf("PublishPlumbusDeleted() error = %v", err)
+	}
+
+	header := mockConn.PublishedMessages[0].Header.Get("traceparent")
+	if !strings.Contains(header, span.SpanContext().TraceID().String()) {
+		t.Errorf("traceparent = %q, want trace %s", header, span.SpanContext().TraceID())
+	}
+}
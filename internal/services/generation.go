@@ -0,0 +1,486 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"factory/internal/logger"
+	"factory/internal/metrics"
+	"factory/internal/models"
+	"factory/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxGenerationAttempts - сколько раз по умолчанию можно запустить генерацию одного плюмбуса
+const DefaultMaxGenerationAttempts = 3
+
+var (
+	// ErrPlumbusNotFailed возвращается при повторе генерации плюмбуса, который не завершился ошибкой
+	ErrPlumbusNotFailed = errors.New("plumbus generation has not failed")
+	// ErrRetryLimitReached возвращается, если все попытки генерации уже использованы
+	ErrRetryLimitReached = errors.New("plumbus retry limit reached")
+	// ErrPlumbusNotRunning возвращается при отмене плюмбуса, генерация которого уже завершилась
+	ErrPlumbusNotRunning = errors.New("plumbus generation is not running")
+
+	// errShutdown - причина отмены генераций, не успевших завершиться до остановки фабрики
+	errShutdown = errors.New("factory is shutting down")
+)
+
+// GenerationService создает плюмбусы и проводит их через генерацию и подпись.
+// Используется и HTTP обработчиками, и gRPC сервером.
+type GenerationService struct {
+	userService      *UserService
+	plumbusService   *PlumbusService
+	signatureService *SignatureService
+	eventsService    *EventsService
+	webhookService   *WebhookService
+	logger           *logrus.Logger
+
+	// jobs - функции отмены генераций, выполняющихся в этом процессе. wg учитывает
+	// генерации и публикации событий, которые нужно дождаться при остановке.
+	mu      sync.Mutex
+	jobs    map[uuid.UUID]context.CancelCauseFunc
+	wg      sync.WaitGroup
+	closing bool
+
+	// MaxAttempts - предельное число попыток генерации одного плюмбуса, включая первую
+	MaxAttempts int
+	// RateLimiter ограничивает частоту запусков и повторов генерации на пользователя.
+	// nil - без ограничения.
+	RateLimiter *RateLimiter
+	// StuckAfter - сколько плюмбус может ждать или генерироваться без изменений, прежде
+	// чем Reap сочтет его зависшим
+	StuckAfter time.Duration
+}
+
+func NewGenerationService(us *UserService, ps *PlumbusService, ss *SignatureService, es *EventsService, ws *WebhookService) *GenerationService {
+	return &GenerationService{
+		userService:      us,
+		plumbusService:   ps,
+		signatureService: ss,
+		eventsService:    es,
+		webhookService:   ws,
+		logger:           logger.For("generation"),
+		jobs:             make(map[uuid.UUID]context.CancelCauseFunc),
+		MaxAttempts:      DefaultMaxGenerationAttempts,
+		StuckAfter:       DefaultStuckAfter,
+	}
+}
+
+// Start создает запись плюмбуса, публикует событие и запускает генерацию в фоне.
+// Генерация продолжает трейс из ctx, но не отменяется вместе с ним.
+func (s *GenerationService) Start(ctx context.Context, userID uuid.UUID, req models.PlumbusRequest) (*models.Plumbus, error) {
+	if err := s.allow(userID); err != nil {
+		return nil, err
+	}
+	users := s.userService.WithContext(ctx)
+
+	// Создаем запись плюмбуса в БД
+	plumbus, err := users.CreatePlumbus(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	ctx = logger.WithPlumbusID(ctx, plumbus.ID.String())
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id": plumbus.ID,
+		"user_id":    userID,
+		"is_rare":    plumbus.IsRare,
+		"name":       req.Name,
+	}).Info("Plumbus created successfully")
+	if plumbus.IsRare {
+		metrics.RarePlumbuses.Inc()
+	}
+
+	// Отправляем событие о создании плюмбуса в NATS
+	if s.eventsService != nil {
+		// Получаем информацию о пользователе для события
+		user, err := users.GetUserByID(userID)
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("user_id", userID).Warn("Failed to get user info for event")
+		} else {
+			// Отправляем событие в горутине чтобы не блокировать основной поток
+			s.background(func() {
+				if err := s.eventsService.PublishPlumbusCreated(context.WithoutCancel(ctx), user, plumbus, req); err != nil {
+					s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbus.ID).Warn("Failed to publish plumbus created event")
+				}
+			})
+		}
+	}
+
+	// Запускаем генерацию в горутине
+	s.run(ctx, plumbus)
+
+	return plumbus, nil
+}
+
+// allow проверяет лимит частоты запусков генерации пользователя
+func (s *GenerationService) allow(userID uuid.UUID) error {
+	if s.RateLimiter == nil {
+		return nil
+	}
+	return s.RateLimiter.Allow(userID)
+}
+
+// Retry повторно запускает генерацию неудавшегося плюмбуса с сохраненными параметрами
+func (s *GenerationService) Retry(ctx context.Context, plumbus *models.Plumbus) (*models.Plumbus, error) {
+	if plumbus.Status != models.StatusFailed {
+		return nil, ErrPlumbusNotFailed
+	}
+	if plumbus.Attempts >= s.MaxAttempts {
+		return nil, ErrRetryLimitReached
+	}
+	if err := s.allow(plumbus.UserID); err != nil {
+		return nil, err
+	}
+
+	// Условное обновление защищает от двух одновременных повторов
+	users := s.userService.WithContext(ctx)
+	ok, err := users.RetryPlumbus(plumbus.ID, s.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPlumbusNotFailed
+	}
+
+	retried, err := users.GetPlumbus(plumbus.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id": retried.ID,
+		"user_id":    retried.UserID,
+		"attempt":    retried.Attempts,
+	}).Info("Retrying plumbus generation")
+
+	s.run(ctx, retried)
+
+	return retried, nil
+}
+
+// Cancel останавливает генерацию плюмбуса: прерывает запросы к сервисам генерации
+// и подписи и переводит плюмбус в статус cancelled, который генерация уже не перезапишет
+func (s *GenerationService) Cancel(ctx context.Context, plumbus *models.Plumbus) error {
+	ok, err := s.userService.WithContext(ctx).CancelPlumbus(plumbus.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPlumbusNotRunning
+	}
+
+	// Генерация может выполняться в другом экземпляре фабрики - тогда ее остановит
+	// отмененный статус, а не контекст
+	s.mu.Lock()
+	cancel, running := s.jobs[plumbus.ID]
+	s.mu.Unlock()
+	if running {
+		cancel(nil)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id": plumbus.ID,
+		"user_id":    plumbus.UserID,
+		"running":    running,
+	}).Info("Plumbus generation cancelled")
+	return nil
+}
+
+// Resume продолжает генерации, прерванные предыдущей остановкой фабрики.
+// Каждый плюмбус забирает только один экземпляр фабрики.
+func (s *GenerationService) Resume() (int, error) {
+	plumbuses, err := s.userService.GetInterruptedPlumbuses()
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for i := range plumbuses {
+		ctx := logger.WithPlumbusID(context.Background(), plumbuses[i].ID.String())
+		claimed, err := s.userService.ClaimInterruptedPlumbus(plumbuses[i].ID)
+		if err != nil {
+			return resumed, err
+		}
+		if !claimed {
+			continue
+		}
+
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"plumbus_id": plumbuses[i].ID,
+			"attempt":    plumbuses[i].Attempts,
+		}).Info("Resuming interrupted plumbus generation")
+		s.run(ctx, &plumbuses[i])
+		resumed++
+	}
+	return resumed, nil
+}
+
+// Shutdown перестает запускать новые генерации и ждет завершения текущих и доставки их
+// webhook до дедлайна ctx. Не успевшие завершиться генерации прерываются и продолжатся
+// после перезапуска (см. Resume).
+func (s *GenerationService) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	running := len(s.jobs)
+	s.mu.Unlock()
+
+	s.logger.WithContext(ctx).WithField("jobs", running).Info("Waiting for plumbus generations to finish")
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return s.shutdownWebhooks(ctx)
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	interrupted := len(s.jobs)
+	for _, cancel := range s.jobs {
+		cancel(errShutdown)
+	}
+	s.mu.Unlock()
+
+	s.logger.WithContext(ctx).WithField("jobs", interrupted).Warn("Shutdown deadline exceeded, interrupting plumbus generations")
+	<-done
+	s.shutdownWebhooks(ctx)
+	return ctx.Err()
+}
+
+// shutdownWebhooks дожидается доставки webhook о генерациях, завершившихся до остановки
+func (s *GenerationService) shutdownWebhooks(ctx context.Context) error {
+	if s.webhookService == nil {
+		return nil
+	}
+	return s.webhookService.Shutdown(ctx)
+}
+
+// Attempts возвращает историю попыток генерации плюмбуса
+func (s *GenerationService) Attempts(plumbus *models.Plumbus) ([]models.PlumbusAttempt, error) {
+	return s.userService.GetPlumbusAttempts(plumbus.ID)
+}
+
+// Verify проверяет подпись изображения плюмбуса в sig-store
+func (s *GenerationService) Verify(ctx context.Context, plumbus *models.Plumbus) (bool, error) {
+	if plumbus.ImagePath == nil || plumbus.Signature == nil {
+		return false, nil
+	}
+	return s.signatureService.VerifySignature(ctx, *plumbus.ImagePath, *plumbus.Signature)
+}
+
+// generationRequest собирает запрос к сервису генерации из сохраненных параметров плюмбуса
+func generationRequest(plumbus *models.Plumbus) models.PlumbusGenerationRequest {
+	return models.PlumbusGenerationRequest{
+		Size:     plumbus.Size,
+		Color:    plumbus.Color,
+		Shape:    plumbus.Shape,
+		Weight:   plumbus.Weight,
+		Wrapping: plumbus.Wrapping,
+	}
+}
+
+// run регистрирует генерацию плюмбуса, чтобы ее можно было отменить, и запускает ее в фоне.
+// Во время остановки генерация не запускается, а откладывается до перезапуска.
+func (s *GenerationService) run(parent context.Context, plumbus *models.Plumbus) {
+	parent = logger.WithPlumbusID(parent, plumbus.ID.String())
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(parent))
+
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		cancel(errShutdown)
+		s.interrupt(ctx, plumbus.ID)
+		return
+	}
+	s.jobs[plumbus.ID] = cancel
+	s.wg.Add(1)
+	s.mu.Unlock()
+	metrics.GenerationQueueDepth.Inc()
+
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.jobs, plumbus.ID)
+			s.mu.Unlock()
+			cancel(nil)
+			metrics.GenerationQueueDepth.Dec()
+		}()
+		s.generate(ctx, plumbus.ID, plumbus.Attempts, generationRequest(plumbus))
+	}()
+}
+
+// background выполняет fn в фоне так, чтобы остановка фабрики дождалась ее завершения.
+// Во время остановки fn выполняется синхронно.
+func (s *GenerationService) background(fn func()) {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		fn()
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		fn()
+	}()
+}
+
+// interrupt помечает плюмбус для продолжения генерации после перезапуска
+func (s *GenerationService) interrupt(ctx context.Context, plumbusID uuid.UUID) {
+	if err := s.userService.InterruptPlumbus(plumbusID); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbusID).Error("Failed to mark plumbus generation as interrupted")
+		return
+	}
+	s.logger.WithContext(ctx).WithField("plumbus_id", plumbusID).Warn("Plumbus generation interrupted by shutdown")
+}
+
+func (s *GenerationService) generate(ctx context.Context, plumbusID uuid.UUID, attempt int, req models.PlumbusGenerationRequest) {
+	ctx, span := tracing.Tracer().Start(ctx, "plumbus.generate", trace.WithAttributes(
+		attribute.String("plumbus.id", plumbusID.String()),
+		attribute.Int("plumbus.attempt", attempt),
+	))
+	defer span.End()
+
+	// Запросы к БД не отменяются вместе с генерацией: итог нужно записать и после отмены
+	users := s.userService.WithContext(context.WithoutCancel(ctx))
+
+	// Записываем итог попытки в историю и сообщаем о нем в webhook пользователя
+	defer s.finish(ctx, users, plumbusID, attempt, time.Now())
+
+	// Обновляем статус на "generating"
+	users.UpdatePlumbusStatus(plumbusID, models.StatusGenerating, nil, nil, nil, nil)
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id": plumbusID,
+		"attempt":    attempt,
+		"request":    req,
+	}).Info("Starting plumbus generation")
+
+	// Генерируем плюмбус
+	imagePath, err := s.plumbusService.GeneratePlumbus(ctx, req)
+	if ctx.Err() != nil {
+		// Статус уже cancelled, готовое изображение больше не нужно
+		s.abort(ctx, plumbusID, imagePath)
+		return
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbusID).Error("Failed to generate plumbus")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		errorMsg := err.Error()
+		users.UpdatePlumbusStatus(plumbusID, models.StatusFailed, nil, &errorMsg, nil, nil)
+		return
+	}
+
+	// Подписываем изображение плюмбуса
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id": plumbusID,
+		"image_path": imagePath,
+	}).Info("Signing plumbus image")
+
+	signatureResponse, err := s.signatureService.SignFile(ctx, imagePath)
+	if ctx.Err() != nil {
+		s.abort(ctx, plumbusID, imagePath)
+		return
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbusID).Error("Failed to sign plumbus image")
+		// Не считаем это критической ошибкой, продолжаем без подписи
+		span.RecordError(err)
+		users.UpdatePlumbusStatus(plumbusID, models.StatusCompleted, &imagePath, nil, nil, nil)
+		return
+	}
+
+	signaturePreview := signatureResponse.Signature
+	if len(signaturePreview) > 20 {
+		signaturePreview = signaturePreview[:20] + "..."
+	}
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id":    plumbusID,
+		"signature":     signaturePreview,
+		"serial_number": signatureResponse.SerialNumber,
+	}).Info("Plumbus signed successfully")
+
+	// Обновляем статус на "completed" с подписью
+	users.UpdatePlumbusStatus(plumbusID, models.StatusCompleted, &imagePath, nil,
+		&signatureResponse.Signature, &signatureResponse.CreatedAt)
+}
+
+// abort обрабатывает отмену генерации: удаляет уже полученное изображение и, если
+// генерацию прервала остановка фабрики, откладывает ее до перезапуска
+func (s *GenerationService) abort(ctx context.Context, plumbusID uuid.UUID, imagePath string) {
+	if errors.Is(context.Cause(ctx), errShutdown) {
+		s.interrupt(ctx, plumbusID)
+	} else {
+		s.logger.WithContext(ctx).WithField("plumbus_id", plumbusID).Info("Plumbus generation aborted")
+	}
+
+	if imagePath == "" {
+		return
+	}
+	if err := os.Remove(imagePath); err != nil && !os.IsNotExist(err) {
+		s.logger.WithContext(ctx).WithError(err).WithField("image_path", imagePath).Warn("Failed to remove cancelled plumbus image")
+	}
+}
+
+// finish сохраняет итог попытки генерации и доставляет статус плюмбуса в webhook владельца
+func (s *GenerationService) finish(ctx context.Context, users *UserService, plumbusID uuid.UUID, attempt int, startedAt time.Time) {
+	// Плюмбус могли удалить во время генерации: попытка все равно попадает в историю
+	plumbus, err := users.GetPlumbusIncludingDeleted(plumbusID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.WithContext(ctx).WithField("plumbus_id", plumbusID).Info("Plumbus deleted permanently during generation")
+		return
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbusID).Warn("Failed to load generated plumbus")
+		return
+	}
+	// Прерванная попытка будет продолжена после перезапуска под тем же номером
+	if plumbus.Interrupted {
+		metrics.GenerationsTotal.WithLabelValues("interrupted").Inc()
+		return
+	}
+	metrics.GenerationsTotal.WithLabelValues(string(plumbus.Status)).Inc()
+	metrics.GenerationDuration.WithLabelValues(string(plumbus.Status)).Observe(time.Since(startedAt).Seconds())
+
+	record := &models.PlumbusAttempt{
+		PlumbusID:  plumbusID,
+		Attempt:    attempt,
+		Status:     plumbus.Status,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	}
+	if plumbus.ErrorMsg != nil {
+		record.Error = *plumbus.ErrorMsg
+	}
+	if err := users.CreatePlumbusAttempt(record); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbusID).Warn("Failed to record plumbus generation attempt")
+	}
+
+	// Владелец удалил плюмбус и больше не ждет итога его генерации
+	if plumbus.DeletedAt.Valid {
+		s.logger.WithContext(ctx).WithField("plumbus_id", plumbusID).Info("Plumbus deleted during generation, webhook not sent")
+		return
+	}
+	if s.webhookService != nil {
+		s.webhookService.Dispatch(ctx, plumbus)
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/metrics"
+	"factory/internal/models"
+	"factory/internal/testutils"
+	"factory/internal/tracing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// blockingServer отвечает только после отмены запроса клиентом и сообщает о начале и отмене запроса
+func blockingServer(t *testing.T) (*httptest.Server, chan struct{}, chan struct{}) {
+	started := make(chan struct{}, 1)
+	aborted := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Обрыв соединения сервер замечает только после чтения тела запроса
+		io.Copy(io.Discard, r.Body)
+		started <- struct{}{}
+		<-r.Context().Done()
+		aborted <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return server, started, aborted
+}
+
+func setupGenerationService(t *testing.T, generatorURL, sigStoreURL string) (*GenerationService, *UserService, *models.User) {
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change working directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.PlumbusAttempt{}); err != nil {
+		t.Fatalf("Failed to migrate plumbus attempts table: %v", err)
+	}
+	user := createTestUser(t, db)
+	us := NewUserService(db)
+
+	cfg := &config.Config{PlumbusServiceURL: generatorURL, SigStoreURL: sigStoreURL}
+	return NewGenerationService(us, NewPlumbusService(cfg), NewSignatureService(cfg), nil, nil), us, user
+}
+
+// waitForAttempt ждет записи о завершении попытки генерации
+func waitForAttempt(t *testing.T, us *UserService, plumbus *models.Plumbus) models.PlumbusAttempt {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		attempts, _ := us.GetPlumbusAttempts(plumbus.ID)
+		if len(attempts) > 0 {
+			return attempts[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Generation attempt was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitFor(t *testing.T, ch chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s", what)
+	}
+}
+
+var cancelRequest = models.PlumbusRequest{
+	Name: "Doomed", Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+}
+
+func TestGenerationService_CancelAbortsGeneration(t *testing.T) {
+	generator, started, aborted := blockingServer(t)
+	service, us, user := setupGenerationService(t, generator.URL, "")
+	cancelled := testutil.ToFloat64(metrics.GenerationsTotal.WithLabelValues(string(models.StatusCancelled)))
+
+	plumbus, err := service.Start(context.Background(), user.ID, cancelRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, started, "generation request")
+
+	if err := service.Cancel(context.Background(), plumbus); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	waitFor(t, aborted, "generation request to be aborted")
+
+	attempt := waitForAttempt(t, us, plumbus)
+	if attempt.Status != models.StatusCancelled {
+		t.Errorf("attempt status = %v, want %v", attempt.Status, models.StatusCancelled)
+	}
+	if got := testutil.ToFloat64(metrics.GenerationsTotal.WithLabelValues(string(models.StatusCancelled))); got != cancelled+1 {
+		t.Errorf("cancelled generations = %v, want %v", got, cancelled+1)
+	}
+
+	// Поздние обновления статуса не перезаписывают отмену
+	us.UpdatePlumbusStatus(plumbus.ID, models.StatusFailed, nil, nil, nil, nil)
+	stored, _ := us.GetPlumbus(plumbus.ID)
+	if stored.Status != models.StatusCancelled {
+		t.Errorf("status = %v, want %v", stored.Status, models.StatusCancelled)
+	}
+
+	if err := service.Cancel(context.Background(), stored); !errors.Is(err, ErrPlumbusNotRunning) {
+		t.Errorf("second Cancel() error = %v, want %v", err, ErrPlumbusNotRunning)
+	}
+	if _, err := service.Retry(context.Background(), stored); !errors.Is(err, ErrPlumbusNotFailed) {
+		t.Errorf("Retry() of cancelled plumbus error = %v, want %v", err, ErrPlumbusNotFailed)
+	}
+}
+
+func TestGenerationService_CancelDuringSigningRemovesImage(t *testing.T) {
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testutils.CreateTestPNGData())
+	}))
+	t.Cleanup(generator.Close)
+	sigStore, started, aborted := blockingServer(t)
+	service, us, user := setupGenerationService(t, generator.URL, sigStore.URL)
+
+	plumbus, err := service.Start(context.Background(), user.ID, cancelRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, started, "signing request")
+
+	if err := service.Cancel(context.Background(), plumbus); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	waitFor(t, aborted, "signing request to be aborted")
+	waitForAttempt(t, us, plumbus)
+
+	stored, _ := us.GetPlumbus(plumbus.ID)
+	if stored.Status != models.StatusCancelled || stored.ImagePath != nil {
+		t.Errorf("plumbus = %+v, want cancelled without image", stored)
+	}
+	images, _ := filepath.Glob(filepath.Join("storage", "images", "*.png"))
+	if len(images) != 0 {
+		t.Errorf("images left after cancellation: %v", images)
+	}
+}
+
+func TestGenerationService_CancelFinishedPlumbus(t *testing.T) {
+	service, us, user := setupGenerationService(t, "", "")
+
+	plumbus, err := us.CreatePlumbus(user.ID, cancelRequest)
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+	us.UpdatePlumbusStatus(plumbus.ID, models.StatusCompleted, nil, nil, nil, nil)
+
+	if err := service.Cancel(context.Background(), plumbus); !errors.Is(err, ErrPlumbusNotRunning) {
+		t.Errorf("Cancel() of completed plumbus error = %v, want %v", err, ErrPlumbusNotRunning)
+	}
+}
+
+func TestGenerationService_ShutdownInterruptsGeneration(t *testing.T) {
+	generator, started, aborted := blockingServer(t)
+	service, us, user := setupGenerationService(t, generator.URL, "")
+
+	plumbus, err := service.Start(context.Background(), user.ID, cancelRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, started, "generation request")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := service.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	waitFor(t, aborted, "generation request to be aborted")
+
+	stored, _ := us.GetPlumbus(plumbus.ID)
+	if stored.Status != models.StatusPending || !stored.Interrupted {
+		t.Errorf("plumbus = %+v, want pending and interrupted", stored)
+	}
+
+	// Прерванный плюмбус забирает только один экземпляр фабрики
+	if claimed, _ := us.ClaimInterruptedPlumbus(plumbus.ID); !claimed {
+		t.Error("ClaimInterruptedPlumbus() = false, want true")
+	}
+	if claimed, _ := us.ClaimInterruptedPlumbus(plumbus.ID); claimed {
+		t.Error("second ClaimInterruptedPlumbus() = true, want false")
+	}
+}
+
+func TestGenerationService_TracesGeneration(t *testing.T) {
+	exporter := testutils.SetupTracing(t)
+	traceparent := make(chan string, 1)
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get("traceparent")
+		w.Write(testutils.CreateTestPNGData())
+	}))
+	t.Cleanup(generator.Close)
+	service, us, user := setupGenerationService(t, generator.URL, "")
+
+	ctx, request := tracing.Tracer().Start(context.Background(), "request")
+	plumbus, err := service.Start(ctx, user.ID, cancelRequest)
+	request.End()
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitForAttempt(t, us, plumbus)
+
+	traceID := request.SpanContext().TraceID().String()
+	if header := <-traceparent; !strings.Contains(header, traceID) {
+		t.Errorf("generator traceparent = %q, want trace %s", header, traceID)
+	}
+
+	// Генерация завершается позже запроса, но остается в его трейсе.
+	// Спан генерации закрывается сразу после записи попытки.
+	names := make(map[string]bool)
+	deadline := time.Now().Add(5 * time.Second)
+	for !names["plumbus.generate"] && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		for _, span := range exporter.GetSpans() {
+			if span.SpanContext.TraceID().String() == traceID {
+				names[span.Name] = true
+			}
+		}
+	}
+	for _, name := range []string{"request", "plumbus.generate", "HTTP POST"} {
+		if !names[name] {
+			t.Errorf("trace has no %q span, spans = %v", name, names)
+		}
+	}
+}
+
+func TestGenerationService_FinishRecordsDeletedPlumbus(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(generator.Close)
+
+	service, us, user := setupGenerationService(t, generator.URL, "")
+	if err := us.db.AutoMigrate(&models.Webhook{}, &models.WebhookDelivery{}); err != nil {
+		t.Fatalf("Failed to migrate webhooks tables: %v", err)
+	}
+	webhooks := NewWebhookService(us.db)
+	webhooks.AllowedHosts = []string{"127.0.0.1"}
+	receiver := newWebhookReceiver(t)
+	if _, _, err := webhooks.CreateWebhook(user.ID, receiver.URL, []string{EventPlumbusFailed}); err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	service.webhookService = webhooks
+
+	plumbus, err := service.Start(context.Background(), user.ID, cancelRequest)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, started, "generation request")
+	if err := us.DeletePlumbus(plumbus.ID); err != nil {
+		t.Fatalf("DeletePlumbus() error = %v", err)
+	}
+	close(release)
+
+	attempt := waitForAttempt(t, us, plumbus)
+	if attempt.Status != models.StatusFailed {
+		t.Errorf("attempt status = %v, want %v", attempt.Status, models.StatusFailed)
+	}
+	if err := service.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if receiver.count() != 0 {
+		t.Errorf("webhook for deleted plumbus sent %d times, want 0", receiver.count())
+	}
+}
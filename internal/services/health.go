@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Зависимости фабрики, которые проверяет /readyz
+const (
+	DependencyDatabase  = "database"
+	DependencyNATS      = "nats"
+	DependencyKeycloak  = "keycloak"
+	DependencyGenerator = "generator"
+	DependencySigStore  = "sigstore"
+)
+
+// Итоговое состояние готовности
+const (
+	// HealthOK - все зависимости доступны
+	HealthOK = "ok"
+	// HealthDegraded - недоступны только необязательные зависимости
+	HealthDegraded = "degraded"
+	// HealthUnavailable - недоступна хотя бы одна критичная зависимость
+	HealthUnavailable = "unavailable"
+)
+
+// Состояние отдельной зависимости
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+// DependencyHealth - результат проверки одной зависимости
+type DependencyHealth struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// HealthReport - сводка проверок зависимостей
+type HealthReport struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyHealth `json:"dependencies"`
+}
+
+// healthCheck - зарегистрированная проверка и ее последний результат
+type healthCheck struct {
+	name     string
+	critical bool
+	check    func(ctx context.Context) error
+
+	mu     sync.Mutex
+	result DependencyHealth
+}
+
+// HealthService проверяет доступность зависимостей. Каждая проверка ограничена
+// таймаутом, а ее результат кэшируется, чтобы частые пробы не нагружали зависимости.
+type HealthService struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	checks   []*healthCheck
+}
+
+func NewHealthService(timeout, cacheTTL time.Duration) *HealthService {
+	return &HealthService{
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Register добавляет проверку зависимости. Недоступность критичной зависимости
+// делает фабрику неготовой, необязательной - только ухудшает состояние.
+func (s *HealthService) Register(name string, critical bool, check func(ctx context.Context) error) {
+	s.checks = append(s.checks, &healthCheck{name: name, critical: critical, check: check})
+}
+
+// Check проверяет все зависимости параллельно и возвращает сводку
+func (s *HealthService) Check(ctx context.Context) HealthReport {
+	results := make([]DependencyHealth, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check *healthCheck) {
+			defer wg.Done()
+			results[i] = s.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: HealthOK, Dependencies: make(map[string]DependencyHealth, len(s.checks))}
+	for i, check := range s.checks {
+		result := results[i]
+		report.Dependencies[check.name] = result
+		if result.Status == DependencyUp {
+			continue
+		}
+		if result.Critical {
+			report.Status = HealthUnavailable
+		} else if report.Status == HealthOK {
+			report.Status = HealthDegraded
+		}
+	}
+	return report
+}
+
+// ParseCriticalDependencies разбирает список критичных зависимостей, разделенных запятыми
+func ParseCriticalDependencies(value string) (map[string]bool, error) {
+	known := map[string]bool{
+		DependencyDatabase:  true,
+		DependencyNATS:      true,
+		DependencyKeycloak:  true,
+		DependencyGenerator: true,
+		DependencySigStore:  true,
+	}
+
+	critical := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown dependency %q", name)
+		}
+		critical[name] = true
+	}
+	return critical, nil
+}
+
+// run выполняет проверку, если закэшированный результат устарел. Одновременные
+// запросы ждут одну проверку вместо того, чтобы запускать свои.
+func (s *HealthService) run(ctx context.Context, check *healthCheck) DependencyHealth {
+	check.mu.Lock()
+	defer check.mu.Unlock()
+
+	if !check.result.CheckedAt.IsZero() && time.Since(check.result.CheckedAt) < s.cacheTTL {
+		return check.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.check(ctx)
+	result := DependencyHealth{
+		Status:    DependencyUp,
+		Critical:  check.critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = DependencyDown
+		result.Error = err.Error()
+	}
+
+	check.result = result
+	return result
+}
+
+// pingHTTP проверяет health endpoint сервиса
+func pingHTTP(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach service: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
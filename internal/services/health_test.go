@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthService_CachesResults(t *testing.T) {
+	var calls atomic.Int32
+	health := NewHealthService(time.Second, time.Minute)
+	health.Register(DependencyDatabase, true, func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		if report := health.Check(context.Background()); report.Status != HealthOK {
+			t.Fatalf("Check() status = %v, want %v", report.Status, HealthOK)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("check ran %d times, want 1 within cache TTL", calls.Load())
+	}
+}
+
+func TestHealthService_CheckTimeout(t *testing.T) {
+	health := NewHealthService(50*time.Millisecond, 0)
+	health.Register(DependencyGenerator, true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	report := health.Check(context.Background())
+	if time.Since(start) > time.Second {
+		t.Errorf("Check() took %v, want it bounded by timeout", time.Since(start))
+	}
+	if report.Status != HealthUnavailable || report.Dependencies[DependencyGenerator].Status != DependencyDown {
+		t.Errorf("report = %+v, want generator down", report)
+	}
+}
+
+func TestPingHTTP(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	if err := pingHTTP(context.Background(), server.Client(), server.URL); err != nil {
+		t.Errorf("pingHTTP() error = %v, want nil", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := pingHTTP(context.Background(), server.Client(), server.URL); err == nil {
+		t.Error("pingHTTP() error = nil for 503 response")
+	}
+
+	server.Close()
+	if err := pingHTTP(context.Background(), http.DefaultClient, server.URL); err == nil {
+		t.Error("pingHTTP() error = nil for unreachable service")
+	}
+}
+
+func TestParseCriticalDependencies(t *testing.T) {
+	critical, err := ParseCriticalDependencies(" database, nats ,")
+	if err != nil {
+		t.Fatalf("ParseCriticalDependencies() error = %v", err)
+	}
+	if !critical[DependencyDatabase] || !critical[DependencyNATS] || critical[DependencyKeycloak] {
+		t.Errorf("critical = %v, want database and nats", critical)
+	}
+
+	if _, err := ParseCriticalDependencies("database,redis"); err == nil {
+		t.Error("ParseCriticalDependencies() accepted unknown dependency")
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"factory/internal/logger"
+	"factory/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultImageGCGrace - сколько файл-сирота хранится, прежде чем его можно удалить
+const DefaultImageGCGrace = time.Hour
+
+// imageGCLockKey - ключ advisory lock Postgres, под которым каталог изображений
+// сверяет только один экземпляр фабрики
+const imageGCLockKey int64 = 0x706c756d62757302
+
+// Действия сборщика изображений, они же значения метки action метрики ImageGC
+const (
+	imageGCRemoved  = "removed"
+	imageGCFlagged  = "flagged"
+	imageGCRestored = "restored"
+)
+
+// PlumbusImage - путь к изображению плюмбуса
+type PlumbusImage struct {
+	ID     uuid.UUID
+	Path   string
+	Broken bool
+}
+
+// OrphanImage - файл в каталоге изображений, на который не ссылается ни один плюмбус
+type OrphanImage struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Recent - файл моложе Grace: генерация могла сохранить его, но еще не записать путь
+	Recent bool
+}
+
+// ImageGCReport - итог сверки каталога изображений с записями плюмбусов
+type ImageGCReport struct {
+	// Scanned - сколько файлов найдено в каталоге
+	Scanned int
+	// Orphans - файлы, на которые не ссылается ни один плюмбус
+	Orphans []OrphanImage
+	// Removed - сколько файлов-сирот удалено, RemovedBytes - их общий размер
+	Removed      int
+	RemovedBytes int64
+	// Missing - изображения плюмбусов, файлов которых нет в каталоге
+	Missing []PlumbusImage
+	// Flagged - сколько плюмбусов помечено как испорченные, Restored - со скольких
+	// пометка снята, потому что файл вернулся
+	Flagged  int
+	Restored int
+	// Skipped - сверку выполняет другой экземпляр фабрики
+	Skipped bool
+}
+
+// ImageGC сверяет каталог изображений с записями плюмбусов: находит файлы-сироты,
+// оставшиеся после неудачных генераций и удалений, и плюмбусы, файлы которых пропали.
+// Изображения мягко удаленных плюмбусов сиротами не считаются: их удалит очистка.
+type ImageGC struct {
+	userService *UserService
+	logger      *logrus.Logger
+
+	// Dir - каталог изображений
+	Dir string
+	// Grace - файлы-сироты моложе этого возраста не удаляются
+	Grace time.Duration
+}
+
+func NewImageGC(us *UserService, dir string) *ImageGC {
+	return &ImageGC{
+		userService: us,
+		logger:      logger.For("images"),
+		Dir:         dir,
+		Grace:       DefaultImageGCGrace,
+	}
+}
+
+// Run сверяет каталог с записями плюмбусов. Плюмбусы с пропавшими файлами помечаются
+// как испорченные, пометка снимается, когда файл возвращается. Файлы-сироты старше Grace
+// удаляются, только если remove истинно, иначе они лишь перечисляются в отчете. Сверка
+// выполняется под блокировкой в базе данных, поэтому одновременно ее выполняет только
+// один экземпляр.
+func (g *ImageGC) Run(ctx context.Context, remove bool) (*ImageGCReport, error) {
+	report := &ImageGCReport{}
+	locked, err := g.userService.WithContext(ctx).WithAdvisoryLock(imageGCLockKey, func(users *UserService) error {
+		images, err := users.GetPlumbusImages()
+		if err != nil {
+			return fmt.Errorf("failed to load plumbus images: %w", err)
+		}
+		if err := g.checkMissing(ctx, users, images, report); err != nil {
+			return err
+		}
+		return g.collectOrphans(ctx, images, remove, report)
+	})
+	if err != nil {
+		return report, err
+	}
+	if !locked {
+		g.logger.WithContext(ctx).Debug("Image directory is being reconciled by another instance")
+		return &ImageGCReport{Skipped: true}, nil
+	}
+
+	g.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"scanned":       report.Scanned,
+		"orphans":       len(report.Orphans),
+		"removed":       report.Removed,
+		"removed_bytes": report.RemovedBytes,
+		"missing":       len(report.Missing),
+		"flagged":       report.Flagged,
+		"restored":      report.Restored,
+	}).Info("Image directory reconciled")
+	return report, nil
+}
+
+// checkMissing находит плюмбусы, файлов изображений которых нет, и обновляет их пометки
+func (g *ImageGC) checkMissing(ctx context.Context, users *UserService, images []PlumbusImage, report *ImageGCReport) error {
+	var flag, restore []uuid.UUID
+	for _, image := range images {
+		_, err := os.Stat(image.Path)
+		switch {
+		case os.IsNotExist(err):
+			report.Missing = append(report.Missing, image)
+			if !image.Broken {
+				flag = append(flag, image.ID)
+				g.logger.WithContext(ctx).WithFields(logrus.Fields{
+					"plumbus_id": image.ID,
+					"path":       image.Path,
+				}).Warn("Plumbus image file is missing")
+			}
+		case err != nil:
+			return fmt.Errorf("failed to check image %s: %w", image.Path, err)
+		case image.Broken:
+			restore = append(restore, image.ID)
+		}
+	}
+
+	if err := users.SetPlumbusesBroken(flag, true); err != nil {
+		return fmt.Errorf("failed to flag plumbuses with missing images: %w", err)
+	}
+	if err := users.SetPlumbusesBroken(restore, false); err != nil {
+		return fmt.Errorf("failed to unflag plumbuses with restored images: %w", err)
+	}
+	report.Flagged = len(flag)
+	report.Restored = len(restore)
+	metrics.ImageGC.WithLabelValues(imageGCFlagged).Add(float64(report.Flagged))
+	metrics.ImageGC.WithLabelValues(imageGCRestored).Add(float64(report.Restored))
+	return nil
+}
+
+// collectOrphans находит файлы, на которые не ссылается ни один плюмбус, и удаляет
+// файлы старше Grace, если remove истинно
+func (g *ImageGC) collectOrphans(ctx context.Context, images []PlumbusImage, remove bool, report *ImageGCReport) error {
+	referenced := make(map[string]bool, len(images))
+	for _, image := range images {
+		referenced[filepath.Clean(image.Path)] = true
+	}
+
+	entries, err := os.ReadDir(g.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read image directory: %w", err)
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		report.Scanned++
+		path := filepath.Join(g.Dir, entry.Name())
+		if referenced[path] {
+			continue
+		}
+		info, err := entry.Info()
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat orphaned image: %w", err)
+		}
+
+		orphan := OrphanImage{
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Recent:  now.Sub(info.ModTime()) < g.Grace,
+		}
+		report.Orphans = append(report.Orphans, orphan)
+		if !remove || orphan.Recent {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove orphaned image: %w", err)
+		}
+		report.Removed++
+		report.RemovedBytes += orphan.Size
+		metrics.ImageGC.WithLabelValues(imageGCRemoved).Inc()
+		g.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"path": path,
+			"size": orphan.Size,
+		}).Info("Orphaned image removed")
+	}
+	return nil
+}
+
+// Start периодически сверяет каталог изображений, см. RunPeriodically
+func (g *ImageGC) Start(ctx context.Context, interval time.Duration, remove bool, onError func(error)) <-chan struct{} {
+	return RunPeriodically(ctx, interval, func(ctx context.Context) error {
+		_, err := g.Run(ctx, remove)
+		return err
+	}, onError)
+}
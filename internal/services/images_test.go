@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"factory/internal/models"
+	"factory/internal/testutils"
+)
+
+func writeImage(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, testutils.CreateTestPNGData(), 0644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+}
+
+func TestImageGC_Run(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	us := NewUserService(db)
+	dir := t.TempDir()
+
+	// Изображения готового и мягко удаленного плюмбусов не трогаются
+	completed := createTestPlumbus(t, db, user.ID)
+	completedPath := filepath.Join(dir, "completed.png")
+	writeImage(t, completedPath)
+	if err := us.UpdatePlumbusStatus(completed.ID, models.StatusCompleted, &completedPath, nil, nil, nil); err != nil {
+		t.Fatalf("UpdatePlumbusStatus() error = %v", err)
+	}
+	deleted := createTestPlumbus(t, db, user.ID)
+	deletedPath := filepath.Join(dir, "deleted.png")
+	writeImage(t, deletedPath)
+	if err := us.UpdatePlumbusStatus(deleted.ID, models.StatusCompleted, &deletedPath, nil, nil, nil); err != nil {
+		t.Fatalf("UpdatePlumbusStatus() error = %v", err)
+	}
+	if err := us.DeletePlumbus(deleted.ID); err != nil {
+		t.Fatalf("DeletePlumbus() error = %v", err)
+	}
+
+	// Старый файл-сирота удаляется, свежий может принадлежать идущей генерации
+	orphan := filepath.Join(dir, "orphan.png")
+	writeImage(t, orphan)
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(orphan, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	recent := filepath.Join(dir, "recent.png")
+	writeImage(t, recent)
+
+	gc := NewImageGC(us, dir)
+
+	report, err := gc.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Scanned != 4 || report.Removed != 0 || len(report.Orphans) != 2 {
+		t.Errorf("Run(dry run) = %+v, want 4 scanned and two orphans kept", report)
+	}
+	for _, o := range report.Orphans {
+		if o.Recent != (o.Path == recent) || o.Size == 0 {
+			t.Errorf("orphan %+v, want only %s recent and the size reported", o, recent)
+		}
+	}
+	if _, err := os.Stat(orphan); err != nil {
+		t.Errorf("orphan removed in dry run: %v", err)
+	}
+
+	report, err = gc.Run(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Removed != 1 || report.RemovedBytes != int64(len(testutils.CreateTestPNGData())) {
+		t.Errorf("Run() removed = %d (%d bytes), want the old orphan", report.Removed, report.RemovedBytes)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("orphan still exists: %v", err)
+	}
+	for _, path := range []string{completedPath, deletedPath, recent} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("image %s removed: %v", path, err)
+		}
+	}
+}
+
+func TestImageGC_FlagsMissingImages(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	us := NewUserService(db)
+	dir := t.TempDir()
+
+	plumbus := createTestPlumbus(t, db, user.ID)
+	path := filepath.Join(dir, "lost.png")
+	if err := us.UpdatePlumbusStatus(plumbus.ID, models.StatusCompleted, &path, nil, nil, nil); err != nil {
+		t.Fatalf("UpdatePlumbusStatus() error = %v", err)
+	}
+	gc := NewImageGC(us, dir)
+
+	// Пометка отражает состояние хранилища и ставится и при пробном прогоне
+	report, err := gc.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].ID != plumbus.ID || report.Flagged != 1 {
+		t.Errorf("Run() = %+v, want the plumbus reported missing and flagged", report)
+	}
+	if stored, _ := us.GetPlumbus(plumbus.ID); !stored.Broken {
+		t.Error("plumbus with missing image not flagged as broken")
+	}
+
+	// Уже помеченный плюмбус повторно не помечается
+	if report, err := gc.Run(context.Background(), false); err != nil || len(report.Missing) != 1 || report.Flagged != 0 {
+		t.Errorf("second Run() = %+v, %v, want missing but not flagged again", report, err)
+	}
+
+	// Файл вернули из резервной копии - пометка снимается
+	writeImage(t, path)
+	report, err = gc.Run(context.Background(), false)
+	if err != nil || report.Restored != 1 || len(report.Missing) != 0 {
+		t.Errorf("Run() after restore = %+v, %v, want the plumbus unflagged", report, err)
+	}
+	if stored, _ := us.GetPlumbus(plumbus.ID); stored.Broken {
+		t.Error("plumbus with restored image still flagged as broken")
+	}
+}
+
+func TestImageGC_MissingDirectory(t *testing.T) {
+	gc := NewImageGC(NewUserService(setupTestDB(t)), filepath.Join(t.TempDir(), "missing"))
+	report, err := gc.Run(context.Background(), true)
+	if err != nil || report.Scanned != 0 {
+		t.Errorf("Run() = %+v, %v, want empty report", report, err)
+	}
+}
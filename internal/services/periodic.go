@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// RunPeriodically вызывает fn каждые interval до отмены контекста и передает ошибки
+// прохода в onError, если он задан. Возвращенный канал закрывается, когда задача
+// остановлена и текущий проход завершен.
+func RunPeriodically(ctx context.Context, interval time.Duration, fn func(context.Context) error, onError func(error)) <-chan struct{} {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return done
+}
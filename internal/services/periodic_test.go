@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunPeriodically(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errFailed := errors.New("pass failed")
+	calls := make(chan struct{}, 10)
+	errs := make(chan error, 10)
+	done := RunPeriodically(ctx, 5*time.Millisecond, func(context.Context) error {
+		calls <- struct{}{}
+		return errFailed
+	}, func(err error) { errs <- err })
+
+	for i := 0; i < 2; i++ {
+		waitFor(t, calls, "periodic pass")
+		select {
+		case err := <-errs:
+			if !errors.Is(err, errFailed) {
+				t.Errorf("onError() got %v, want %v", err, errFailed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for onError")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunPeriodically did not stop after cancel")
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,26 +12,60 @@ import (
 	"time"
 
 	"factory/internal/config"
+	"factory/internal/metrics"
 	"factory/internal/models"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// ImageDir - каталог, в котором хранятся изображения плюмбусов
+const ImageDir = "storage/images"
+
 type PlumbusService struct {
-	config *config.Config
-	client *http.Client
+	config  *config.Config
+	client  *http.Client
+	timeout *requestTimeout
 }
 
 func NewPlumbusService(cfg *config.Config) *PlumbusService {
 	return &PlumbusService{
 		config: cfg,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
+		timeout: newRequestTimeout(cfg.GeneratorTimeout),
 	}
 }
 
-func (s *PlumbusService) GeneratePlumbus(req models.PlumbusGenerationRequest) (string, error) {
+// SetTimeout заменяет таймаут запросов к генератору. Действует на новые запросы.
+func (s *PlumbusService) SetTimeout(d time.Duration) {
+	s.timeout.set(d)
+}
+
+// Timeout возвращает текущий таймаут запросов к генератору
+func (s *PlumbusService) Timeout() time.Duration {
+	return s.timeout.get()
+}
+
+// Ping проверяет доступность сервиса генерации
+func (s *PlumbusService) Ping(ctx context.Context) error {
+	return pingHTTP(ctx, s.client, s.config.PlumbusServiceURL+"/health")
+}
+
+// GeneratePlumbus запрашивает изображение у сервиса генерации и сохраняет его в storage/images.
+// При отмене ctx запрос прерывается, а недописанный файл удаляется.
+func (s *PlumbusService) GeneratePlumbus(ctx context.Context, req models.PlumbusGenerationRequest) (string, error) {
+	start := time.Now()
+	imagePath, err := s.generate(ctx, req)
+	metrics.GeneratorRequestDuration.WithLabelValues(metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+	return imagePath, err
+}
+
+func (s *PlumbusService) generate(ctx context.Context, req models.PlumbusGenerationRequest) (string, error) {
+	ctx, cancel := s.timeout.context(ctx)
+	defer cancel()
+
 	// Подготавливаем запрос к сервису генерации
 	jsonData, err := json.Marshal(req)
 	if err != nil {
@@ -39,7 +74,13 @@ func (s *PlumbusService) GeneratePlumbus(req models.PlumbusGenerationRequest) (s
 
 	// Отправляем запрос
 	url := fmt.Sprintf("%s/plumbus", s.config.PlumbusServiceURL)
-	resp, err := s.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(httpReq)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -50,7 +91,7 @@ func (s *PlumbusService) GeneratePlumbus(req models.PlumbusGenerationRequest) (s
 	}
 
 	// Создаем папку для хранения изображений если её нет
-	imgDir := "storage/images"
+	imgDir := ImageDir
 	if err := os.MkdirAll(imgDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create image directory: %w", err)
 	}
@@ -68,6 +109,7 @@ func (s *PlumbusService) GeneratePlumbus(req models.PlumbusGenerationRequest) (s
 
 	_, err = io.Copy(file, resp.Body)
 	if err != nil {
+		os.Remove(filePath)
 		return "", fmt.Errorf("failed to save image: %w", err)
 	}
 
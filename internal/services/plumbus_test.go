@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -30,8 +31,8 @@ func TestNewPlumbusService(t *testing.T) {
 		t.Error("NewPlumbusService() did not initialize HTTP client")
 	}
 
-	if service.client.Timeout != 30*time.Second {
-		t.Errorf("NewPlumbusService() timeout = %v, want %v", service.client.Timeout, 30*time.Second)
+	if service.Timeout() != 30*time.Second {
+		t.Errorf("NewPlumbusService() timeout = %v, want %v", service.Timeout(), 30*time.Second)
 	}
 }
 
@@ -64,7 +65,7 @@ func TestPlumbusService_GeneratePlumbus_Success(t *testing.T) {
 		Wrapping: "gift",
 	}
 
-	filePath, err := service.GeneratePlumbus(req)
+	filePath, err := service.GeneratePlumbus(context.Background(), req)
 
 	// Проверяем что ошибки нет
 	if err != nil {
@@ -126,7 +127,7 @@ func TestPlumbusService_GeneratePlumbus_ServiceError(t *testing.T) {
 		Wrapping: "gift",
 	}
 
-	_, err := service.GeneratePlumbus(req)
+	_, err := service.GeneratePlumbus(context.Background(), req)
 
 	// Проверяем что вернулась ошибка
 	if err == nil {
@@ -155,7 +156,7 @@ func TestPlumbusService_GeneratePlumbus_NetworkError(t *testing.T) {
 		Wrapping: "gift",
 	}
 
-	_, err := service.GeneratePlumbus(req)
+	_, err := service.GeneratePlumbus(context.Background(), req)
 
 	// Проверяем что вернулась ошибка сети
 	if err == nil {
@@ -198,7 +199,7 @@ func TestPlumbusService_GeneratePlumbus_FileCreationError(t *testing.T) {
 		Wrapping: "gift",
 	}
 
-	_, err = service.GeneratePlumbus(req)
+	_, err = service.GeneratePlumbus(context.Background(), req)
 
 	// Проверяем что вернулась ошибка создания директории
 	if err == nil {
@@ -238,7 +239,7 @@ func TestPlumbusService_GeneratePlumbus_FilePathGeneration(t *testing.T) {
 	// Генерируем несколько файлов
 	paths := make([]string, 3)
 	for i := 0; i < 3; i++ {
-		path, err := service.GeneratePlumbus(req)
+		path, err := service.GeneratePlumbus(context.Background(), req)
 		if err != nil {
 			t.Fatalf("GeneratePlumbus() error = %v", err)
 		}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// DefaultRareChance - вероятность редкого плюмбуса по умолчанию (5%)
+const DefaultRareChance = 0.05
+
+// Rarity решает, будет ли новый плюмбус редким. Вероятность можно менять во
+// время работы, например при перезагрузке настроек.
+type Rarity struct {
+	chance atomic.Uint64
+}
+
+// NewRarity создает движок редкости с вероятностью chance от 0 до 1
+func NewRarity(chance float64) *Rarity {
+	r := &Rarity{}
+	r.SetChance(chance)
+	return r
+}
+
+// SetChance заменяет вероятность редкого плюмбуса
+func (r *Rarity) SetChance(chance float64) {
+	r.chance.Store(math.Float64bits(chance))
+}
+
+// Chance возвращает текущую вероятность редкого плюмбуса
+func (r *Rarity) Chance() float64 {
+	return math.Float64frombits(r.chance.Load())
+}
+
+// Roll определяет, будет ли очередной плюмбус редким
+func (r *Rarity) Roll() bool {
+	return rand.Float64() < r.Chance()
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRateLimited возвращается, если пользователь слишком часто запускает генерацию
+var ErrRateLimited = errors.New("generation rate limit exceeded")
+
+// RateLimitError сообщает, через сколько пользователь сможет снова запустить генерацию.
+// errors.Is(err, ErrRateLimited) для нее истинно.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrRateLimited, e.RetryAfter)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimiter ограничивает число запусков генерации одного пользователя в минуту
+// (token bucket: запас до limit запусков, пополняется равномерно за минуту).
+// Лимит 0 отключает ограничение. Лимит можно менять во время работы.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	buckets map[uuid.UUID]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewRateLimiter(perMinute int) *RateLimiter {
+	return &RateLimiter{
+		limit:   perMinute,
+		buckets: make(map[uuid.UUID]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimit заменяет лимит. Накопленный пользователями запас обрезается до нового лимита.
+func (l *RateLimiter) SetLimit(perMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = perMinute
+	if perMinute <= 0 {
+		l.buckets = make(map[uuid.UUID]*bucket)
+		return
+	}
+	for _, b := range l.buckets {
+		b.tokens = math.Min(b.tokens, float64(perMinute))
+	}
+}
+
+// Limit возвращает текущий лимит запусков в минуту
+func (l *RateLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// Allow расходует один запуск пользователя. Если запас исчерпан, возвращает
+// *RateLimitError со временем до следующего доступного запуска.
+func (l *RateLimiter) Allow(userID uuid.UUID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit <= 0 {
+		return nil
+	}
+
+	now := l.now()
+	perSecond := float64(l.limit) / time.Minute.Seconds()
+	l.sweep(now, perSecond)
+
+	b, ok := l.buckets[userID]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), updated: now}
+		l.buckets[userID] = b
+	}
+	b.tokens = math.Min(float64(l.limit), b.tokens+now.Sub(b.updated).Seconds()*perSecond)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := math.Ceil((1 - b.tokens) / perSecond)
+		return &RateLimitError{RetryAfter: time.Duration(wait) * time.Second}
+	}
+	b.tokens--
+	return nil
+}
+
+// sweep удаляет полностью восстановленные запасы, чтобы карта не росла
+// вместе с числом пользователей. Вызывается под mu.
+func (l *RateLimiter) sweep(now time.Time, perSecond float64) {
+	if len(l.buckets) < 1024 {
+		return
+	}
+	for id, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*perSecond >= float64(l.limit) {
+			delete(l.buckets, id)
+		}
+	}
+}
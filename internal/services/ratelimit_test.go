@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(2)
+	limiter.now = func() time.Time { return now }
+	user := uuid.New()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Allow(user); err != nil {
+			t.Fatalf("Allow() #%d error = %v", i+1, err)
+		}
+	}
+
+	err := limiter.Allow(user)
+	var limited *RateLimitError
+	if !errors.As(err, &limited) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Allow() over limit error = %v, want *RateLimitError", err)
+	}
+	if limited.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", limited.RetryAfter)
+	}
+
+	// Лимит считается отдельно для каждого пользователя
+	if err := limiter.Allow(uuid.New()); err != nil {
+		t.Errorf("Allow() for another user error = %v", err)
+	}
+
+	// За полминуты восстанавливается один запуск
+	now = now.Add(30 * time.Second)
+	if err := limiter.Allow(user); err != nil {
+		t.Errorf("Allow() after refill error = %v", err)
+	}
+	if err := limiter.Allow(user); err == nil {
+		t.Error("Allow() after one refill = nil, want rate limit error")
+	}
+}
+
+func TestRateLimiter_SetLimit(t *testing.T) {
+	limiter := NewRateLimiter(0)
+	user := uuid.New()
+
+	// Лимит 0 ничего не ограничивает
+	for i := 0; i < 100; i++ {
+		if err := limiter.Allow(user); err != nil {
+			t.Fatalf("Allow() without limit error = %v", err)
+		}
+	}
+
+	limiter.SetLimit(1)
+	if limiter.Limit() != 1 {
+		t.Errorf("Limit() = %d, want 1", limiter.Limit())
+	}
+	if err := limiter.Allow(user); err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if err := limiter.Allow(user); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Allow() over new limit error = %v, want ErrRateLimited", err)
+	}
+
+	limiter.SetLimit(0)
+	if err := limiter.Allow(user); err != nil {
+		t.Errorf("Allow() after removing limit error = %v", err)
+	}
+}
+
+func TestRarity_Roll(t *testing.T) {
+	rarity := NewRarity(0)
+	for i := 0; i < 100; i++ {
+		if rarity.Roll() {
+			t.Fatal("Roll() with zero chance = true")
+		}
+	}
+
+	rarity.SetChance(1)
+	if rarity.Chance() != 1 || !rarity.Roll() {
+		t.Errorf("Roll() with chance %v = false, want true", rarity.Chance())
+	}
+}
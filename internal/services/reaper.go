@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"factory/internal/logger"
+	"factory/internal/metrics"
+	"factory/internal/models"
+	"factory/internal/testutils"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Действия сборщика зависших плюмбусов, они же окончания типов событий plumbus.requeued и plumbus.failed
+const (
+	ReapRequeued = "requeued"
+	ReapFailed   = "failed"
+)
+
+// reaperLockKey - ключ advisory lock Postgres, под которым зависшие плюмбусы
+// собирает только один экземпляр фабрики
+const reaperLockKey int64 = 0x706c756d62757301
+
+// ReapResult - итог одного прохода сборщика зависших плюмбусов
+type ReapResult struct {
+	Requeued int
+	Failed   int
+	// Skipped - проход выполняет другой экземпляр фабрики
+	Skipped bool
+}
+
+// reapedPlumbus - зависший плюмбус и то, что с ним сделал сборщик
+type reapedPlumbus struct {
+	id         uuid.UUID
+	action     string
+	stuckSince time.Time
+}
+
+// Reap находит плюмбусы, которые ждут или генерируются дольше StuckAfter, например
+// после падения экземпляра фабрики. Пока остаются попытки, генерация запускается
+// заново, иначе плюмбус завершается ошибкой. Проход выполняется под блокировкой в
+// базе данных, поэтому одновременно его выполняет только один экземпляр.
+func (s *GenerationService) Reap(ctx context.Context, now time.Time) (ReapResult, error) {
+	cutoff := now.Add(-s.StuckAfter)
+	errorMsg := fmt.Sprintf("generation did not finish within %s, the factory instance may have crashed", s.StuckAfter)
+
+	var reaped []reapedPlumbus
+	locked, err := s.userService.WithContext(ctx).WithAdvisoryLock(reaperLockKey, func(users *UserService) error {
+		stuck, err := users.GetStuckPlumbuses(cutoff)
+		if err != nil {
+			return err
+		}
+		for i := range stuck {
+			p := &stuck[i]
+			// Генерации этого экземпляра не зависли, а еще выполняются
+			if s.running(p.ID) {
+				continue
+			}
+			action, ok, err := s.reapOne(users, p, cutoff, now, errorMsg)
+			if err != nil {
+				return err
+			}
+			if ok {
+				reaped = append(reaped, reapedPlumbus{id: p.ID, action: action, stuckSince: p.UpdatedAt})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return ReapResult{}, err
+	}
+	if !locked {
+		s.logger.WithContext(ctx).Debug("Stuck plumbuses are being reaped by another instance")
+		return ReapResult{Skipped: true}, nil
+	}
+
+	// События и новые генерации - только после фиксации транзакции
+	result := ReapResult{}
+	for _, r := range reaped {
+		s.afterReap(ctx, r)
+		if r.action == ReapRequeued {
+			result.Requeued++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// reapOne отправляет зависший плюмбус на повтор или завершает его ошибкой в транзакции
+// сборщика. Возвращает false, если плюмбус успел измениться после выборки.
+func (s *GenerationService) reapOne(users *UserService, p *models.Plumbus, cutoff, now time.Time, errorMsg string) (string, bool, error) {
+	// Прерванная остановкой генерация продолжается с той же попыткой, как при Resume
+	if p.Interrupted {
+		ok, err := users.ClaimInterruptedPlumbus(p.ID)
+		return ReapRequeued, ok, err
+	}
+
+	action := ReapFailed
+	var ok bool
+	var err error
+	if p.Attempts < s.MaxAttempts {
+		action = ReapRequeued
+		ok, err = users.RequeueStuckPlumbus(p.ID, cutoff)
+	} else {
+		ok, err = users.FailStuckPlumbus(p.ID, cutoff, errorMsg)
+	}
+	if err != nil || !ok {
+		return action, ok, err
+	}
+
+	// Зависшая попытка попадает в историю как неудавшаяся
+	err = users.CreatePlumbusAttempt(&models.PlumbusAttempt{
+		PlumbusID:  p.ID,
+		Attempt:    p.Attempts,
+		Status:     models.StatusFailed,
+		Error:      errorMsg,
+		StartedAt:  p.UpdatedAt,
+		FinishedAt: now,
+	})
+	return action, true, err
+}
+
+// afterReap сообщает о собранном плюмбусе и запускает повторную генерацию
+func (s *GenerationService) afterReap(ctx context.Context, r reapedPlumbus) {
+	ctx = logger.WithPlumbusID(ctx, r.id.String())
+	metrics.ReapedPlumbuses.WithLabelValues(r.action).Inc()
+
+	plumbus, err := s.userService.WithContext(ctx).GetPlumbus(r.id)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", r.id).Warn("Failed to load reaped plumbus")
+		return
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"plumbus_id":  plumbus.ID,
+		"user_id":     plumbus.UserID,
+		"action":      r.action,
+		"attempt":     plumbus.Attempts,
+		"stuck_since": r.stuckSince,
+	}).Warn("Stuck plumbus reaped")
+
+	if s.eventsService != nil {
+		if err := s.eventsService.PublishPlumbusReaped(ctx, plumbus, r.action, r.stuckSince); err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbus.ID).Warn("Failed to publish stuck plumbus event")
+		}
+	}
+
+	if r.action == ReapRequeued {
+		s.run(ctx, plumbus)
+		return
+	}
+	if s.webhookService != nil {
+		s.webhookService.Dispatch(ctx, plumbus)
+	}
+}
+
+// running сообщает, выполняется ли генерация плюмбуса в этом экземпляре
+func (s *GenerationService) running(id uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.jobs[id]
+	return ok
+}
+
+// StartReaper периодически собирает зависшие плюмбусы, см. RunPeriodically
+func (s *GenerationService) StartReaper(ctx context.Context, interval time.Duration, onError func(error)) <-chan struct{} {
+	return RunPeriodically(ctx, interval, func(ctx context.Context) error {
+		_, err := s.Reap(ctx, time.Now())
+		return err
+	}, onError)
+}
+
+// WithAdvisoryLock выполняет fn в транзакции под advisory lock Postgres с ключом key.
+// Если блокировку держит другой экземпляр фабрики, fn не вызывается и возвращается
+// false. В SQLite (тесты, один процесс) блокировка не берется.
+func (s *UserService) WithAdvisoryLock(key int64, fn func(users *UserService) error) (bool, error) {
+	locked := true
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if tx.Name() != "sqlite" {
+			if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", key).Scan(&locked).Error; err != nil {
+				return err
+			}
+			if !locked {
+				return nil
+			}
+		}
+		return fn(&UserService{db: tx, logger: s.logger, rarity: s.rarity})
+	})
+	return locked, err
+}
+
+// GetStuckPlumbuses возвращает неудаленные плюмбусы в ожидании или генерации, не
+// обновлявшиеся с before
+func (s *UserService) GetStuckPlumbuses(before time.Time) ([]models.Plumbus, error) {
+	query := s.db.Where("status IN ? AND updated_at < ?",
+		[]models.PlumbusStatus{models.StatusPending, models.StatusGenerating}, before).Order("updated_at")
+
+	if s.db.Name() == "sqlite" {
+		var sqlitePlumbuses []SQLitePlumbus
+		if err := query.Find(&sqlitePlumbuses).Error; err != nil {
+			return nil, err
+		}
+		plumbuses := make([]models.Plumbus, len(sqlitePlumbuses))
+		for i, sp := range sqlitePlumbuses {
+			plumbuses[i] = sp.toModel()
+		}
+		return plumbuses, nil
+	}
+
+	var plumbuses []models.Plumbus
+	err := query.Find(&plumbuses).Error
+	return plumbuses, err
+}
+
+// RequeueStuckPlumbus возвращает зависший плюмбус в ожидание следующей попытки. Возвращает
+// false, если плюмбус уже не в ожидании или генерации либо обновился после before.
+func (s *UserService) RequeueStuckPlumbus(id uuid.UUID, before time.Time) (bool, error) {
+	return s.updateStuckPlumbus(id, before, map[string]interface{}{
+		"status":      models.StatusPending,
+		"attempts":    gorm.Expr("attempts + 1"),
+		"error_msg":   nil,
+		"interrupted": false,
+	})
+}
+
+// FailStuckPlumbus завершает зависший плюмбус ошибкой errorMsg на тех же условиях,
+// что и RequeueStuckPlumbus
+func (s *UserService) FailStuckPlumbus(id uuid.UUID, before time.Time, errorMsg string) (bool, error) {
+	return s.updateStuckPlumbus(id, before, map[string]interface{}{
+		"status":      models.StatusFailed,
+		"error_msg":   errorMsg,
+		"interrupted": false,
+	})
+}
+
+// updateStuckPlumbus применяет updates, только если плюмбус все еще завис
+func (s *UserService) updateStuckPlumbus(id uuid.UUID, before time.Time, updates map[string]interface{}) (bool, error) {
+	running := []models.PlumbusStatus{models.StatusPending, models.StatusGenerating}
+
+	var result *gorm.DB
+	if s.db.Name() == "sqlite" {
+		result = s.db.Model(&SQLitePlumbus{}).
+			Where("id = ? AND status IN ? AND updated_at < ?", testutils.SQLiteUUID(id), running, before).
+			Updates(updates)
+	} else {
+		result = s.db.Model(&models.Plumbus{}).
+			Where("id = ? AND status IN ? AND updated_at < ?", id, running, before).
+			Updates(updates)
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// setupReaper создает сервис генерации с недоступным генератором и моком NATS
+func setupReaper(t *testing.T) (*GenerationService, *UserService, *models.User, *MockNATSConn) {
+	t.Helper()
+	generator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(generator.Close)
+
+	service, us, user := setupGenerationService(t, generator.URL, "")
+	conn := &MockNATSConn{}
+	service.eventsService = &EventsService{
+		conn:   conn,
+		config: &config.Config{NatsTopic: "test-topic", EventSource: "factory"},
+		logger: logrus.New(),
+	}
+	return service, us, user, conn
+}
+
+// createStuckPlumbus создает плюмбус в статусе status с attempts попытками
+func createStuckPlumbus(t *testing.T, us *UserService, user *models.User, status models.PlumbusStatus, attempts int, interrupted bool) *models.Plumbus {
+	t.Helper()
+	plumbus, err := us.CreatePlumbus(user.ID, cancelRequest)
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+	if err := us.db.Exec("UPDATE plumbus SET status = ?, attempts = ?, interrupted = ? WHERE id = ?",
+		status, attempts, interrupted, plumbus.ID.String()).Error; err != nil {
+		t.Fatalf("Failed to update plumbus: %v", err)
+	}
+	return plumbus
+}
+
+func TestGenerationService_Reap(t *testing.T) {
+	service, us, user, conn := setupReaper(t)
+
+	requeued := createStuckPlumbus(t, us, user, models.StatusGenerating, 1, false)
+	exhausted := createStuckPlumbus(t, us, user, models.StatusGenerating, service.MaxAttempts, false)
+	interrupted := createStuckPlumbus(t, us, user, models.StatusPending, 2, true)
+	completed := createStuckPlumbus(t, us, user, models.StatusCompleted, 1, false)
+
+	// Только что обновленные плюмбусы не зависли
+	if result, err := service.Reap(context.Background(), time.Now().UTC()); err != nil || result != (ReapResult{}) {
+		t.Fatalf("Reap() of fresh plumbuses = %+v, %v, want nothing reaped", result, err)
+	}
+
+	// Триггер тестовой схемы не дает состарить updated_at, поэтому сдвигаем время прохода
+	result, err := service.Reap(context.Background(), time.Now().UTC().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Reap() error = %v", err)
+	}
+	if result.Requeued != 2 || result.Failed != 1 {
+		t.Errorf("Reap() = %+v, want 2 requeued and 1 failed", result)
+	}
+	if err := service.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	// Повтор расходует попытку, зависшая попытка записывается как неудавшаяся
+	attempts, _ := us.GetPlumbusAttempts(requeued.ID)
+	if len(attempts) != 2 || attempts[0].Attempt != 1 || !strings.Contains(attempts[0].Error, "did not finish") {
+		t.Errorf("requeued attempts = %+v, want the stuck attempt and the retried one", attempts)
+	}
+	if stored, _ := us.GetPlumbus(requeued.ID); stored.Attempts != 2 {
+		t.Errorf("requeued attempts counter = %d, want 2", stored.Attempts)
+	}
+
+	stored, _ := us.GetPlumbus(exhausted.ID)
+	if stored.Status != models.StatusFailed || stored.ErrorMsg == nil || !strings.Contains(*stored.ErrorMsg, "did not finish within 15m0s") {
+		t.Errorf("exhausted plumbus = %s, %v, want failed with a stuck error", stored.Status, stored.ErrorMsg)
+	}
+
+	// Прерванная генерация продолжается той же попыткой
+	stored, _ = us.GetPlumbus(interrupted.ID)
+	if stored.Attempts != 2 || stored.Interrupted {
+		t.Errorf("interrupted plumbus attempts = %d, interrupted = %v, want 2 and false", stored.Attempts, stored.Interrupted)
+	}
+
+	if stored, _ := us.GetPlumbus(completed.ID); stored.Status != models.StatusCompleted {
+		t.Errorf("completed plumbus status = %s, want untouched", stored.Status)
+	}
+
+	types := make(map[string]int)
+	for _, msg := range conn.PublishedMessages {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		types[event.Type]++
+	}
+	if types["plumbus.requeued"] != 2 || types["plumbus.failed"] != 1 {
+		t.Errorf("published events = %v, want 2 plumbus.requeued and 1 plumbus.failed", types)
+	}
+}
+
+func TestGenerationService_ReapSkipsRunningGenerations(t *testing.T) {
+	service, us, user, _ := setupReaper(t)
+	plumbus := createStuckPlumbus(t, us, user, models.StatusGenerating, 1, false)
+
+	// Генерация этого экземпляра еще выполняется
+	service.jobs[plumbus.ID] = func(error) {}
+
+	result, err := service.Reap(context.Background(), time.Now().UTC().Add(time.Hour))
+	if err != nil || result != (ReapResult{}) {
+		t.Errorf("Reap() = %+v, %v, want running generation skipped", result, err)
+	}
+	delete(service.jobs, plumbus.ID)
+}
+
+func TestUserService_StuckPlumbusUpdatesAreConditional(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	us := NewUserService(db)
+	plumbus := createTestPlumbus(t, db, user.ID)
+
+	// Плюмбус обновился после выборки - сборщик его не трогает
+	ok, err := us.RequeueStuckPlumbus(plumbus.ID, time.Now().UTC().Add(-time.Hour))
+	if err != nil || ok {
+		t.Errorf("RequeueStuckPlumbus() of fresh plumbus = %v, %v, want false", ok, err)
+	}
+	if ok, err := us.FailStuckPlumbus(uuid.New(), time.Now().UTC().Add(time.Hour), "stuck"); err != nil || ok {
+		t.Errorf("FailStuckPlumbus() of unknown plumbus = %v, %v, want false", ok, err)
+	}
+
+	locked, err := us.WithAdvisoryLock(reaperLockKey, func(*UserService) error { return nil })
+	if err != nil || !locked {
+		t.Errorf("WithAdvisoryLock() on SQLite = %v, %v, want the lock taken", locked, err)
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,11 +13,15 @@ import (
 	"time"
 
 	"factory/internal/config"
+	"factory/internal/metrics"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type SignatureService struct {
-	config *config.Config
-	client *http.Client
+	config  *config.Config
+	client  *http.Client
+	timeout *requestTimeout
 }
 
 type SignatureResponse struct {
@@ -29,12 +34,40 @@ func NewSignatureService(cfg *config.Config) *SignatureService {
 	return &SignatureService{
 		config: cfg,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
+		timeout: newRequestTimeout(cfg.SigStoreTimeout),
 	}
 }
 
-func (s *SignatureService) SignFile(filePath string) (*SignatureResponse, error) {
+// SetTimeout заменяет таймаут запросов к sig-store. Действует на новые запросы.
+func (s *SignatureService) SetTimeout(d time.Duration) {
+	s.timeout.set(d)
+}
+
+// Timeout возвращает текущий таймаут запросов к sig-store
+func (s *SignatureService) Timeout() time.Duration {
+	return s.timeout.get()
+}
+
+// Ping проверяет доступность sig-store
+func (s *SignatureService) Ping(ctx context.Context) error {
+	return pingHTTP(ctx, s.client, s.config.SigStoreURL+"/health")
+}
+
+// SignFile регистрирует файл в sig-store и возвращает его подпись.
+// Запрос прерывается при отмене ctx.
+func (s *SignatureService) SignFile(ctx context.Context, filePath string) (*SignatureResponse, error) {
+	start := time.Now()
+	signature, err := s.sign(ctx, filePath)
+	metrics.SigningDuration.WithLabelValues(metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+	return signature, err
+}
+
+func (s *SignatureService) sign(ctx context.Context, filePath string) (*SignatureResponse, error) {
+	ctx, cancel := s.timeout.context(ctx)
+	defer cancel()
+
 	// Открываем файл для чтения
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -64,7 +97,7 @@ func (s *SignatureService) SignFile(filePath string) (*SignatureResponse, error)
 
 	// Отправляем запрос к sig-store
 	url := fmt.Sprintf("%s/api/v1/register", s.config.SigStoreURL)
-	req, err := http.NewRequest("POST", url, &requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -92,7 +125,11 @@ func (s *SignatureService) SignFile(filePath string) (*SignatureResponse, error)
 	return &sigResponse, nil
 }
 
-func (s *SignatureService) VerifySignature(filePath, signature string) (bool, error) {
+// VerifySignature проверяет подпись файла в sig-store
+func (s *SignatureService) VerifySignature(ctx context.Context, filePath, signature string) (bool, error) {
+	ctx, cancel := s.timeout.context(ctx)
+	defer cancel()
+
 	// Открываем файл для чтения
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -128,7 +165,7 @@ func (s *SignatureService) VerifySignature(filePath, signature string) (bool, er
 
 	// Отправляем запрос к sig-store
 	url := fmt.Sprintf("%s/api/v1/verify", s.config.SigStoreURL)
-	req, err := http.NewRequest("POST", url, &requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &requestBody)
 	if err != nil {
 		return false, fmt.Errorf("failed to create request: %w", err)
 	}
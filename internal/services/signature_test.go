@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,8 +31,8 @@ func TestNewSignatureService(t *testing.T) {
 		t.Error("NewSignatureService() did not initialize HTTP client")
 	}
 
-	if service.client.Timeout != 30*time.Second {
-		t.Errorf("NewSignatureService() timeout = %v, want %v", service.client.Timeout, 30*time.Second)
+	if service.Timeout() != 30*time.Second {
+		t.Errorf("NewSignatureService() timeout = %v, want %v", service.Timeout(), 30*time.Second)
 	}
 }
 
@@ -63,7 +64,7 @@ func TestSignatureService_SignFile_Success(t *testing.T) {
 	service.client.Transport = mockRT
 
 	// Выполняем подписание файла
-	result, err := service.SignFile(testFile)
+	result, err := service.SignFile(context.Background(), testFile)
 
 	// Проверяем что ошибки нет
 	if err != nil {
@@ -115,7 +116,7 @@ func TestSignatureService_SignFile_FileNotFound(t *testing.T) {
 	service := NewSignatureService(cfg)
 
 	// Пытаемся подписать несуществующий файл
-	_, err := service.SignFile("/nonexistent/file.png")
+	_, err := service.SignFile(context.Background(), "/nonexistent/file.png")
 
 	// Проверяем что вернулась ошибка
 	if err == nil {
@@ -150,7 +151,7 @@ func TestSignatureService_SignFile_ServiceError(t *testing.T) {
 	service.client.Transport = mockRT
 
 	// Выполняем подписание файла
-	_, err = service.SignFile(testFile)
+	_, err = service.SignFile(context.Background(), testFile)
 
 	// Проверяем что вернулась ошибка
 	if err == nil {
@@ -189,7 +190,7 @@ func TestSignatureService_VerifySignature_Success(t *testing.T) {
 	service.client.Transport = mockRT
 
 	// Выполняем верификацию подписи
-	isValid, err := service.VerifySignature(testFile, "test-signature")
+	isValid, err := service.VerifySignature(context.Background(), testFile, "test-signature")
 
 	// Проверяем что ошибки нет
 	if err != nil {
@@ -247,7 +248,7 @@ func TestSignatureService_VerifySignature_Invalid(t *testing.T) {
 	service.client.Transport = mockRT
 
 	// Выполняем верификацию подписи
-	isValid, err := service.VerifySignature(testFile, "invalid-signature")
+	isValid, err := service.VerifySignature(context.Background(), testFile, "invalid-signature")
 
 	// Проверяем что ошибки нет
 	if err != nil {
@@ -268,7 +269,7 @@ func TestSignatureService_VerifySignature_FileNotFound(t *testing.T) {
 	service := NewSignatureService(cfg)
 
 	// Пытаемся верифицировать несуществующий файл
-	_, err := service.VerifySignature("/nonexistent/file.png", "test-signature")
+	_, err := service.VerifySignature(context.Background(), "/nonexistent/file.png", "test-signature")
 
 	// Проверяем что вернулась ошибка
 	if err == nil {
@@ -303,7 +304,7 @@ func TestSignatureService_VerifySignature_ServiceError(t *testing.T) {
 	service.client.Transport = mockRT
 
 	// Выполняем верификацию подписи
-	_, err = service.VerifySignature(testFile, "test-signature")
+	_, err = service.VerifySignature(context.Background(), testFile, "test-signature")
 
 	// Проверяем что вернулась ошибка
 	if err == nil {
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRequestTimeout - таймаут запросов к генератору и sig-store по умолчанию
+const DefaultRequestTimeout = 30 * time.Second
+
+// requestTimeout - таймаут запросов к внешнему сервису. Применяется через
+// дедлайн контекста, а не http.Client.Timeout, чтобы его можно было менять во
+// время работы без гонок с выполняющимися запросами.
+type requestTimeout struct {
+	value atomic.Int64
+}
+
+func newRequestTimeout(d time.Duration) *requestTimeout {
+	t := &requestTimeout{}
+	if d <= 0 {
+		d = DefaultRequestTimeout
+	}
+	t.set(d)
+	return t
+}
+
+func (t *requestTimeout) set(d time.Duration) {
+	t.value.Store(int64(d))
+}
+
+func (t *requestTimeout) get() time.Duration {
+	return time.Duration(t.value.Load())
+}
+
+// context ограничивает ctx текущим таймаутом
+func (t *requestTimeout) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.get())
+}
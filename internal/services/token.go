@@ -0,0 +1,155 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"factory/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Разрешения (scopes) персональных токенов доступа
+const (
+	// ScopePlumbusRead - просмотр плюмбусов
+	ScopePlumbusRead = "plumbus:read"
+	// ScopePlumbusWrite - создание плюмбусов
+	ScopePlumbusWrite = "plumbus:write"
+)
+
+// TokenPrefix - префикс персональных токенов, по которому их отличают от токенов Keycloak
+const TokenPrefix = "pat_"
+
+// tokenDisplayLength - сколько символов токена сохраняется для отображения в списке
+const tokenDisplayLength = 12
+
+var (
+	// ErrTokenInvalid возвращается для неизвестного, отозванного или истекшего токена
+	ErrTokenInvalid = errors.New("invalid API token")
+	// ErrTokenNotFound возвращается, если токен не найден среди токенов пользователя
+	ErrTokenNotFound = errors.New("API token not found")
+	// ErrUnknownScope возвращается при запросе неизвестного разрешения
+	ErrUnknownScope = errors.New("unknown scope")
+)
+
+// knownScopes - все поддерживаемые разрешения
+var knownScopes = map[string]bool{
+	ScopePlumbusRead:  true,
+	ScopePlumbusWrite: true,
+}
+
+// TokenService управляет персональными токенами доступа к API
+type TokenService struct {
+	db *gorm.DB
+}
+
+func NewTokenService(db *gorm.DB) *TokenService {
+	return &TokenService{db: db}
+}
+
+// CreateToken выпускает новый токен. Открытое значение возвращается только один раз,
+// в БД сохраняется его SHA-256 хэш. ttl = 0 означает бессрочный токен.
+func (s *TokenService) CreateToken(userID uuid.UUID, name string, scopes []string, ttl time.Duration) (string, *models.APIToken, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil, errors.New("token name is required")
+	}
+	if len(scopes) == 0 {
+		return "", nil, errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !knownScopes[scope] {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	plain := TokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	token := &models.APIToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    plain[:tokenDisplayLength],
+		TokenHash: hashToken(plain),
+		Scopes:    strings.Join(scopes, " "),
+		CreatedAt: time.Now(),
+	}
+	if ttl > 0 {
+		expiresAt := token.CreatedAt.Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.Create(token).Error; err != nil {
+		return "", nil, err
+	}
+	return plain, token, nil
+}
+
+// ListTokens возвращает токены пользователя, включая отозванные
+func (s *TokenService) ListTokens(userID uuid.UUID) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := s.db.Where("user_id = ?", userID).Order("created_at desc").Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeToken отзывает токен пользователя
+func (s *TokenService) RevokeToken(userID, tokenID uuid.UUID) error {
+	result := s.db.Model(&models.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate находит действующий токен по открытому значению и отмечает его использование
+func (s *TokenService) Authenticate(plain string) (*models.APIToken, error) {
+	if !strings.HasPrefix(plain, TokenPrefix) {
+		return nil, ErrTokenInvalid
+	}
+
+	var token models.APIToken
+	err := s.db.Where("token_hash = ?", hashToken(plain)).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil || (token.ExpiresAt != nil && !token.ExpiresAt.After(now)) {
+		return nil, ErrTokenInvalid
+	}
+
+	// Время последнего использования не критично, ошибку обновления игнорируем
+	s.db.Model(&models.APIToken{}).Where("id = ?", token.ID).Update("last_used_at", now)
+	token.LastUsedAt = &now
+
+	return &token, nil
+}
+
+// TokenScopes возвращает список разрешений токена
+func TokenScopes(token *models.APIToken) []string {
+	return strings.Fields(token.Scopes)
+}
+
+// hashToken возвращает SHA-256 хэш токена в hex
+func hashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
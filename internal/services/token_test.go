@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"factory/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func setupTokenService(t *testing.T) (*TokenService, *gorm.DB) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.APIToken{}); err != nil {
+		t.Fatalf("Failed to migrate API tokens table: %v", err)
+	}
+	return NewTokenService(db), db
+}
+
+func TestTokenService_CreateAndAuthenticate(t *testing.T) {
+	service, db := setupTokenService(t)
+	userID := uuid.New()
+
+	plain, token, err := service.CreateToken(userID, "ci", []string{ScopePlumbusRead}, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	if !strings.HasPrefix(plain, TokenPrefix) {
+		t.Errorf("token = %q, want prefix %q", plain, TokenPrefix)
+	}
+	if !strings.HasPrefix(plain, token.Prefix) {
+		t.Errorf("Prefix = %q is not a prefix of the token", token.Prefix)
+	}
+
+	// В БД хранится только хэш
+	var stored models.APIToken
+	if err := db.First(&stored, "id = ?", token.ID).Error; err != nil {
+		t.Fatalf("Failed to load stored token: %v", err)
+	}
+	if stored.TokenHash == plain || strings.Contains(stored.TokenHash, plain[len(TokenPrefix):]) {
+		t.Error("plain token is stored in the database")
+	}
+
+	authenticated, err := service.Authenticate(plain)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if authenticated.UserID != userID {
+		t.Errorf("UserID = %v, want %v", authenticated.UserID, userID)
+	}
+	if scopes := TokenScopes(authenticated); len(scopes) != 1 || scopes[0] != ScopePlumbusRead {
+		t.Errorf("TokenScopes() = %v, want [%s]", scopes, ScopePlumbusRead)
+	}
+	if authenticated.LastUsedAt == nil {
+		t.Error("LastUsedAt is not set after Authenticate()")
+	}
+}
+
+func TestTokenService_CreateToken_Validation(t *testing.T) {
+	service, _ := setupTokenService(t)
+
+	tests := []struct {
+		name   string
+		tName  string
+		scopes []string
+	}{
+		{"empty name", " ", []string{ScopePlumbusRead}},
+		{"no scopes", "ci", nil},
+		{"unknown scope", "ci", []string{"admin:all"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := service.CreateToken(uuid.New(), tt.tName, tt.scopes, 0); err == nil {
+				t.Error("CreateToken() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTokenService_Authenticate_Rejected(t *testing.T) {
+	service, db := setupTokenService(t)
+	userID := uuid.New()
+
+	revoked, revokedToken, _ := service.CreateToken(userID, "revoked", []string{ScopePlumbusRead}, 0)
+	if err := service.RevokeToken(userID, revokedToken.ID); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+
+	expired, expiredToken, _ := service.CreateToken(userID, "expired", []string{ScopePlumbusRead}, time.Hour)
+	db.Model(&models.APIToken{}).Where("id = ?", expiredToken.ID).Update("expires_at", time.Now().Add(-time.Minute))
+
+	for name, plain := range map[string]string{
+		"revoked":     revoked,
+		"expired":     expired,
+		"unknown":     TokenPrefix + "unknown",
+		"no prefix":   "eyJhbGciOi",
+		"tampered":    revoked[:len(revoked)-1] + "x",
+		"empty":       "",
+		"only prefix": TokenPrefix,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := service.Authenticate(plain); !errors.Is(err, ErrTokenInvalid) {
+				t.Errorf("Authenticate() error = %v, want ErrTokenInvalid", err)
+			}
+		})
+	}
+}
+
+func TestTokenService_RevokeToken_OtherUser(t *testing.T) {
+	service, _ := setupTokenService(t)
+	owner := uuid.New()
+
+	plain, token, _ := service.CreateToken(owner, "ci", []string{ScopePlumbusRead}, 0)
+
+	if err := service.RevokeToken(uuid.New(), token.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("RevokeToken() by other user error = %v, want ErrTokenNotFound", err)
+	}
+	if _, err := service.Authenticate(plain); err != nil {
+		t.Errorf("token revoked by other user, Authenticate() error = %v", err)
+	}
+
+	tokens, err := service.ListTokens(owner)
+	if err != nil || len(tokens) != 1 {
+		t.Errorf("ListTokens() = %d tokens, %v, want 1, nil", len(tokens), err)
+	}
+}
@@ -1,23 +1,45 @@
 package services
 
 import (
+	"context"
+	"factory/internal/logger"
 	"factory/internal/models"
 	"factory/internal/testutils"
 
-	"log"
-	"math/rand"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type UserService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	logger *logrus.Logger
+	rarity *Rarity
 }
 
 func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+	return &UserService{db: db, logger: logger.For("users"), rarity: NewRarity(DefaultRareChance)}
+}
+
+// Rarity возвращает движок редкости, через который можно менять вероятность редкого плюмбуса
+func (s *UserService) Rarity() *Rarity {
+	return s.rarity
+}
+
+// WithContext возвращает сервис, запросы которого выполняются с ctx и попадают в его трейс
+func (s *UserService) WithContext(ctx context.Context) *UserService {
+	return &UserService{db: s.db.WithContext(ctx), logger: s.logger, rarity: s.rarity}
+}
+
+// logRarePlumbus отмечает в логах создание редкого плюмбуса
+func (s *UserService) logRarePlumbus(plumbus *models.Plumbus) {
+	s.logger.WithContext(s.db.Statement.Context).WithFields(logrus.Fields{
+		"plumbus_id": plumbus.ID,
+		"name":       plumbus.Name,
+		"user_id":    plumbus.UserID,
+	}).Info("🌟 Rare plumbus created")
 }
 
 // SQLiteUser структура для работы с SQLite
@@ -50,8 +72,12 @@ type SQLitePlumbus struct {
 	Signature     *string
 	SignatureDate *time.Time
 	ErrorMsg      *string
-	CreatedAt     time.Time `gorm:"not null"`
-	UpdatedAt     time.Time `gorm:"not null"`
+	Attempts      int            `gorm:"not null;default:1"`
+	Interrupted   bool           `gorm:"not null;default:false;index"`
+	Broken        bool           `gorm:"not null;default:false"`
+	CreatedAt     time.Time      `gorm:"not null"`
+	UpdatedAt     time.Time      `gorm:"not null"`
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
 }
 
 func (SQLitePlumbus) TableName() string {
@@ -129,9 +155,7 @@ func (s *UserService) GetOrCreateUser(keycloakID, username, email string) (*mode
 }
 
 func (s *UserService) CreatePlumbus(userID uuid.UUID, req models.PlumbusRequest) (*models.Plumbus, error) {
-	// Генерируем случайность для редкого плюмбуса (5% шанс)
-	rand.Seed(time.Now().UnixNano())
-	isRare := rand.Float64() < 0.05
+	isRare := s.rarity.Roll()
 
 	if s.db.Name() == "sqlite" {
 		newID := uuid.New()
@@ -146,6 +170,7 @@ func (s *UserService) CreatePlumbus(userID uuid.UUID, req models.PlumbusRequest)
 			Wrapping: req.Wrapping,
 			Status:   models.StatusPending,
 			IsRare:   isRare,
+			Attempts: 1,
 		}
 
 		if err := s.db.Create(&sqlitePlumbus).Error; err != nil {
@@ -163,13 +188,13 @@ func (s *UserService) CreatePlumbus(userID uuid.UUID, req models.PlumbusRequest)
 			Wrapping:  sqlitePlumbus.Wrapping,
 			Status:    sqlitePlumbus.Status,
 			IsRare:    sqlitePlumbus.IsRare,
+			Attempts:  sqlitePlumbus.Attempts,
 			CreatedAt: sqlitePlumbus.CreatedAt,
 			UpdatedAt: sqlitePlumbus.UpdatedAt,
 		}
 
-		// Логируем создание редкого плюмбуса
 		if isRare {
-			log.Printf("🌟 RARE PLUMBUS CREATED! ID: %s, Name: %s, User: %s", plumbus.ID, plumbus.Name, userID)
+			s.logRarePlumbus(plumbus)
 		}
 
 		return plumbus, nil
@@ -185,15 +210,15 @@ func (s *UserService) CreatePlumbus(userID uuid.UUID, req models.PlumbusRequest)
 		Wrapping: req.Wrapping,
 		Status:   models.StatusPending,
 		IsRare:   isRare,
+		Attempts: 1,
 	}
 
 	if err := s.db.Create(&plumbus).Error; err != nil {
 		return nil, err
 	}
 
-	// Логируем создание редкого плюмбуса
 	if isRare {
-		log.Printf("🌟 RARE PLUMBUS CREATED! ID: %s, Name: %s, User: %s", plumbus.ID, plumbus.Name, userID)
+		s.logRarePlumbus(&plumbus)
 	}
 
 	return &plumbus, nil
@@ -220,11 +245,36 @@ func (s *UserService) UpdatePlumbusStatus(id uuid.UUID, status models.PlumbusSta
 		updates["signature_date"] = *signatureDate
 	}
 
+	// Генерация могла завершиться уже после удаления плюмбуса - путь к изображению
+	// все равно сохраняем, чтобы очистка удалила файл. Отмененный плюмбус статус не меняет.
 	if s.db.Name() == "sqlite" {
-		return s.db.Model(&SQLitePlumbus{}).Where("id = ?", testutils.SQLiteUUID(id)).Updates(updates).Error
+		return s.db.Unscoped().Model(&SQLitePlumbus{}).
+			Where("id = ? AND status <> ?", testutils.SQLiteUUID(id), models.StatusCancelled).Updates(updates).Error
 	}
 
-	return s.db.Model(&models.Plumbus{}).Where("id = ?", id).Updates(updates).Error
+	return s.db.Unscoped().Model(&models.Plumbus{}).
+		Where("id = ? AND status <> ?", id, models.StatusCancelled).Updates(updates).Error
+}
+
+// CancelPlumbus переводит плюмбус в статус cancelled, если его генерация еще не завершилась.
+// Возвращает false, если плюмбус уже в конечном статусе.
+func (s *UserService) CancelPlumbus(id uuid.UUID) (bool, error) {
+	running := []models.PlumbusStatus{models.StatusPending, models.StatusGenerating}
+
+	var result *gorm.DB
+	if s.db.Name() == "sqlite" {
+		result = s.db.Model(&SQLitePlumbus{}).
+			Where("id = ? AND status IN ?", testutils.SQLiteUUID(id), running).
+			Update("status", models.StatusCancelled)
+	} else {
+		result = s.db.Model(&models.Plumbus{}).
+			Where("id = ? AND status IN ?", id, running).
+			Update("status", models.StatusCancelled)
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
 
 func (s *UserService) GetPlumbus(id uuid.UUID) (*models.Plumbus, error) {
@@ -250,6 +300,9 @@ func (s *UserService) GetPlumbus(id uuid.UUID) (*models.Plumbus, error) {
 			Signature:     sqlitePlumbus.Signature,
 			SignatureDate: sqlitePlumbus.SignatureDate,
 			ErrorMsg:      sqlitePlumbus.ErrorMsg,
+			Attempts:      sqlitePlumbus.Attempts,
+			Interrupted:   sqlitePlumbus.Interrupted,
+			Broken:        sqlitePlumbus.Broken,
 			CreatedAt:     sqlitePlumbus.CreatedAt,
 			UpdatedAt:     sqlitePlumbus.UpdatedAt,
 		}, nil
@@ -311,6 +364,9 @@ func (s *UserService) GetUserPlumbuses(userID uuid.UUID) ([]models.Plumbus, erro
 				Signature:     sp.Signature,
 				SignatureDate: sp.SignatureDate,
 				ErrorMsg:      sp.ErrorMsg,
+				Attempts:      sp.Attempts,
+				Interrupted:   sp.Interrupted,
+				Broken:        sp.Broken,
 				CreatedAt:     sp.CreatedAt,
 				UpdatedAt:     sp.UpdatedAt,
 			}
@@ -322,3 +378,213 @@ func (s *UserService) GetUserPlumbuses(userID uuid.UUID) ([]models.Plumbus, erro
 	err := s.db.Where("user_id = ?", userID).Order("created_at desc").Find(&plumbuses).Error
 	return plumbuses, err
 }
+
+// DeletePlumbus мягко удаляет плюмбус: он скрывается из выборок, но остается в БД
+func (s *UserService) DeletePlumbus(id uuid.UUID) error {
+	var result *gorm.DB
+	if s.db.Name() == "sqlite" {
+		result = s.db.Delete(&SQLitePlumbus{}, "id = ?", testutils.SQLiteUUID(id))
+	} else {
+		result = s.db.Delete(&models.Plumbus{}, "id = ?", id)
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RestorePlumbus отменяет мягкое удаление плюмбуса
+func (s *UserService) RestorePlumbus(id uuid.UUID) error {
+	var result *gorm.DB
+	if s.db.Name() == "sqlite" {
+		result = s.db.Unscoped().Model(&SQLitePlumbus{}).
+			Where("id = ? AND deleted_at IS NOT NULL", testutils.SQLiteUUID(id)).Update("deleted_at", nil)
+	} else {
+		result = s.db.Unscoped().Model(&models.Plumbus{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// HardDeletePlumbus безвозвратно удаляет запись плюмбуса, в том числе мягко удаленную,
+// вместе с историей попыток генерации
+func (s *UserService) HardDeletePlumbus(id uuid.UUID) error {
+	// Внутри транзакции очистки это точка сохранения: ошибка откатывает только этот плюмбус
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("plumbus_id = ?", id).Delete(&models.PlumbusAttempt{}).Error; err != nil {
+			return err
+		}
+		if tx.Name() == "sqlite" {
+			return tx.Unscoped().Delete(&SQLitePlumbus{}, "id = ?", testutils.SQLiteUUID(id)).Error
+		}
+		return tx.Unscoped().Delete(&models.Plumbus{}, "id = ?", id).Error
+	})
+}
+
+// GetPlumbusIncludingDeleted возвращает плюмбус по ID, в том числе мягко удаленный
+func (s *UserService) GetPlumbusIncludingDeleted(id uuid.UUID) (*models.Plumbus, error) {
+	if s.db.Name() == "sqlite" {
+		var sqlitePlumbus SQLitePlumbus
+		if err := s.db.Unscoped().First(&sqlitePlumbus, "id = ?", testutils.SQLiteUUID(id)).Error; err != nil {
+			return nil, err
+		}
+		plumbus := sqlitePlumbus.toModel()
+		return &plumbus, nil
+	}
+
+	var plumbus models.Plumbus
+	if err := s.db.Unscoped().First(&plumbus, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &plumbus, nil
+}
+
+// GetPlumbusesDeletedBefore возвращает плюмбусы, мягко удаленные раньше указанного времени
+func (s *UserService) GetPlumbusesDeletedBefore(before time.Time) ([]models.Plumbus, error) {
+	if s.db.Name() == "sqlite" {
+		var sqlitePlumbuses []SQLitePlumbus
+		err := s.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Find(&sqlitePlumbuses).Error
+		if err != nil {
+			return nil, err
+		}
+
+		plumbuses := make([]models.Plumbus, len(sqlitePlumbuses))
+		for i, sp := range sqlitePlumbuses {
+			plumbuses[i] = sp.toModel()
+		}
+		return plumbuses, nil
+	}
+
+	var plumbuses []models.Plumbus
+	err := s.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Find(&plumbuses).Error
+	return plumbuses, err
+}
+
+// toModel преобразует строку SQLite в модель плюмбуса
+func (sp SQLitePlumbus) toModel() models.Plumbus {
+	return models.Plumbus{
+		ID:            uuid.UUID(sp.ID),
+		UserID:        uuid.UUID(sp.UserID),
+		Name:          sp.Name,
+		Size:          sp.Size,
+		Color:         sp.Color,
+		Shape:         sp.Shape,
+		Weight:        sp.Weight,
+		Wrapping:      sp.Wrapping,
+		Status:        sp.Status,
+		IsRare:        sp.IsRare,
+		ImagePath:     sp.ImagePath,
+		Signature:     sp.Signature,
+		SignatureDate: sp.SignatureDate,
+		ErrorMsg:      sp.ErrorMsg,
+		Attempts:      sp.Attempts,
+		Interrupted:   sp.Interrupted,
+		Broken:        sp.Broken,
+		CreatedAt:     sp.CreatedAt,
+		UpdatedAt:     sp.UpdatedAt,
+		DeletedAt:     sp.DeletedAt,
+	}
+}
+
+// RetryPlumbus переводит неудавшийся плюмбус в ожидание следующей попытки и увеличивает
+// счетчик попыток. Возвращает false, если плюмбус не в статусе failed или лимит попыток исчерпан.
+func (s *UserService) RetryPlumbus(id uuid.UUID, maxAttempts int) (bool, error) {
+	updates := map[string]interface{}{
+		"status":    models.StatusPending,
+		"attempts":  gorm.Expr("attempts + 1"),
+		"error_msg": nil,
+	}
+
+	var result *gorm.DB
+	if s.db.Name() == "sqlite" {
+		result = s.db.Model(&SQLitePlumbus{}).
+			Where("id = ? AND status = ? AND attempts < ?", testutils.SQLiteUUID(id), models.StatusFailed, maxAttempts).
+			Updates(updates)
+	} else {
+		result = s.db.Model(&models.Plumbus{}).
+			Where("id = ? AND status = ? AND attempts < ?", id, models.StatusFailed, maxAttempts).
+			Updates(updates)
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreatePlumbusAttempt сохраняет результат попытки генерации в истории плюмбуса
+func (s *UserService) CreatePlumbusAttempt(attempt *models.PlumbusAttempt) error {
+	if attempt.ID == uuid.Nil {
+		attempt.ID = uuid.New()
+	}
+	return s.db.Create(attempt).Error
+}
+
+// GetPlumbusAttempts возвращает историю попыток генерации плюмбуса, от первой к последней
+func (s *UserService) GetPlumbusAttempts(plumbusID uuid.UUID) ([]models.PlumbusAttempt, error) {
+	var attempts []models.PlumbusAttempt
+	err := s.db.Where("plumbus_id = ?", plumbusID).Order("attempt, started_at").Find(&attempts).Error
+	return attempts, err
+}
+
+// InterruptPlumbus возвращает незавершенный плюмбус в ожидание и помечает его для продолжения
+// генерации после перезапуска фабрики
+func (s *UserService) InterruptPlumbus(id uuid.UUID) error {
+	running := []models.PlumbusStatus{models.StatusPending, models.StatusGenerating}
+	updates := map[string]interface{}{
+		"status":      models.StatusPending,
+		"interrupted": true,
+	}
+
+	if s.db.Name() == "sqlite" {
+		return s.db.Unscoped().Model(&SQLitePlumbus{}).
+			Where("id = ? AND status IN ?", testutils.SQLiteUUID(id), running).Updates(updates).Error
+	}
+	return s.db.Unscoped().Model(&models.Plumbus{}).
+		Where("id = ? AND status IN ?", id, running).Updates(updates).Error
+}
+
+// GetInterruptedPlumbuses возвращает плюмбусы, генерация которых прервана остановкой фабрики
+func (s *UserService) GetInterruptedPlumbuses() ([]models.Plumbus, error) {
+	if s.db.Name() == "sqlite" {
+		var sqlitePlumbuses []SQLitePlumbus
+		if err := s.db.Where("interrupted = ?", true).Order("created_at").Find(&sqlitePlumbuses).Error; err != nil {
+			return nil, err
+		}
+
+		plumbuses := make([]models.Plumbus, len(sqlitePlumbuses))
+		for i, sp := range sqlitePlumbuses {
+			plumbuses[i] = sp.toModel()
+		}
+		return plumbuses, nil
+	}
+
+	var plumbuses []models.Plumbus
+	err := s.db.Where("interrupted = ?", true).Order("created_at").Find(&plumbuses).Error
+	return plumbuses, err
+}
+
+// ClaimInterruptedPlumbus снимает пометку о прерванной генерации. Возвращает false, если
+// плюмбус уже забрал другой экземпляр фабрики.
+func (s *UserService) ClaimInterruptedPlumbus(id uuid.UUID) (bool, error) {
+	var result *gorm.DB
+	if s.db.Name() == "sqlite" {
+		result = s.db.Model(&SQLitePlumbus{}).
+			Where("id = ? AND interrupted = ?", testutils.SQLiteUUID(id), true).Update("interrupted", false)
+	} else {
+		result = s.db.Model(&models.Plumbus{}).
+			Where("id = ? AND interrupted = ?", id, true).Update("interrupted", false)
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
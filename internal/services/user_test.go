@@ -435,3 +435,41 @@ func TestUserService_CreatePlumbus_RarenessProbability(t *testing.T) {
 
 	t.Logf("Created %d rare plumbuses out of %d total (%.1f%%)", rareCount, totalCount, float64(rareCount)/float64(totalCount)*100)
 }
+
+func TestUserService_RetryPlumbus(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewUserService(db)
+	user := createTestUser(t, db)
+
+	plumbus, err := service.CreatePlumbus(user.ID, models.PlumbusRequest{
+		Name: "Retry", Size: "M", Color: "pink", Shape: "smooth", Weight: "light", Wrapping: "default",
+	})
+	if err != nil {
+		t.Fatalf("CreatePlumbus() error = %v", err)
+	}
+	if plumbus.Attempts != 1 {
+		t.Errorf("new plumbus Attempts = %d, want 1", plumbus.Attempts)
+	}
+
+	// Плюмбус в ожидании повторить нельзя
+	if ok, err := service.RetryPlumbus(plumbus.ID, 3); err != nil || ok {
+		t.Errorf("RetryPlumbus() of pending plumbus = %v, %v, want false", ok, err)
+	}
+
+	errorMsg := "generator unavailable"
+	service.UpdatePlumbusStatus(plumbus.ID, models.StatusFailed, nil, &errorMsg, nil, nil)
+	if ok, err := service.RetryPlumbus(plumbus.ID, 2); err != nil || !ok {
+		t.Fatalf("RetryPlumbus() = %v, %v, want true", ok, err)
+	}
+
+	retried, _ := service.GetPlumbus(plumbus.ID)
+	if retried.Status != models.StatusPending || retried.Attempts != 2 || retried.ErrorMsg != nil {
+		t.Errorf("retried plumbus = %+v, want pending attempt 2 without error", retried)
+	}
+
+	// Лимит попыток исчерпан
+	service.UpdatePlumbusStatus(plumbus.ID, models.StatusFailed, nil, &errorMsg, nil, nil)
+	if ok, err := service.RetryPlumbus(plumbus.ID, 2); err != nil || ok {
+		t.Errorf("RetryPlumbus() beyond limit = %v, %v, want false", ok, err)
+	}
+}
@@ -0,0 +1,545 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"factory/internal/logger"
+	"factory/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// События, на которые можно подписать webhook
+const (
+	// EventPlumbusCompleted - генерация плюмбуса завершена
+	EventPlumbusCompleted = "plumbus.completed"
+	// EventPlumbusFailed - генерация плюмбуса завершилась ошибкой
+	EventPlumbusFailed = "plumbus.failed"
+)
+
+// Заголовки запроса доставки webhook
+const (
+	WebhookSignatureHeader = "X-Factory-Signature"
+	WebhookEventHeader     = "X-Factory-Event"
+	WebhookDeliveryHeader  = "X-Factory-Delivery"
+)
+
+// webhookSecretPrefix - префикс секрета подписи webhook
+const webhookSecretPrefix = "whsec_"
+
+var (
+	// ErrWebhookNotFound возвращается, если webhook не найден среди webhook пользователя
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrUnknownEvent возвращается при подписке на неизвестное событие
+	ErrUnknownEvent = errors.New("unknown event")
+	// ErrInvalidWebhookURL возвращается для URL, отличного от абсолютного http(s)
+	ErrInvalidWebhookURL = errors.New("webhook URL must be an absolute http or https URL")
+	// ErrWebhookHostNotAllowed возвращается для webhook в loopback, частные и link-local
+	// адреса: иначе через webhook можно обращаться к внутренним сервисам фабрики
+	ErrWebhookHostNotAllowed = errors.New("webhook host is not allowed: loopback, private and link-local addresses are blocked")
+)
+
+// sharedAddressSpace - адреса CGNAT (RFC 6598), во внешней сети не встречаются
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// knownEvents - все поддерживаемые события
+var knownEvents = map[string]bool{
+	EventPlumbusCompleted: true,
+	EventPlumbusFailed:    true,
+}
+
+// WebhookPayload - тело запроса доставки webhook
+type WebhookPayload struct {
+	// ID события, одинаковый для всех попыток доставки
+	ID        uuid.UUID      `json:"id"`
+	Event     string         `json:"event"`
+	CreatedAt time.Time      `json:"created_at"`
+	Plumbus   WebhookPlumbus `json:"plumbus"`
+}
+
+// WebhookPlumbus - плюмбус в теле webhook
+type WebhookPlumbus struct {
+	ID            uuid.UUID            `json:"id"`
+	Name          string               `json:"name"`
+	Status        models.PlumbusStatus `json:"status"`
+	IsRare        bool                 `json:"is_rare"`
+	Signature     *string              `json:"signature,omitempty"`
+	SignatureDate *time.Time           `json:"signature_date,omitempty"`
+	Error         *string              `json:"error,omitempty"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+// WebhookService управляет webhook пользователей и доставляет события
+// с повторными попытками и экспоненциальной задержкой
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+	logger *logrus.Logger
+
+	// wg учитывает доставки, выполняющиеся в фоне. stop прерывает их, если
+	// они не успели завершиться до дедлайна остановки.
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	closing bool
+	stop    context.Context
+	cancel  context.CancelFunc
+
+	// MaxAttempts - число попыток доставки одного события
+	MaxAttempts int
+	// Backoff - задержка перед второй попыткой, далее удваивается
+	Backoff time.Duration
+	// DisableAfter - после скольких подряд недоставленных событий webhook отключается
+	DisableAfter int
+	// AllowedHosts - хосты, в которые webhook доставляются, даже если они во внутренней
+	// сети, например получатели в том же кластере
+	AllowedHosts []string
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	stop, cancel := context.WithCancel(context.Background())
+	s := &WebhookService{
+		db:           db,
+		stop:         stop,
+		cancel:       cancel,
+		logger:       logger.For("webhooks"),
+		MaxAttempts:  5,
+		Backoff:      2 * time.Second,
+		DisableAfter: 5,
+	}
+	// Прокси из окружения не используется: адрес проверяется при подключении, а через
+	// прокси подключение шло бы к нему
+	s.client = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         s.dialContext(&net.Dialer{Timeout: 5 * time.Second}),
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+			ForceAttemptHTTP2:   true,
+		},
+	}
+	return s
+}
+
+// SplitHosts разбирает список хостов через запятую
+func SplitHosts(value string) []string {
+	var hosts []string
+	for _, host := range strings.Split(value, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// CreateWebhook регистрирует webhook. Секрет подписи возвращается только один раз.
+func (s *WebhookService) CreateWebhook(userID uuid.UUID, rawURL string, events []string) (string, *models.Webhook, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", nil, ErrInvalidWebhookURL
+	}
+	if err := s.checkHost(parsed.Hostname()); err != nil {
+		return "", nil, err
+	}
+	if len(events) == 0 {
+		return "", nil, errors.New("at least one event is required")
+	}
+	for _, event := range events {
+		if !knownEvents[event] {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnknownEvent, event)
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	secret := webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	webhook := &models.Webhook{
+		ID:        uuid.New(),
+		UserID:    userID,
+		URL:       parsed.String(),
+		Secret:    secret,
+		Events:    strings.Join(events, " "),
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.db.Create(webhook).Error; err != nil {
+		return "", nil, err
+	}
+	return secret, webhook, nil
+}
+
+// ListWebhooks возвращает webhook пользователя, включая отключенные
+func (s *WebhookService) ListWebhooks(userID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := s.db.Where("user_id = ?", userID).Order("created_at desc").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// DeleteWebhook удаляет webhook пользователя вместе с журналом доставок
+func (s *WebhookService) DeleteWebhook(userID, webhookID uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", webhookID, userID).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+		return tx.Where("webhook_id = ?", webhookID).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// EnableWebhook снова включает отключенный webhook и сбрасывает счетчик ошибок
+func (s *WebhookService) EnableWebhook(userID, webhookID uuid.UUID) error {
+	result := s.db.Model(&models.Webhook{}).
+		Where("id = ? AND user_id = ?", webhookID, userID).
+		Updates(map[string]interface{}{
+			"enabled":       true,
+			"failure_count": 0,
+			"disabled_at":   nil,
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeliveries возвращает последние попытки доставки webhook пользователя
+func (s *WebhookService) ListDeliveries(userID, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var count int64
+	if err := s.db.Model(&models.Webhook{}).Where("id = ? AND user_id = ?", webhookID, userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrWebhookNotFound
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := s.db.Where("webhook_id = ?", webhookID).Order("created_at desc").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Dispatch запускает в фоне доставку события о завершении генерации во все подписанные
+// webhook владельца плюмбуса и сразу возвращается. Доставки дожидается Shutdown.
+func (s *WebhookService) Dispatch(ctx context.Context, plumbus *models.Plumbus) {
+	var event string
+	switch plumbus.Status {
+	case models.StatusCompleted:
+		event = EventPlumbusCompleted
+	case models.StatusFailed:
+		event = EventPlumbusFailed
+	default:
+		return
+	}
+
+	var webhooks []models.Webhook
+	if err := s.db.Where("user_id = ? AND enabled = ?", plumbus.UserID, true).Find(&webhooks).Error; err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("plumbus_id", plumbus.ID).Error("Failed to load webhooks")
+		return
+	}
+
+	payload := WebhookPayload{
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Plumbus: WebhookPlumbus{
+			ID:            plumbus.ID,
+			Name:          plumbus.Name,
+			Status:        plumbus.Status,
+			IsRare:        plumbus.IsRare,
+			Signature:     plumbus.Signature,
+			SignatureDate: plumbus.SignatureDate,
+			Error:         plumbus.ErrorMsg,
+			CreatedAt:     plumbus.CreatedAt,
+		},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		s.logger.WithContext(ctx).WithField("plumbus_id", plumbus.ID).Warn("Webhook service is shutting down, event not delivered")
+		return
+	}
+	for i := range webhooks {
+		if !containsEvent(webhooks[i].Events, event) {
+			continue
+		}
+		s.wg.Add(1)
+		go func(webhook models.Webhook) {
+			defer s.wg.Done()
+			// Доставка продолжает трейс ctx, но прерывается только остановкой сервиса
+			ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			defer cancel()
+			defer context.AfterFunc(s.stop, cancel)()
+
+			p := payload
+			p.ID = uuid.New()
+			s.deliver(ctx, webhook, p)
+		}(webhooks[i])
+	}
+}
+
+// Shutdown перестает принимать события и ждет фоновых доставок до дедлайна ctx.
+// Не успевшие завершиться доставки прерываются без учета в счетчике ошибок webhook.
+func (s *WebhookService) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.logger.WithContext(ctx).Warn("Shutdown deadline exceeded, abandoning webhook deliveries")
+	s.cancel()
+	<-done
+	return ctx.Err()
+}
+
+// deliver отправляет событие с повторными попытками и обновляет счетчик ошибок webhook
+func (s *WebhookService) deliver(ctx context.Context, webhook models.Webhook, payload WebhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("webhook_id", webhook.ID).Error("Failed to marshal webhook payload")
+		return
+	}
+
+	log := s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"webhook_id": webhook.ID,
+		"event_id":   payload.ID,
+		"event":      payload.Event,
+		"plumbus_id": payload.Plumbus.ID,
+	})
+
+	backoff := s.Backoff
+	for attempt := 1; attempt <= s.MaxAttempts; attempt++ {
+		delivery := s.send(ctx, webhook, payload, body, attempt)
+		if err := s.db.Create(delivery).Error; err != nil {
+			log.WithError(err).Warn("Failed to save webhook delivery")
+		}
+
+		if delivery.Success {
+			log.WithField("attempt", attempt).Info("Webhook delivered")
+			if webhook.FailureCount > 0 {
+				s.db.Model(&models.Webhook{}).Where("id = ?", webhook.ID).Update("failure_count", 0)
+			}
+			return
+		}
+
+		log.WithFields(logrus.Fields{
+			"attempt":     attempt,
+			"status_code": delivery.StatusCode,
+			"error":       delivery.Error,
+		}).Warn("Webhook delivery failed")
+
+		if attempt == s.MaxAttempts {
+			break
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.WithField("attempt", attempt).Warn("Webhook delivery abandoned on shutdown")
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+
+	s.recordFailure(webhook, log)
+}
+
+// send выполняет одну попытку доставки
+func (s *WebhookService) send(ctx context.Context, webhook models.Webhook, payload WebhookPayload, body []byte, attempt int) *models.WebhookDelivery {
+	delivery := &models.WebhookDelivery{
+		ID:        uuid.New(),
+		WebhookID: webhook.ID,
+		EventID:   payload.ID,
+		Event:     payload.Event,
+		PlumbusID: payload.Plumbus.ID,
+		Attempt:   attempt,
+		CreatedAt: time.Now(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "plumbus-factory-webhook")
+	req.Header.Set(WebhookEventHeader, payload.Event)
+	req.Header.Set(WebhookDeliveryHeader, payload.ID.String())
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := s.client.Do(req)
+	delivery.DurationMs = time.Since(delivery.CreatedAt).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = fmt.Sprintf("endpoint returned status %d", resp.StatusCode)
+	}
+	return delivery
+}
+
+// checkHost отклоняет адреса внутренней сети, известные до разрешения имени. Имена
+// хостов проверяются при каждом подключении (см. dialContext).
+func (s *WebhookService) checkHost(host string) error {
+	if s.hostAllowed(host) {
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrWebhookHostNotAllowed, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookHostNotAllowed, host)
+	}
+	return nil
+}
+
+// dialContext подключается к получателю webhook только по публичным адресам. Имя
+// разрешается здесь же и подключение идет к проверенному адресу, поэтому смена
+// DNS записи после регистрации webhook проверку не обходит.
+func (s *WebhookService) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if s.hostAllowed(host) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if !publicIP(ip.IP) {
+				return nil, fmt.Errorf("%w: %s resolves to %s", ErrWebhookHostNotAllowed, host, ip.IP)
+			}
+		}
+
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// hostAllowed сообщает, входит ли host в AllowedHosts
+func (s *WebhookService) hostAllowed(host string) bool {
+	for _, allowed := range s.AllowedHosts {
+		if strings.EqualFold(strings.TrimSuffix(host, "."), allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// publicIP сообщает, что адрес не относится к loopback, частным, link-local и
+// другим адресам, недоступным из внешней сети
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !sharedAddressSpace.Contains(ip)
+}
+
+// recordFailure увеличивает счетчик недоставленных событий и отключает webhook по порогу
+func (s *WebhookService) recordFailure(webhook models.Webhook, log *logrus.Entry) {
+	err := s.db.Model(&models.Webhook{}).Where("id = ?", webhook.ID).
+		Update("failure_count", gorm.Expr("failure_count + 1")).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to update webhook failure count")
+		return
+	}
+
+	var current models.Webhook
+	if err := s.db.First(&current, "id = ?", webhook.ID).Error; err != nil {
+		log.WithError(err).Error("Failed to reload webhook")
+		return
+	}
+	if current.FailureCount < s.DisableAfter {
+		return
+	}
+
+	now := time.Now()
+	err = s.db.Model(&models.Webhook{}).Where("id = ?", webhook.ID).Updates(map[string]interface{}{
+		"enabled":     false,
+		"disabled_at": now,
+		"updated_at":  now,
+	}).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to disable webhook")
+		return
+	}
+	log.WithField("failure_count", current.FailureCount).Warn("Webhook disabled after repeated failures")
+}
+
+// SignWebhookPayload возвращает значение заголовка подписи: sha256=<hex HMAC-SHA256 тела>
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookEvents возвращает список событий webhook
+func WebhookEvents(webhook *models.Webhook) []string {
+	return strings.Fields(webhook.Events)
+}
+
+func containsEvent(events, event string) bool {
+	for _, e := range strings.Fields(events) {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"factory/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func setupWebhookService(t *testing.T) (*WebhookService, *gorm.DB) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.Webhook{}, &models.WebhookDelivery{}); err != nil {
+		t.Fatalf("Failed to migrate webhooks tables: %v", err)
+	}
+	service := NewWebhookService(db)
+	service.Backoff = time.Millisecond
+	// Тестовые получатели слушают loopback
+	service.AllowedHosts = []string{"127.0.0.1"}
+	return service, db
+}
+
+// webhookReceiver - тестовый endpoint, отвечающий кодами из statuses по очереди
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	r := &webhookReceiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status = r.statuses[0]
+			r.statuses = r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func finishedPlumbus(userID uuid.UUID, status models.PlumbusStatus) *models.Plumbus {
+	signature := "c2lnbmF0dXJl"
+	return &models.Plumbus{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      "Hooked",
+		Status:    status,
+		Signature: &signature,
+		CreatedAt: time.Now(),
+	}
+}
+
+// dispatch доставляет событие и ждет окончания фоновых доставок
+func dispatch(service *WebhookService, plumbus *models.Plumbus) {
+	service.Dispatch(context.Background(), plumbus)
+	service.wg.Wait()
+}
+
+func TestWebhookService_CreateWebhookValidation(t *testing.T) {
+	service, _ := setupWebhookService(t)
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		url     string
+		events  []string
+		wantErr error
+	}{
+		{"relative URL", "/hooks", []string{EventPlumbusCompleted}, ErrInvalidWebhookURL},
+		{"unsupported scheme", "ftp://partner.example/hooks", []string{EventPlumbusCompleted}, ErrInvalidWebhookURL},
+		{"unknown event", "https://partner.example/hooks", []string{"plumbus.exploded"}, ErrUnknownEvent},
+		{"localhost", "http://localhost:5432/", []string{EventPlumbusCompleted}, ErrWebhookHostNotAllowed},
+		{"loopback", "http://127.0.0.2/hooks", []string{EventPlumbusCompleted}, ErrWebhookHostNotAllowed},
+		{"IPv6 loopback", "http://[::1]/hooks", []string{EventPlumbusCompleted}, ErrWebhookHostNotAllowed},
+		{"private", "http://10.0.0.7/hooks", []string{EventPlumbusCompleted}, ErrWebhookHostNotAllowed},
+		{"link-local metadata", "http://169.254.169.254/latest/meta-data", []string{EventPlumbusCompleted}, ErrWebhookHostNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := service.CreateWebhook(userID, tt.url, tt.events); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, _, err := service.CreateWebhook(userID, "https://partner.example/hooks", nil); err == nil {
+		t.Error("CreateWebhook() without events should fail")
+	}
+
+	secret, webhook, err := service.CreateWebhook(userID, "https://partner.example/hooks", []string{EventPlumbusCompleted, EventPlumbusFailed})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	if secret == "" || secret != webhook.Secret {
+		t.Errorf("secret = %q, want the stored webhook secret", secret)
+	}
+	if events := WebhookEvents(webhook); len(events) != 2 {
+		t.Errorf("WebhookEvents() = %v, want 2 events", events)
+	}
+}
+
+func TestWebhookService_DispatchSignsPayload(t *testing.T) {
+	service, db := setupWebhookService(t)
+	userID := uuid.New()
+	receiver := newWebhookReceiver(t)
+
+	secret, webhook, err := service.CreateWebhook(userID, receiver.URL, []string{EventPlumbusCompleted})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	// Webhook другого пользователя и webhook без подписки на событие не вызываются
+	other := newWebhookReceiver(t)
+	service.CreateWebhook(uuid.New(), other.URL, []string{EventPlumbusCompleted})
+	service.CreateWebhook(userID, other.URL, []string{EventPlumbusFailed})
+
+	plumbus := finishedPlumbus(userID, models.StatusCompleted)
+	dispatch(service, plumbus)
+
+	if receiver.count() != 1 {
+		t.Fatalf("receiver got %d requests, want 1", receiver.count())
+	}
+	if other.count() != 0 {
+		t.Errorf("unsubscribed receiver got %d requests, want 0", other.count())
+	}
+
+	req, body := receiver.requests[0], receiver.bodies[0]
+	if got, want := req.Header.Get(WebhookSignatureHeader), SignWebhookPayload(secret, body); got != want {
+		t.Errorf("%s = %q, want %q", WebhookSignatureHeader, got, want)
+	}
+	if req.Header.Get(WebhookEventHeader) != EventPlumbusCompleted {
+		t.Errorf("%s = %q, want %q", WebhookEventHeader, req.Header.Get(WebhookEventHeader), EventPlumbusCompleted)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("Invalid payload: %v", err)
+	}
+	if payload.Event != EventPlumbusCompleted || payload.Plumbus.ID != plumbus.ID {
+		t.Errorf("payload = %+v, want completed event for %v", payload, plumbus.ID)
+	}
+	if req.Header.Get(WebhookDeliveryHeader) != payload.ID.String() {
+		t.Errorf("%s = %q, want event ID %v", WebhookDeliveryHeader, req.Header.Get(WebhookDeliveryHeader), payload.ID)
+	}
+
+	deliveries, err := service.ListDeliveries(userID, webhook.ID, 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(deliveries) != 1 || !deliveries[0].Success || deliveries[0].StatusCode != http.StatusOK {
+		t.Errorf("deliveries = %+v, want one successful delivery", deliveries)
+	}
+
+	// Незавершенные плюмбусы не отправляются
+	dispatch(service, finishedPlumbus(userID, models.StatusGenerating))
+	var count int64
+	db.Model(&models.WebhookDelivery{}).Count(&count)
+	if count != 1 {
+		t.Errorf("deliveries after generating plumbus = %d, want 1", count)
+	}
+}
+
+func TestWebhookService_RetriesWithBackoff(t *testing.T) {
+	service, db := setupWebhookService(t)
+	userID := uuid.New()
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent)
+
+	_, webhook, err := service.CreateWebhook(userID, receiver.URL, []string{EventPlumbusFailed})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	db.Model(&models.Webhook{}).Where("id = ?", webhook.ID).Update("failure_count", 2)
+
+	dispatch(service, finishedPlumbus(userID, models.StatusFailed))
+
+	deliveries, err := service.ListDeliveries(userID, webhook.ID, 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(deliveries) != 3 {
+		t.Fatalf("deliveries = %d, want 3 attempts", len(deliveries))
+	}
+	for _, d := range deliveries {
+		if d.EventID != deliveries[0].EventID {
+			t.Error("retries must reuse the event ID")
+		}
+	}
+
+	// Повторные попытки отправляют то же событие
+	for _, req := range receiver.requests {
+		if req.Header.Get(WebhookDeliveryHeader) != deliveries[0].EventID.String() {
+			t.Errorf("%s = %q, want %v", WebhookDeliveryHeader, req.Header.Get(WebhookDeliveryHeader), deliveries[0].EventID)
+		}
+	}
+
+	// Успешная доставка сбрасывает счетчик ошибок
+	var stored models.Webhook
+	db.First(&stored, "id = ?", webhook.ID)
+	if stored.FailureCount != 0 || !stored.Enabled {
+		t.Errorf("webhook = %+v, want enabled with zero failures", stored)
+	}
+}
+
+func TestWebhookService_DisablesAfterRepeatedFailures(t *testing.T) {
+	service, db := setupWebhookService(t)
+	service.MaxAttempts = 2
+	service.DisableAfter = 2
+	userID := uuid.New()
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError,
+		http.StatusInternalServerError, http.StatusInternalServerError)
+
+	_, webhook, err := service.CreateWebhook(userID, receiver.URL, []string{EventPlumbusCompleted})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	dispatch(service, finishedPlumbus(userID, models.StatusCompleted))
+
+	var stored models.Webhook
+	db.First(&stored, "id = ?", webhook.ID)
+	if stored.FailureCount != 1 || !stored.Enabled {
+		t.Fatalf("after one failed event webhook = %+v, want enabled with one failure", stored)
+	}
+
+	dispatch(service, finishedPlumbus(userID, models.StatusCompleted))
+
+	db.First(&stored, "id = ?", webhook.ID)
+	if stored.Enabled || stored.DisabledAt == nil {
+		t.Fatalf("after %d failed events webhook = %+v, want disabled", service.DisableAfter, stored)
+	}
+	if receiver.count() != 4 {
+		t.Errorf("receiver got %d requests, want 4", receiver.count())
+	}
+
+	// Отключенный webhook не вызывается
+	dispatch(service, finishedPlumbus(userID, models.StatusCompleted))
+	if receiver.count() != 4 {
+		t.Errorf("disabled webhook was called: %d requests", receiver.count())
+	}
+
+	if err := service.EnableWebhook(userID, webhook.ID); err != nil {
+		t.Fatalf("EnableWebhook() error = %v", err)
+	}
+	dispatch(service, finishedPlumbus(userID, models.StatusCompleted))
+	if receiver.count() != 5 {
+		t.Errorf("re-enabled webhook got %d requests, want 5", receiver.count())
+	}
+}
+
+func TestWebhookService_OwnerOnly(t *testing.T) {
+	service, _ := setupWebhookService(t)
+	userID := uuid.New()
+
+	_, webhook, err := service.CreateWebhook(userID, "https://partner.example/hooks", []string{EventPlumbusCompleted})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	stranger := uuid.New()
+	if _, err := service.ListDeliveries(stranger, webhook.ID, 10); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("ListDeliveries() by another user error = %v, want %v", err, ErrWebhookNotFound)
+	}
+	if err := service.EnableWebhook(stranger, webhook.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("EnableWebhook() by another user error = %v, want %v", err, ErrWebhookNotFound)
+	}
+	if err := service.DeleteWebhook(stranger, webhook.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("DeleteWebhook() by another user error = %v, want %v", err, ErrWebhookNotFound)
+	}
+
+	if err := service.DeleteWebhook(userID, webhook.ID); err != nil {
+		t.Fatalf("DeleteWebhook() error = %v", err)
+	}
+	webhooks, _ := service.ListWebhooks(userID)
+	if len(webhooks) != 0 {
+		t.Errorf("ListWebhooks() after delete = %d, want 0", len(webhooks))
+	}
+}
+
+func TestWebhookService_ShutdownAbandonsRetries(t *testing.T) {
+	service, db := setupWebhookService(t)
+	service.Backoff = time.Hour
+	userID := uuid.New()
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+
+	_, webhook, err := service.CreateWebhook(userID, receiver.URL, []string{EventPlumbusCompleted})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	// Dispatch не ждет доставки
+	service.Dispatch(context.Background(), finishedPlumbus(userID, models.StatusCompleted))
+	deadline := time.Now().Add(5 * time.Second)
+	for receiver.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Ожидание следующей попытки прерывается дедлайном остановки
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if err := service.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Shutdown() took %s, want it to stop at the deadline", elapsed)
+	}
+
+	// Прерванная доставка не считается ошибкой webhook, новые события не принимаются
+	var stored models.Webhook
+	db.First(&stored, "id = ?", webhook.ID)
+	if stored.FailureCount != 0 {
+		t.Errorf("failure count after abandoned delivery = %d, want 0", stored.FailureCount)
+	}
+	dispatch(service, finishedPlumbus(userID, models.StatusCompleted))
+	if receiver.count() != 1 {
+		t.Errorf("receiver got %d requests after shutdown, want 1", receiver.count())
+	}
+}
+
+func TestWebhookService_BlocksInternalAddressesAtDialTime(t *testing.T) {
+	service, _ := setupWebhookService(t)
+	userID := uuid.New()
+	receiver := newWebhookReceiver(t)
+
+	_, webhook, err := service.CreateWebhook(userID, receiver.URL, []string{EventPlumbusCompleted})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	// Адрес проверяется при каждом подключении, а не только при регистрации
+	service.AllowedHosts = nil
+	service.MaxAttempts = 1
+	dispatch(service, finishedPlumbus(userID, models.StatusCompleted))
+
+	if receiver.count() != 0 {
+		t.Errorf("receiver on loopback got %d requests, want 0", receiver.count())
+	}
+	deliveries, _ := service.ListDeliveries(userID, webhook.ID, 10)
+	if len(deliveries) != 1 || deliveries[0].Success || !strings.Contains(deliveries[0].Error, "not allowed") || deliveries[0].StatusCode != 0 {
+		t.Errorf("deliveries = %+v, want one blocked delivery without a status code", deliveries)
+	}
+}
+
+func TestSplitHosts(t *testing.T) {
+	if got := SplitHosts(" hooks.internal, ,10.0.0.5 "); !reflect.DeepEqual(got, []string{"hooks.internal", "10.0.0.5"}) {
+		t.Errorf("SplitHosts() = %q", got)
+	}
+}
@@ -0,0 +1,272 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/models"
+	"factory/internal/services"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/google/uuid"
+)
+
+const (
+	// CookieName - имя cookie с подписанным ID сессии
+	CookieName = "factory_session"
+	// refreshSkew - за сколько до истечения access токена его нужно обновить
+	refreshSkew = 30 * time.Second
+)
+
+// ErrRefreshFailed возвращается, если обновить токены не удалось и сессия больше недействительна
+var ErrRefreshFailed = errors.New("failed to refresh session tokens")
+
+// TokenRefresher обменивает refresh токен на новую пару токенов
+type TokenRefresher interface {
+	RefreshToken(ctx context.Context, refreshToken string) (*gocloak.JWT, error)
+}
+
+// Manager создает, загружает и обновляет серверные сессии
+type Manager struct {
+	store     Store
+	signer    *Signer
+	refresher TokenRefresher
+
+	// BaseURL - публичный адрес фабрики. Если он начинается с https, cookie получает
+	// флаг Secure, даже когда TLS завершается на прокси перед фабрикой.
+	BaseURL string
+
+	// refreshLocks сериализуют обновление токенов одной сессии, чтобы параллельные
+	// запросы не обменивали refresh токен несколько раз. Разные сессии друг друга не ждут.
+	mu           sync.Mutex
+	refreshLocks map[string]*refreshLock
+}
+
+// refreshLock - блокировка обновления одной сессии и число запросов, которые ее держат или ждут
+type refreshLock struct {
+	sync.Mutex
+	users int
+}
+
+// NewManager создает менеджер сессий. Cookie подписываются текущим значением secret.
+func NewManager(store Store, secret *config.Secret, refresher TokenRefresher) *Manager {
+	return &Manager{
+		store:        store,
+		signer:       NewSigner(secret),
+		refresher:    refresher,
+		refreshLocks: make(map[string]*refreshLock),
+	}
+}
+
+// Signer возвращает подписчик, используемый для cookie сессии
+func (m *Manager) Signer() *Signer {
+	return m.signer
+}
+
+// Owner описывает владельца создаваемой сессии
+type Owner struct {
+	UserID uuid.UUID
+	// Subject - ID пользователя в Keycloak (sub)
+	Subject string
+	// KeycloakSessionID - ID SSO сессии Keycloak (sid)
+	KeycloakSessionID string
+}
+
+// Start создает сессию для пользователя и устанавливает cookie
+func (m *Manager) Start(w http.ResponseWriter, r *http.Request, owner Owner, token *gocloak.JWT) (*models.Session, error) {
+	id, err := RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		ID:                id,
+		UserID:            owner.UserID,
+		Subject:           owner.Subject,
+		KeycloakSessionID: owner.KeycloakSessionID,
+	}
+	applyToken(session, token, time.Now())
+
+	if err := m.store.Save(r.Context(), session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	m.setCookie(w, r, session)
+	return session, nil
+}
+
+// Load возвращает сессию по cookie запроса
+func (m *Manager) Load(r *http.Request) (*models.Session, error) {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	id, err := m.signer.Verify(cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := m.store.Get(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !session.ExpiresAt.After(time.Now()) {
+		m.store.Delete(r.Context(), id)
+		return nil, ErrNotFound
+	}
+
+	return session, nil
+}
+
+// EnsureFresh обновляет токены сессии, если access токен скоро истечет. После обновления
+// cookie выдается заново: ее срок действия следует за новым сроком сессии.
+func (m *Manager) EnsureFresh(w http.ResponseWriter, r *http.Request, session *models.Session) (*models.Session, error) {
+	if time.Until(session.AccessExpiresAt) > refreshSkew {
+		return session, nil
+	}
+
+	unlock := m.lockRefresh(session.ID)
+	defer unlock()
+
+	// Сессию мог уже обновить параллельный запрос
+	ctx := r.Context()
+	current, err := m.store.Get(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Until(current.AccessExpiresAt) > refreshSkew {
+		m.setCookie(w, r, current)
+		return current, nil
+	}
+
+	if current.RefreshToken == "" {
+		return nil, ErrRefreshFailed
+	}
+
+	token, err := m.refresher.RefreshToken(ctx, current.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRefreshFailed, err)
+	}
+
+	applyToken(current, token, time.Now())
+	if err := m.store.Save(ctx, current); err != nil {
+		return nil, fmt.Errorf("failed to save refreshed session: %w", err)
+	}
+
+	m.setCookie(w, r, current)
+	return current, nil
+}
+
+// lockRefresh блокирует обновление сессии id и возвращает функцию снятия блокировки
+func (m *Manager) lockRefresh(id string) func() {
+	m.mu.Lock()
+	lock, ok := m.refreshLocks[id]
+	if !ok {
+		lock = &refreshLock{}
+		m.refreshLocks[id] = lock
+	}
+	lock.users++
+	m.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		m.mu.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(m.refreshLocks, id)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Destroy удаляет сессию из хранилища и очищает cookie.
+// Возвращает удаленную сессию (или nil), чтобы можно было завершить SSO сессию в Keycloak.
+func (m *Manager) Destroy(w http.ResponseWriter, r *http.Request) *models.Session {
+	session, err := m.Load(r)
+	if err == nil {
+		m.store.Delete(r.Context(), session.ID)
+	} else {
+		session = nil
+	}
+
+	cookie := m.cookie(r, "")
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+
+	return session
+}
+
+// setCookie выдает cookie с подписанным ID сессии, действующую до истечения сессии
+func (m *Manager) setCookie(w http.ResponseWriter, r *http.Request, session *models.Session) {
+	cookie := m.cookie(r, m.signer.Sign(session.ID))
+	cookie.Expires = session.ExpiresAt
+	http.SetCookie(w, cookie)
+}
+
+func (m *Manager) cookie(r *http.Request, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   m.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// secure сообщает, открыта ли фабрика по https: напрямую, через прокси или по BaseURL
+func (m *Manager) secure(r *http.Request) bool {
+	return r.TLS != nil ||
+		strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") ||
+		strings.HasPrefix(strings.ToLower(m.BaseURL), "https://")
+}
+
+// RevokeKeycloakSession удаляет сессии, связанные с SSO сессией Keycloak.
+// Если sid не передан, удаляются все сессии пользователя Keycloak.
+func (m *Manager) RevokeKeycloakSession(ctx context.Context, sid, subject string) (int64, error) {
+	if sid != "" {
+		return m.store.DeleteByKeycloakSession(ctx, sid)
+	}
+	if subject != "" {
+		return m.store.DeleteBySubject(ctx, subject)
+	}
+	return 0, errors.New("either sid or subject is required")
+}
+
+// StartCleanup периодически удаляет истекшие сессии, см. services.RunPeriodically
+func (m *Manager) StartCleanup(ctx context.Context, interval time.Duration, onError func(error)) <-chan struct{} {
+	return services.RunPeriodically(ctx, interval, func(ctx context.Context) error {
+		_, err := m.store.DeleteExpired(ctx, time.Now())
+		return err
+	}, onError)
+}
+
+// applyToken переносит токены и сроки их действия в сессию
+func applyToken(session *models.Session, token *gocloak.JWT, now time.Time) {
+	session.AccessToken = token.AccessToken
+	session.AccessExpiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	// Keycloak может не вернуть новые refresh и ID токены - оставляем прежние
+	if token.RefreshToken != "" {
+		session.RefreshToken = token.RefreshToken
+	}
+	if token.IDToken != "" {
+		session.IDToken = token.IDToken
+	}
+
+	// Время жизни сессии ограничено сроком действия refresh токена
+	if token.RefreshExpiresIn > 0 {
+		session.ExpiresAt = now.Add(time.Duration(token.RefreshExpiresIn) * time.Second)
+	} else if session.ExpiresAt.Before(session.AccessExpiresAt) {
+		session.ExpiresAt = session.AccessExpiresAt
+	}
+}
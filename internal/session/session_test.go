@@ -0,0 +1,413 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"factory/internal/config"
+	"factory/internal/models"
+	"factory/internal/testutils"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/google/uuid"
+)
+
+// mockRefresher - мок обмена refresh токена
+type mockRefresher struct {
+	mu    sync.Mutex
+	calls int
+	token *gocloak.JWT
+	err   error
+	// entered и release, если заданы, удерживают обмен, пока тест не разрешит его завершить
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (m *mockRefresher) RefreshToken(_ context.Context, _ string) (*gocloak.JWT, error) {
+	m.mu.Lock()
+	m.calls++
+	m.mu.Unlock()
+	if m.entered != nil {
+		m.entered <- struct{}{}
+		<-m.release
+	}
+	return m.token, m.err
+}
+
+func testToken(access string, expiresIn int) *gocloak.JWT {
+	return &gocloak.JWT{
+		AccessToken:      access,
+		RefreshToken:     "refresh-" + access,
+		ExpiresIn:        expiresIn,
+		RefreshExpiresIn: 1800,
+	}
+}
+
+// startSession создает сессию и возвращает запрос с ее cookie
+func startSession(t *testing.T, m *Manager, token *gocloak.JWT) (*models.Session, *http.Request) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	owner := Owner{UserID: uuid.New(), Subject: "kc-user", KeycloakSessionID: "kc-sid"}
+	sess, err := m.Start(rec, httptest.NewRequest(http.MethodGet, "/auth/callback", nil), owner, token)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return sess, req
+}
+
+func TestSigner_SignVerify(t *testing.T) {
+	signer := NewSigner(config.NewSecret("secret"))
+
+	signed := signer.Sign("session-id")
+	value, err := signer.Verify(signed)
+	if err != nil {
+		t.Fatalf("Verify() error = %v, want nil", err)
+	}
+	if value != "session-id" {
+		t.Errorf("Verify() = %v, want session-id", value)
+	}
+
+	// Подпись другим секретом не должна проходить проверку
+	if _, err := NewSigner(config.NewSecret("other")).Verify(signed); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with other secret error = %v, want ErrInvalidSignature", err)
+	}
+
+	for _, tampered := range []string{"", "no-dot", signed + "x", "eA." + signed[len(signed)-43:]} {
+		if _, err := signer.Verify(tampered); err == nil {
+			t.Errorf("Verify(%q) error = nil, want error", tampered)
+		}
+	}
+}
+
+func TestSigner_SecretRotation(t *testing.T) {
+	secret := config.NewSecret("old-secret")
+	signer := NewSigner(secret)
+	signedBefore := signer.Sign("session-id")
+
+	secret.Set("new-secret")
+	signedAfter := signer.Sign("session-id")
+	if signedAfter == signedBefore {
+		t.Fatal("Sign() after rotation uses the old secret")
+	}
+
+	// Подписи прежним секретом действуют до следующей ротации
+	for _, signed := range []string{signedBefore, signedAfter} {
+		if value, err := signer.Verify(signed); err != nil || value != "session-id" {
+			t.Errorf("Verify(%q) = %q, %v, want session-id", signed, value, err)
+		}
+	}
+
+	secret.Set("newest-secret")
+	if _, err := signer.Verify(signedBefore); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with a secret two rotations old error = %v, want ErrInvalidSignature", err)
+	}
+	if _, err := signer.Verify(signedAfter); err != nil {
+		t.Errorf("Verify() with the previous secret error = %v", err)
+	}
+}
+
+func TestStores(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	if err := db.AutoMigrate(&models.Session{}); err != nil {
+		t.Fatalf("Failed to migrate sessions table: %v", err)
+	}
+
+	stores := map[string]Store{
+		"memory":   NewMemoryStore(),
+		"database": NewDBStore(db),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			active := &models.Session{ID: "active", UserID: uuid.New(), AccessToken: "a", ExpiresAt: now.Add(time.Hour), AccessExpiresAt: now.Add(time.Minute)}
+			expired := &models.Session{ID: "expired", UserID: uuid.New(), AccessToken: "b", ExpiresAt: now.Add(-time.Hour), AccessExpiresAt: now.Add(-time.Hour)}
+
+			for _, s := range []*models.Session{active, expired} {
+				if err := store.Save(ctx, s); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			got, err := store.Get(ctx, "active")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.UserID != active.UserID || got.AccessToken != "a" {
+				t.Errorf("Get() = %+v, want %+v", got, active)
+			}
+
+			deleted, err := store.DeleteExpired(ctx, now)
+			if err != nil {
+				t.Fatalf("DeleteExpired() error = %v", err)
+			}
+			if deleted != 1 {
+				t.Errorf("DeleteExpired() = %d, want 1", deleted)
+			}
+
+			if _, err := store.Get(ctx, "expired"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get(expired) error = %v, want ErrNotFound", err)
+			}
+
+			byKeycloak := []*models.Session{
+				{ID: "sso-1", UserID: uuid.New(), AccessToken: "c", Subject: "sub-1", KeycloakSessionID: "sid-1", ExpiresAt: now.Add(time.Hour), AccessExpiresAt: now.Add(time.Minute)},
+				{ID: "sso-2", UserID: uuid.New(), AccessToken: "d", Subject: "sub-1", KeycloakSessionID: "sid-2", ExpiresAt: now.Add(time.Hour), AccessExpiresAt: now.Add(time.Minute)},
+			}
+			for _, s := range byKeycloak {
+				if err := store.Save(ctx, s); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			if deleted, err := store.DeleteByKeycloakSession(ctx, "sid-1"); err != nil || deleted != 1 {
+				t.Errorf("DeleteByKeycloakSession() = %d, %v, want 1, nil", deleted, err)
+			}
+			if deleted, err := store.DeleteBySubject(ctx, "sub-1"); err != nil || deleted != 1 {
+				t.Errorf("DeleteBySubject() = %d, %v, want 1, nil", deleted, err)
+			}
+
+			if err := store.Delete(ctx, "active"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Get(ctx, "active"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get(deleted) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestNewStore_Unknown(t *testing.T) {
+	if _, err := NewStore("redis", nil); err == nil {
+		t.Error("NewStore(redis) error = nil, want error")
+	}
+}
+
+func TestManager_StartAndLoad(t *testing.T) {
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), &mockRefresher{})
+	sess, req := startSession(t, m, testToken("access", 300))
+
+	loaded, err := m.Load(req)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.ID != sess.ID || loaded.AccessToken != "access" || loaded.RefreshToken != "refresh-access" {
+		t.Errorf("Load() = %+v, want session %s with tokens", loaded, sess.ID)
+	}
+}
+
+func TestManager_Load_ForgedCookie(t *testing.T) {
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), &mockRefresher{})
+	sess, _ := startSession(t, m, testToken("access", 300))
+
+	// Злоумышленник знает ID сессии, но не может подписать его
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: NewSigner(config.NewSecret("guess")).Sign(sess.ID)})
+
+	if _, err := m.Load(req); err == nil {
+		t.Error("Load() error = nil, want error for forged cookie")
+	}
+}
+
+func TestManager_EnsureFresh_RefreshesExpiringToken(t *testing.T) {
+	refresher := &mockRefresher{token: testToken("new-access", 300)}
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), refresher)
+	sess, req := startSession(t, m, testToken("old-access", 10))
+
+	fresh, err := m.EnsureFresh(httptest.NewRecorder(), req, sess)
+	if err != nil {
+		t.Fatalf("EnsureFresh() error = %v", err)
+	}
+
+	if refresher.calls != 1 {
+		t.Errorf("refresher calls = %d, want 1", refresher.calls)
+	}
+	if fresh.AccessToken != "new-access" {
+		t.Errorf("AccessToken = %v, want new-access", fresh.AccessToken)
+	}
+
+	// Повторный вызов с уже обновленной сессией не должен обращаться к Keycloak
+	if _, err := m.EnsureFresh(httptest.NewRecorder(), req, sess); err != nil {
+		t.Fatalf("EnsureFresh() second call error = %v", err)
+	}
+	if refresher.calls != 1 {
+		t.Errorf("refresher calls after second EnsureFresh = %d, want 1", refresher.calls)
+	}
+}
+
+func TestManager_EnsureFresh_ValidTokenUntouched(t *testing.T) {
+	refresher := &mockRefresher{}
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), refresher)
+	sess, req := startSession(t, m, testToken("access", 300))
+
+	rec := httptest.NewRecorder()
+	if _, err := m.EnsureFresh(rec, req, sess); err != nil {
+		t.Fatalf("EnsureFresh() error = %v", err)
+	}
+	if refresher.calls != 0 {
+		t.Errorf("refresher calls = %d, want 0", refresher.calls)
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("EnsureFresh() without refresh set cookies %+v", cookies)
+	}
+}
+
+func TestManager_EnsureFresh_RefreshError(t *testing.T) {
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), &mockRefresher{err: errors.New("invalid_grant")})
+	sess, req := startSession(t, m, testToken("access", 0))
+
+	if _, err := m.EnsureFresh(httptest.NewRecorder(), req, sess); !errors.Is(err, ErrRefreshFailed) {
+		t.Errorf("EnsureFresh() error = %v, want ErrRefreshFailed", err)
+	}
+}
+
+func TestManager_EnsureFresh_ReissuesCookie(t *testing.T) {
+	token := testToken("new-access", 300)
+	token.RefreshExpiresIn = 7200
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), &mockRefresher{token: token})
+	sess, req := startSession(t, m, testToken("old-access", 10))
+
+	rec := httptest.NewRecorder()
+	fresh, err := m.EnsureFresh(rec, req, sess)
+	if err != nil {
+		t.Fatalf("EnsureFresh() error = %v", err)
+	}
+
+	// Cookie продлевается вместе с сессией, иначе браузер удалит ее раньше
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CookieName {
+		t.Fatalf("EnsureFresh() cookies = %+v, want session cookie", cookies)
+	}
+	if !fresh.ExpiresAt.After(sess.ExpiresAt) || !cookies[0].Expires.Equal(fresh.ExpiresAt.Truncate(time.Second)) {
+		t.Errorf("cookie expires = %v, want refreshed session expiry %v", cookies[0].Expires, fresh.ExpiresAt)
+	}
+	if id, err := m.Signer().Verify(cookies[0].Value); err != nil || id != sess.ID {
+		t.Errorf("cookie value = %q (%v), want signed session ID", id, err)
+	}
+}
+
+func TestManager_EnsureFresh_LocksPerSession(t *testing.T) {
+	refresher := &mockRefresher{
+		token:   testToken("new-access", 300),
+		entered: make(chan struct{}, 3),
+		release: make(chan struct{}),
+	}
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), refresher)
+	first, firstReq := startSession(t, m, testToken("first", 10))
+	second, secondReq := startSession(t, m, testToken("second", 10))
+
+	var wg sync.WaitGroup
+	refresh := func(sess *models.Session, req *http.Request) {
+		defer wg.Done()
+		if _, err := m.EnsureFresh(httptest.NewRecorder(), req, sess); err != nil {
+			t.Errorf("EnsureFresh() error = %v", err)
+		}
+	}
+	wg.Add(3)
+	go refresh(first, firstReq)
+	go refresh(first, firstReq)
+	go refresh(second, secondReq)
+
+	// Обе сессии обмениваются одновременно: обновление одной не ждет другую
+	for i := 0; i < 2; i++ {
+		select {
+		case <-refresher.entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for parallel refresh of different sessions")
+		}
+	}
+	close(refresher.release)
+	wg.Wait()
+
+	// Второй запрос первой сессии получил уже обновленные токены
+	if refresher.calls != 2 {
+		t.Errorf("refresher calls = %d, want 2", refresher.calls)
+	}
+	if len(m.refreshLocks) != 0 {
+		t.Errorf("refresh locks left = %d, want 0", len(m.refreshLocks))
+	}
+}
+
+func TestManager_SecureCookie(t *testing.T) {
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), &mockRefresher{})
+	owner := Owner{UserID: uuid.New()}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		request func() *http.Request
+		want    bool
+	}{
+		{"http", "", func() *http.Request { return httptest.NewRequest(http.MethodGet, "http://factory/", nil) }, false},
+		{"tls", "", func() *http.Request { return httptest.NewRequest(http.MethodGet, "https://factory/", nil) }, true},
+		{"proxy", "", func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "http://factory/", nil)
+			req.Header.Set("X-Forwarded-Proto", "https")
+			return req
+		}, true},
+		{"base url", "https://factory.example", func() *http.Request { return httptest.NewRequest(http.MethodGet, "http://factory/", nil) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.BaseURL = tt.baseURL
+			rec := httptest.NewRecorder()
+			if _, err := m.Start(rec, tt.request(), owner, testToken("access", 300)); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			cookies := rec.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Secure != tt.want {
+				t.Errorf("cookies = %+v, want Secure = %v", cookies, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_Destroy(t *testing.T) {
+	store := NewMemoryStore()
+	m := NewManager(store, config.NewSecret("secret"), &mockRefresher{})
+	sess, req := startSession(t, m, testToken("access", 300))
+
+	rec := httptest.NewRecorder()
+	destroyed := m.Destroy(rec, req)
+	if destroyed == nil || destroyed.ID != sess.ID {
+		t.Errorf("Destroy() = %+v, want destroyed session %s", destroyed, sess.ID)
+	}
+
+	if _, err := store.Get(context.Background(), sess.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("session still stored after Destroy(), err = %v", err)
+	}
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("Destroy() cookies = %+v, want expired session cookie", cookies)
+	}
+}
+
+func TestManager_RevokeKeycloakSession(t *testing.T) {
+	m := NewManager(NewMemoryStore(), config.NewSecret("secret"), &mockRefresher{})
+	_, req := startSession(t, m, testToken("access", 300))
+
+	if _, err := m.RevokeKeycloakSession(context.Background(), "", ""); err == nil {
+		t.Error("RevokeKeycloakSession() without sid and sub error = nil, want error")
+	}
+
+	revoked, err := m.RevokeKeycloakSession(context.Background(), "kc-sid", "")
+	if err != nil || revoked != 1 {
+		t.Fatalf("RevokeKeycloakSession() = %d, %v, want 1, nil", revoked, err)
+	}
+
+	if _, err := m.Load(req); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load() after revoke error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"factory/internal/config"
+)
+
+// ErrInvalidSignature возвращается для подделанных или поврежденных значений
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Signer подписывает значения cookie с помощью HMAC-SHA256. Секрет читается при каждой
+// подписи и проверке, поэтому ротация секрета подхватывается без перезапуска.
+type Signer struct {
+	secret *config.Secret
+}
+
+// NewSigner создает подписчик с указанным секретом
+func NewSigner(secret *config.Secret) *Signer {
+	return &Signer{secret: secret}
+}
+
+// Sign возвращает значение вместе с его подписью текущим секретом
+func (s *Signer) Sign(value string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(value))
+	return encoded + "." + mac(s.secret.Value(), encoded)
+}
+
+// Verify проверяет подпись и возвращает исходное значение. После ротации секрета
+// принимаются и подписи прежним значением, чтобы не завершать действующие сессии.
+func (s *Signer) Verify(signed string) (string, error) {
+	encoded, signature, ok := strings.Cut(signed, ".")
+	if !ok {
+		return "", ErrInvalidSignature
+	}
+
+	current, previous := s.secret.Values()
+	valid := hmac.Equal([]byte(signature), []byte(mac(current, encoded)))
+	if !valid && previous != "" {
+		valid = hmac.Equal([]byte(signature), []byte(mac(previous, encoded)))
+	}
+	if !valid {
+		return "", ErrInvalidSignature
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	return string(value), nil
+}
+
+func mac(secret, value string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// RandomToken возвращает криптографически случайную строку из n байт в base64url
+func RandomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
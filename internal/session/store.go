@@ -0,0 +1,167 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"factory/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound возвращается, если сессия не найдена или истекла
+var ErrNotFound = errors.New("session not found")
+
+// Store - хранилище серверных сессий
+type Store interface {
+	Get(ctx context.Context, id string) (*models.Session, error)
+	Save(ctx context.Context, session *models.Session) error
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	// DeleteByKeycloakSession удаляет сессии, созданные в рамках SSO сессии Keycloak (sid)
+	DeleteByKeycloakSession(ctx context.Context, sid string) (int64, error)
+	// DeleteBySubject удаляет все сессии пользователя Keycloak (sub)
+	DeleteBySubject(ctx context.Context, subject string) (int64, error)
+}
+
+// Типы хранилищ сессий
+const (
+	StoreMemory   = "memory"
+	StoreDatabase = "database"
+)
+
+// NewStore создает хранилище сессий указанного типа
+func NewStore(kind string, db *gorm.DB) (Store, error) {
+	switch kind {
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StoreDatabase, "":
+		return NewDBStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown session store %q", kind)
+	}
+}
+
+// MemoryStore хранит сессии в памяти процесса.
+// Подходит для одной реплики и тестов: сессии теряются при перезапуске.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]models.Session
+}
+
+// NewMemoryStore создает хранилище сессий в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]models.Session)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &session, nil
+}
+
+func (s *MemoryStore) Save(_ context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.UpdatedAt = now
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *MemoryStore) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	return s.deleteWhere(func(session models.Session) bool {
+		return !session.ExpiresAt.After(now)
+	}), nil
+}
+
+func (s *MemoryStore) DeleteByKeycloakSession(_ context.Context, sid string) (int64, error) {
+	return s.deleteWhere(func(session models.Session) bool {
+		return session.KeycloakSessionID == sid
+	}), nil
+}
+
+func (s *MemoryStore) DeleteBySubject(_ context.Context, subject string) (int64, error) {
+	return s.deleteWhere(func(session models.Session) bool {
+		return session.Subject == subject
+	}), nil
+}
+
+func (s *MemoryStore) deleteWhere(match func(models.Session) bool) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, session := range s.sessions {
+		if match(session) {
+			delete(s.sessions, id)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// DBStore хранит сессии в базе данных и позволяет работать нескольким репликам
+type DBStore struct {
+	db *gorm.DB
+}
+
+// NewDBStore создает хранилище сессий в базе данных
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+func (s *DBStore) Get(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	err := s.db.WithContext(ctx).First(&session, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *DBStore) Save(ctx context.Context, session *models.Session) error {
+	return s.db.WithContext(ctx).Save(session).Error
+}
+
+func (s *DBStore) Delete(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Delete(&models.Session{}, "id = ?", id).Error
+}
+
+func (s *DBStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *DBStore) DeleteByKeycloakSession(ctx context.Context, sid string) (int64, error) {
+	result := s.db.WithContext(ctx).Where("keycloak_session_id = ?", sid).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *DBStore) DeleteBySubject(ctx context.Context, subject string) (int64, error) {
+	result := s.db.WithContext(ctx).Where("subject = ?", subject).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
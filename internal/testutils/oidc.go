@@ -0,0 +1,301 @@
+package testutils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// FakeOIDCRealm - realm фейкового провайдера
+	FakeOIDCRealm = "factory"
+	// FakeOIDCClientID - клиент, зарегистрированный в фейковом провайдере
+	FakeOIDCClientID = "factory"
+	// FakeOIDCClientSecret - секрет клиента фейкового провайдера
+	FakeOIDCClientSecret = "client-secret"
+
+	fakeOIDCKeyID = "test-key"
+)
+
+// FakeOIDCUser - пользователь, от имени которого фейковый провайдер выпускает токены
+type FakeOIDCUser struct {
+	Subject  string
+	Username string
+	Email    string
+	Roles    []string
+}
+
+// authorization - выданный, но еще не обмененный код авторизации
+type authorization struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// FakeOIDCProvider - минимальный OIDC провайдер в стиле Keycloak для тестов:
+// authorization code flow с PKCE, refresh токены и JWKS.
+type FakeOIDCProvider struct {
+	*httptest.Server
+
+	User FakeOIDCUser
+	// AccessTokenTTL - время жизни выпускаемых access токенов
+	AccessTokenTTL time.Duration
+	// NonceOverride подменяет nonce в ID токене (для проверки защиты от подмены)
+	NonceOverride string
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]authorization
+	// TokenRequests содержит параметры всех запросов к token endpoint
+	TokenRequests []url.Values
+}
+
+// NewFakeOIDCProvider запускает фейковый провайдер на локальном порту
+func NewFakeOIDCProvider(t *testing.T) *FakeOIDCProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	p := &FakeOIDCProvider{
+		User: FakeOIDCUser{
+			Subject:  "kc-user-1",
+			Username: "rick",
+			Email:    "rick@citadel.test",
+			Roles:    []string{"factory-operator"},
+		},
+		AccessTokenTTL: 5 * time.Minute,
+		key:            key,
+		codes:          make(map[string]authorization),
+	}
+
+	mux := http.NewServeMux()
+	base := "/realms/" + FakeOIDCRealm + "/protocol/openid-connect"
+	mux.HandleFunc(base+"/auth", p.handleAuth)
+	mux.HandleFunc(base+"/token", p.handleToken)
+	mux.HandleFunc(base+"/certs", p.handleCerts)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+// Issuer возвращает issuer токенов провайдера
+func (p *FakeOIDCProvider) Issuer() string {
+	return p.URL + "/realms/" + FakeOIDCRealm
+}
+
+// Authorize имитирует успешный вход пользователя: принимает URL авторизации
+// и возвращает URL callback с кодом и state
+func (p *FakeOIDCProvider) Authorize(t *testing.T, authURL string) *url.URL {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("Authorization request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Authorization status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Invalid callback URL: %v", err)
+	}
+	return location
+}
+
+// AccessToken выпускает подписанный access токен текущего пользователя
+func (p *FakeOIDCProvider) AccessToken(t *testing.T) string {
+	t.Helper()
+	token, err := p.accessToken()
+	if err != nil {
+		t.Fatalf("Failed to issue access token: %v", err)
+	}
+	return token
+}
+
+// SignToken подписывает произвольные claims ключом провайдера
+func (p *FakeOIDCProvider) SignToken(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = fakeOIDCKeyID
+	return token.SignedString(p.key)
+}
+
+func (p *FakeOIDCProvider) handleAuth(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != FakeOIDCClientID || q.Get("response_type") != "code" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "pkce required", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = authorization{
+		redirectURI:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+	}
+	p.mu.Unlock()
+
+	callback, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := callback.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	callback.RawQuery = params.Encode()
+
+	http.Redirect(w, r, callback.String(), http.StatusFound)
+}
+
+func (p *FakeOIDCProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	p.TokenRequests = append(p.TokenRequests, r.PostForm)
+	p.mu.Unlock()
+
+	// Клиент передает секрет в форме или, как gocloak, через Basic auth
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != FakeOIDCClientID || clientSecret != FakeOIDCClientSecret {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	nonce := ""
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		p.mu.Lock()
+		auth, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mu.Unlock()
+
+		if !ok || auth.redirectURI != r.PostForm.Get("redirect_uri") {
+			tokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge {
+			tokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		nonce = auth.nonce
+	case "refresh_token":
+		if r.PostForm.Get("refresh_token") == "" {
+			tokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+	default:
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	if p.NonceOverride != "" {
+		nonce = p.NonceOverride
+	}
+
+	accessToken, err := p.accessToken()
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	idToken, err := p.SignToken(jwt.MapClaims{
+		"iss":   p.Issuer(),
+		"aud":   FakeOIDCClientID,
+		"azp":   FakeOIDCClientID,
+		"sub":   p.User.Subject,
+		"sid":   "kc-session-1",
+		"nonce": nonce,
+		"exp":   time.Now().Add(p.AccessTokenTTL).Unix(),
+		"iat":   time.Now().Unix(),
+	})
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":       accessToken,
+		"id_token":           idToken,
+		"refresh_token":      "refresh-" + randomString(),
+		"token_type":         "Bearer",
+		"expires_in":         int(p.AccessTokenTTL.Seconds()),
+		"refresh_expires_in": 1800,
+		"session_state":      "kc-session-1",
+	})
+}
+
+func (p *FakeOIDCProvider) handleCerts(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": fakeOIDCKeyID,
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *FakeOIDCProvider) accessToken() (string, error) {
+	return p.SignToken(jwt.MapClaims{
+		"iss":                p.Issuer(),
+		"aud":                []string{FakeOIDCClientID, "account"},
+		"azp":                FakeOIDCClientID,
+		"sub":                p.User.Subject,
+		"sid":                "kc-session-1",
+		"preferred_username": p.User.Username,
+		"email":              p.User.Email,
+		"realm_access":       map[string]interface{}{"roles": p.User.Roles},
+		"exp":                time.Now().Add(p.AccessTokenTTL).Unix(),
+		"iat":                time.Now().Unix(),
+	})
+}
+
+func tokenError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error":%q}`, code)
+}
+
+func randomString() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
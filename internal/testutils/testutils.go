@@ -219,8 +219,12 @@ func createTestSchema(db *gorm.DB) error {
 			signature TEXT,
 			signature_date DATETIME,
 			error_msg TEXT,
+			attempts INTEGER NOT NULL DEFAULT 1,
+			interrupted INTEGER NOT NULL DEFAULT 0,
+			broken INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			deleted_at DATETIME,
 			FOREIGN KEY (user_id) REFERENCES "user"(id)
 		)
 	`).Error
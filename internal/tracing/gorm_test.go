@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"factory/internal/testutils"
+)
+
+func TestGormPlugin_SpansFollowContext(t *testing.T) {
+	exporter := testutils.SetupTracing(t)
+	db := testutils.SetupTestDB(t)
+	if err := db.Use(GormPlugin()); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+
+	ctx, parent := Tracer().Start(context.Background(), "request")
+	var count int64
+	if err := db.WithContext(ctx).Table("plumbus").Count(&count).Error; err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	db.WithContext(ctx).Exec("SELECT * FROM missing_table")
+	parent.End()
+
+	var query, failed bool
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "request" {
+			continue
+		}
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of the request span", span.Name)
+		}
+		for _, attr := range span.Attributes {
+			if attr.Key == "db.statement" && strings.Contains(attr.Value.AsString(), "plumbus") {
+				query = span.Name == "gorm.row" || span.Name == "gorm.query"
+			}
+		}
+		if span.Name == "gorm.raw" && span.Status.Code.String() == "Error" {
+			failed = true
+		}
+	}
+	if !query {
+		t.Errorf("no query span with statement, spans = %v", exporter.GetSpans().Snapshots())
+	}
+	if !failed {
+		t.Error("failed statement span is not marked as error")
+	}
+}
@@ -0,0 +1,18 @@
+package factoryclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// Клиент генерируется из api/openapi.yaml, после изменения спецификации выполните go generate
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.5.1 -config oapi-codegen.yaml ../../api/openapi.yaml
+
+// WithBearerToken добавляет к каждому запросу заголовок Authorization с токеном
+// (персональный токен pat_... или access токен Keycloak)
+func WithBearerToken(token string) ClientOption {
+	return WithRequestEditorFn(func(_ context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
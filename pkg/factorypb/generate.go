@@ -0,0 +1,5 @@
+// Package factorypb содержит сообщения и gRPC клиент/сервер фабрики.
+package factorypb
+
+// Код генерируется из api/proto с помощью buf, после изменения .proto выполните go generate (или make proto)
+//go:generate sh -c "cd ../.. && buf generate"
@@ -0,0 +1,913 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.1
+// 	protoc        (unknown)
+// source: factory/v1/plumbus.proto
+
+package factorypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PlumbusStatus int32
+
+const (
+	PlumbusStatus_PLUMBUS_STATUS_UNSPECIFIED PlumbusStatus = 0
+	PlumbusStatus_PLUMBUS_STATUS_PENDING     PlumbusStatus = 1
+	PlumbusStatus_PLUMBUS_STATUS_GENERATING  PlumbusStatus = 2
+	PlumbusStatus_PLUMBUS_STATUS_COMPLETED   PlumbusStatus = 3
+	PlumbusStatus_PLUMBUS_STATUS_FAILED      PlumbusStatus = 4
+	PlumbusStatus_PLUMBUS_STATUS_CANCELLED   PlumbusStatus = 5
+)
+
+// Enum value maps for PlumbusStatus.
+var (
+	PlumbusStatus_name = map[int32]string{
+		0: "PLUMBUS_STATUS_UNSPECIFIED",
+		1: "PLUMBUS_STATUS_PENDING",
+		2: "PLUMBUS_STATUS_GENERATING",
+		3: "PLUMBUS_STATUS_COMPLETED",
+		4: "PLUMBUS_STATUS_FAILED",
+		5: "PLUMBUS_STATUS_CANCELLED",
+	}
+	PlumbusStatus_value = map[string]int32{
+		"PLUMBUS_STATUS_UNSPECIFIED": 0,
+		"PLUMBUS_STATUS_PENDING":     1,
+		"PLUMBUS_STATUS_GENERATING":  2,
+		"PLUMBUS_STATUS_COMPLETED":   3,
+		"PLUMBUS_STATUS_FAILED":      4,
+		"PLUMBUS_STATUS_CANCELLED":   5,
+	}
+)
+
+func (x PlumbusStatus) Enum() *PlumbusStatus {
+	p := new(PlumbusStatus)
+	*p = x
+	return p
+}
+
+func (x PlumbusStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PlumbusStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_factory_v1_plumbus_proto_enumTypes[0].Descriptor()
+}
+
+func (PlumbusStatus) Type() protoreflect.EnumType {
+	return &file_factory_v1_plumbus_proto_enumTypes[0]
+}
+
+func (x PlumbusStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PlumbusStatus.Descriptor instead.
+func (PlumbusStatus) EnumDescriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{0}
+}
+
+type Plumbus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Size          string                 `protobuf:"bytes,3,opt,name=size,proto3" json:"size,omitempty"`
+	Color         string                 `protobuf:"bytes,4,opt,name=color,proto3" json:"color,omitempty"`
+	Shape         string                 `protobuf:"bytes,5,opt,name=shape,proto3" json:"shape,omitempty"`
+	Weight        string                 `protobuf:"bytes,6,opt,name=weight,proto3" json:"weight,omitempty"`
+	Wrapping      string                 `protobuf:"bytes,7,opt,name=wrapping,proto3" json:"wrapping,omitempty"`
+	Status        PlumbusStatus          `protobuf:"varint,8,opt,name=status,proto3,enum=factory.v1.PlumbusStatus" json:"status,omitempty"`
+	IsRare        bool                   `protobuf:"varint,9,opt,name=is_rare,json=isRare,proto3" json:"is_rare,omitempty"`
+	Signature     string                 `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
+	SignatureDate *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=signature_date,json=signatureDate,proto3" json:"signature_date,omitempty"`
+	Error         string                 `protobuf:"bytes,12,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *Plumbus) Reset() {
+	*x = Plumbus{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plumbus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plumbus) ProtoMessage() {}
+
+func (x *Plumbus) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plumbus.ProtoReflect.Descriptor instead.
+func (*Plumbus) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Plumbus) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Plumbus) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Plumbus) GetSize() string {
+	if x != nil {
+		return x.Size
+	}
+	return ""
+}
+
+func (x *Plumbus) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *Plumbus) GetShape() string {
+	if x != nil {
+		return x.Shape
+	}
+	return ""
+}
+
+func (x *Plumbus) GetWeight() string {
+	if x != nil {
+		return x.Weight
+	}
+	return ""
+}
+
+func (x *Plumbus) GetWrapping() string {
+	if x != nil {
+		return x.Wrapping
+	}
+	return ""
+}
+
+func (x *Plumbus) GetStatus() PlumbusStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PlumbusStatus_PLUMBUS_STATUS_UNSPECIFIED
+}
+
+func (x *Plumbus) GetIsRare() bool {
+	if x != nil {
+		return x.IsRare
+	}
+	return false
+}
+
+func (x *Plumbus) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *Plumbus) GetSignatureDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SignatureDate
+	}
+	return nil
+}
+
+func (x *Plumbus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Plumbus) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Plumbus) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreatePlumbusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Size     string `protobuf:"bytes,2,opt,name=size,proto3" json:"size,omitempty"`
+	Color    string `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"`
+	Shape    string `protobuf:"bytes,4,opt,name=shape,proto3" json:"shape,omitempty"`
+	Weight   string `protobuf:"bytes,5,opt,name=weight,proto3" json:"weight,omitempty"`
+	Wrapping string `protobuf:"bytes,6,opt,name=wrapping,proto3" json:"wrapping,omitempty"`
+}
+
+func (x *CreatePlumbusRequest) Reset() {
+	*x = CreatePlumbusRequest{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePlumbusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePlumbusRequest) ProtoMessage() {}
+
+func (x *CreatePlumbusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePlumbusRequest.ProtoReflect.Descriptor instead.
+func (*CreatePlumbusRequest) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreatePlumbusRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreatePlumbusRequest) GetSize() string {
+	if x != nil {
+		return x.Size
+	}
+	return ""
+}
+
+func (x *CreatePlumbusRequest) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *CreatePlumbusRequest) GetShape() string {
+	if x != nil {
+		return x.Shape
+	}
+	return ""
+}
+
+func (x *CreatePlumbusRequest) GetWeight() string {
+	if x != nil {
+		return x.Weight
+	}
+	return ""
+}
+
+func (x *CreatePlumbusRequest) GetWrapping() string {
+	if x != nil {
+		return x.Wrapping
+	}
+	return ""
+}
+
+type CreatePlumbusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Plumbus *Plumbus `protobuf:"bytes,1,opt,name=plumbus,proto3" json:"plumbus,omitempty"`
+}
+
+func (x *CreatePlumbusResponse) Reset() {
+	*x = CreatePlumbusResponse{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePlumbusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePlumbusResponse) ProtoMessage() {}
+
+func (x *CreatePlumbusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePlumbusResponse.ProtoReflect.Descriptor instead.
+func (*CreatePlumbusResponse) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreatePlumbusResponse) GetPlumbus() *Plumbus {
+	if x != nil {
+		return x.Plumbus
+	}
+	return nil
+}
+
+type GetPlumbusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetPlumbusRequest) Reset() {
+	*x = GetPlumbusRequest{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPlumbusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPlumbusRequest) ProtoMessage() {}
+
+func (x *GetPlumbusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPlumbusRequest.ProtoReflect.Descriptor instead.
+func (*GetPlumbusRequest) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetPlumbusRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetPlumbusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Plumbus *Plumbus `protobuf:"bytes,1,opt,name=plumbus,proto3" json:"plumbus,omitempty"`
+}
+
+func (x *GetPlumbusResponse) Reset() {
+	*x = GetPlumbusResponse{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPlumbusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPlumbusResponse) ProtoMessage() {}
+
+func (x *GetPlumbusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPlumbusResponse.ProtoReflect.Descriptor instead.
+func (*GetPlumbusResponse) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetPlumbusResponse) GetPlumbus() *Plumbus {
+	if x != nil {
+		return x.Plumbus
+	}
+	return nil
+}
+
+type ListPlumbusesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListPlumbusesRequest) Reset() {
+	*x = ListPlumbusesRequest{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPlumbusesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPlumbusesRequest) ProtoMessage() {}
+
+func (x *ListPlumbusesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPlumbusesRequest.ProtoReflect.Descriptor instead.
+func (*ListPlumbusesRequest) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{5}
+}
+
+type ListPlumbusesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Plumbuses []*Plumbus `protobuf:"bytes,1,rep,name=plumbuses,proto3" json:"plumbuses,omitempty"`
+}
+
+func (x *ListPlumbusesResponse) Reset() {
+	*x = ListPlumbusesResponse{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPlumbusesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPlumbusesResponse) ProtoMessage() {}
+
+func (x *ListPlumbusesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPlumbusesResponse.ProtoReflect.Descriptor instead.
+func (*ListPlumbusesResponse) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListPlumbusesResponse) GetPlumbuses() []*Plumbus {
+	if x != nil {
+		return x.Plumbuses
+	}
+	return nil
+}
+
+type WatchPlumbusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *WatchPlumbusRequest) Reset() {
+	*x = WatchPlumbusRequest{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPlumbusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPlumbusRequest) ProtoMessage() {}
+
+func (x *WatchPlumbusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPlumbusRequest.ProtoReflect.Descriptor instead.
+func (*WatchPlumbusRequest) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WatchPlumbusRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type WatchPlumbusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Plumbus *Plumbus `protobuf:"bytes,1,opt,name=plumbus,proto3" json:"plumbus,omitempty"`
+}
+
+func (x *WatchPlumbusResponse) Reset() {
+	*x = WatchPlumbusResponse{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPlumbusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPlumbusResponse) ProtoMessage() {}
+
+func (x *WatchPlumbusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPlumbusResponse.ProtoReflect.Descriptor instead.
+func (*WatchPlumbusResponse) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchPlumbusResponse) GetPlumbus() *Plumbus {
+	if x != nil {
+		return x.Plumbus
+	}
+	return nil
+}
+
+type VerifyPlumbusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *VerifyPlumbusRequest) Reset() {
+	*x = VerifyPlumbusRequest{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyPlumbusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyPlumbusRequest) ProtoMessage() {}
+
+func (x *VerifyPlumbusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyPlumbusRequest.ProtoReflect.Descriptor instead.
+func (*VerifyPlumbusRequest) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *VerifyPlumbusRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type VerifyPlumbusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Valid bool `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+}
+
+func (x *VerifyPlumbusResponse) Reset() {
+	*x = VerifyPlumbusResponse{}
+	mi := &file_factory_v1_plumbus_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyPlumbusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyPlumbusResponse) ProtoMessage() {}
+
+func (x *VerifyPlumbusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_factory_v1_plumbus_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyPlumbusResponse.ProtoReflect.Descriptor instead.
+func (*VerifyPlumbusResponse) Descriptor() ([]byte, []int) {
+	return file_factory_v1_plumbus_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *VerifyPlumbusResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+var File_factory_v1_plumbus_proto protoreflect.FileDescriptor
+
+var file_factory_v1_plumbus_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x2f, 0x76, 0x31, 0x2f, 0x70, 0x6c, 0x75,
+	0x6d, 0x62, 0x75, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x66, 0x61, 0x63, 0x74,
+	0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xda, 0x03, 0x0a, 0x07, 0x50, 0x6c, 0x75, 0x6d,
+	0x62, 0x75, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63,
+	0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f,
+	0x72, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x68, 0x61, 0x70, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x73, 0x68, 0x61, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x77, 0x72, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x77, 0x72, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x12, 0x31, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x66, 0x61,
+	0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x17,
+	0x0a, 0x07, 0x69, 0x73, 0x5f, 0x72, 0x61, 0x72, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x06, 0x69, 0x73, 0x52, 0x61, 0x72, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x41, 0x0a, 0x0e, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0d, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x44, 0x61, 0x74, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x39,
+	0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x64, 0x41, 0x74, 0x22, 0x9e, 0x01, 0x0a, 0x14, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x50,
+	0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x68, 0x61, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x68, 0x61, 0x70,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x77, 0x72, 0x61,
+	0x70, 0x70, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x77, 0x72, 0x61,
+	0x70, 0x70, 0x69, 0x6e, 0x67, 0x22, 0x46, 0x0a, 0x15, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x50,
+	0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d,
+	0x0a, 0x07, 0x70, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c, 0x75,
+	0x6d, 0x62, 0x75, 0x73, 0x52, 0x07, 0x70, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x22, 0x23, 0x0a,
+	0x11, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
+	0x69, 0x64, 0x22, 0x43, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d, 0x0a, 0x07, 0x70, 0x6c, 0x75, 0x6d,
+	0x62, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x66, 0x61, 0x63, 0x74,
+	0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x07,
+	0x70, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x22, 0x16, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x50,
+	0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
+	0x4a, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x65, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x09, 0x70, 0x6c, 0x75, 0x6d,
+	0x62, 0x75, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x66, 0x61,
+	0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73,
+	0x52, 0x09, 0x70, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x65, 0x73, 0x22, 0x25, 0x0a, 0x13, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
+	0x69, 0x64, 0x22, 0x45, 0x0a, 0x14, 0x57, 0x61, 0x74, 0x63, 0x68, 0x50, 0x6c, 0x75, 0x6d, 0x62,
+	0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d, 0x0a, 0x07, 0x70, 0x6c,
+	0x75, 0x6d, 0x62, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x66, 0x61,
+	0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73,
+	0x52, 0x07, 0x70, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x22, 0x26, 0x0a, 0x14, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x79, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x22, 0x2d, 0x0a, 0x15, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x50, 0x6c, 0x75, 0x6d, 0x62,
+	0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64,
+	0x2a, 0xc1, 0x01, 0x0a, 0x0d, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x1e, 0x0a, 0x1a, 0x50, 0x4c, 0x55, 0x4d, 0x42, 0x55, 0x53, 0x5f, 0x53, 0x54,
+	0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x1a, 0x0a, 0x16, 0x50, 0x4c, 0x55, 0x4d, 0x42, 0x55, 0x53, 0x5f, 0x53, 0x54,
+	0x41, 0x54, 0x55, 0x53, 0x5f, 0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x1d,
+	0x0a, 0x19, 0x50, 0x4c, 0x55, 0x4d, 0x42, 0x55, 0x53, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
+	0x5f, 0x47, 0x45, 0x4e, 0x45, 0x52, 0x41, 0x54, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x1c, 0x0a,
+	0x18, 0x50, 0x4c, 0x55, 0x4d, 0x42, 0x55, 0x53, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f,
+	0x43, 0x4f, 0x4d, 0x50, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x03, 0x12, 0x19, 0x0a, 0x15, 0x50,
+	0x4c, 0x55, 0x4d, 0x42, 0x55, 0x53, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x46, 0x41,
+	0x49, 0x4c, 0x45, 0x44, 0x10, 0x04, 0x12, 0x1c, 0x0a, 0x18, 0x50, 0x4c, 0x55, 0x4d, 0x42, 0x55,
+	0x53, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x43, 0x41, 0x4e, 0x43, 0x45, 0x4c, 0x4c,
+	0x45, 0x44, 0x10, 0x05, 0x32, 0xb4, 0x03, 0x0a, 0x0e, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x54, 0x0a, 0x0d, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x12, 0x20, 0x2e, 0x66, 0x61, 0x63, 0x74, 0x6f,
+	0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x50, 0x6c, 0x75, 0x6d,
+	0x62, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x66, 0x61, 0x63,
+	0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x50, 0x6c,
+	0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4b, 0x0a,
+	0x0a, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x12, 0x1d, 0x2e, 0x66, 0x61,
+	0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x75, 0x6d,
+	0x62, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x66, 0x61, 0x63,
+	0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x75, 0x6d, 0x62,
+	0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x54, 0x0a, 0x0d, 0x4c, 0x69,
+	0x73, 0x74, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x65, 0x73, 0x12, 0x20, 0x2e, 0x66, 0x61,
+	0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x6c, 0x75,
+	0x6d, 0x62, 0x75, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e,
+	0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50,
+	0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x53, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73,
+	0x12, 0x1f, 0x2e, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x20, 0x2e, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x54, 0x0a, 0x0d, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x50,
+	0x6c, 0x75, 0x6d, 0x62, 0x75, 0x73, 0x12, 0x20, 0x2e, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x79,
+	0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x50, 0x6c, 0x75, 0x6d, 0x62, 0x75,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x66, 0x61, 0x63, 0x74, 0x6f,
+	0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x50, 0x6c, 0x75, 0x6d,
+	0x62, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x21, 0x5a, 0x1f, 0x66,
+	0x61, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x66, 0x61, 0x63, 0x74, 0x6f,
+	0x72, 0x79, 0x70, 0x62, 0x3b, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x70, 0x62, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_factory_v1_plumbus_proto_rawDescOnce sync.Once
+	file_factory_v1_plumbus_proto_rawDescData = file_factory_v1_plumbus_proto_rawDesc
+)
+
+func file_factory_v1_plumbus_proto_rawDescGZIP() []byte {
+	file_factory_v1_plumbus_proto_rawDescOnce.Do(func() {
+		file_factory_v1_plumbus_proto_rawDescData = protoimpl.X.CompressGZIP(file_factory_v1_plumbus_proto_rawDescData)
+	})
+	return file_factory_v1_plumbus_proto_rawDescData
+}
+
+var file_factory_v1_plumbus_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_factory_v1_plumbus_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_factory_v1_plumbus_proto_goTypes = []any{
+	(PlumbusStatus)(0),            // 0: factory.v1.PlumbusStatus
+	(*Plumbus)(nil),               // 1: factory.v1.Plumbus
+	(*CreatePlumbusRequest)(nil),  // 2: factory.v1.CreatePlumbusRequest
+	(*CreatePlumbusResponse)(nil), // 3: factory.v1.CreatePlumbusResponse
+	(*GetPlumbusRequest)(nil),     // 4: factory.v1.GetPlumbusRequest
+	(*GetPlumbusResponse)(nil),    // 5: factory.v1.GetPlumbusResponse
+	(*ListPlumbusesRequest)(nil),  // 6: factory.v1.ListPlumbusesRequest
+	(*ListPlumbusesResponse)(nil), // 7: factory.v1.ListPlumbusesResponse
+	(*WatchPlumbusRequest)(nil),   // 8: factory.v1.WatchPlumbusRequest
+	(*WatchPlumbusResponse)(nil),  // 9: factory.v1.WatchPlumbusResponse
+	(*VerifyPlumbusRequest)(nil),  // 10: factory.v1.VerifyPlumbusRequest
+	(*VerifyPlumbusResponse)(nil), // 11: factory.v1.VerifyPlumbusResponse
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+}
+var file_factory_v1_plumbus_proto_depIdxs = []int32{
+	0,  // 0: factory.v1.Plumbus.status:type_name -> factory.v1.PlumbusStatus
+	12, // 1: factory.v1.Plumbus.signature_date:type_name -> google.protobuf.Timestamp
+	12, // 2: factory.v1.Plumbus.created_at:type_name -> google.protobuf.Timestamp
+	12, // 3: factory.v1.Plumbus.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 4: factory.v1.CreatePlumbusResponse.plumbus:type_name -> factory.v1.Plumbus
+	1,  // 5: factory.v1.GetPlumbusResponse.plumbus:type_name -> factory.v1.Plumbus
+	1,  // 6: factory.v1.ListPlumbusesResponse.plumbuses:type_name -> factory.v1.Plumbus
+	1,  // 7: factory.v1.WatchPlumbusResponse.plumbus:type_name -> factory.v1.Plumbus
+	2,  // 8: factory.v1.PlumbusService.CreatePlumbus:input_type -> factory.v1.CreatePlumbusRequest
+	4,  // 9: factory.v1.PlumbusService.GetPlumbus:input_type -> factory.v1.GetPlumbusRequest
+	6,  // 10: factory.v1.PlumbusService.ListPlumbuses:input_type -> factory.v1.ListPlumbusesRequest
+	8,  // 11: factory.v1.PlumbusService.WatchPlumbus:input_type -> factory.v1.WatchPlumbusRequest
+	10, // 12: factory.v1.PlumbusService.VerifyPlumbus:input_type -> factory.v1.VerifyPlumbusRequest
+	3,  // 13: factory.v1.PlumbusService.CreatePlumbus:output_type -> factory.v1.CreatePlumbusResponse
+	5,  // 14: factory.v1.PlumbusService.GetPlumbus:output_type -> factory.v1.GetPlumbusResponse
+	7,  // 15: factory.v1.PlumbusService.ListPlumbuses:output_type -> factory.v1.ListPlumbusesResponse
+	9,  // 16: factory.v1.PlumbusService.WatchPlumbus:output_type -> factory.v1.WatchPlumbusResponse
+	11, // 17: factory.v1.PlumbusService.VerifyPlumbus:output_type -> factory.v1.VerifyPlumbusResponse
+	13, // [13:18] is the sub-list for method output_type
+	8,  // [8:13] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_factory_v1_plumbus_proto_init() }
+func file_factory_v1_plumbus_proto_init() {
+	if File_factory_v1_plumbus_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_factory_v1_plumbus_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_factory_v1_plumbus_proto_goTypes,
+		DependencyIndexes: file_factory_v1_plumbus_proto_depIdxs,
+		EnumInfos:         file_factory_v1_plumbus_proto_enumTypes,
+		MessageInfos:      file_factory_v1_plumbus_proto_msgTypes,
+	}.Build()
+	File_factory_v1_plumbus_proto = out.File
+	file_factory_v1_plumbus_proto_rawDesc = nil
+	file_factory_v1_plumbus_proto_goTypes = nil
+	file_factory_v1_plumbus_proto_depIdxs = nil
+}
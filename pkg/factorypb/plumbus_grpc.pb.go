@@ -0,0 +1,295 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: factory/v1/plumbus.proto
+
+package factorypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PlumbusService_CreatePlumbus_FullMethodName = "/factory.v1.PlumbusService/CreatePlumbus"
+	PlumbusService_GetPlumbus_FullMethodName    = "/factory.v1.PlumbusService/GetPlumbus"
+	PlumbusService_ListPlumbuses_FullMethodName = "/factory.v1.PlumbusService/ListPlumbuses"
+	PlumbusService_WatchPlumbus_FullMethodName  = "/factory.v1.PlumbusService/WatchPlumbus"
+	PlumbusService_VerifyPlumbus_FullMethodName = "/factory.v1.PlumbusService/VerifyPlumbus"
+)
+
+// PlumbusServiceClient is the client API for PlumbusService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PlumbusService - gRPC API фабрики плюмбусов. Аутентификация - access токен
+// Keycloak в метаданных запроса: "authorization: Bearer <token>".
+type PlumbusServiceClient interface {
+	// CreatePlumbus создает плюмбус и запускает его генерацию (роль operator)
+	CreatePlumbus(ctx context.Context, in *CreatePlumbusRequest, opts ...grpc.CallOption) (*CreatePlumbusResponse, error)
+	// GetPlumbus возвращает плюмбус текущего пользователя
+	GetPlumbus(ctx context.Context, in *GetPlumbusRequest, opts ...grpc.CallOption) (*GetPlumbusResponse, error)
+	// ListPlumbuses возвращает плюмбусы текущего пользователя
+	ListPlumbuses(ctx context.Context, in *ListPlumbusesRequest, opts ...grpc.CallOption) (*ListPlumbusesResponse, error)
+	// WatchPlumbus отправляет плюмбус при каждом изменении статуса,
+	// поток завершается после статуса COMPLETED, FAILED или CANCELLED
+	WatchPlumbus(ctx context.Context, in *WatchPlumbusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchPlumbusResponse], error)
+	// VerifyPlumbus проверяет подпись изображения плюмбуса в sig-store
+	VerifyPlumbus(ctx context.Context, in *VerifyPlumbusRequest, opts ...grpc.CallOption) (*VerifyPlumbusResponse, error)
+}
+
+type plumbusServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPlumbusServiceClient(cc grpc.ClientConnInterface) PlumbusServiceClient {
+	return &plumbusServiceClient{cc}
+}
+
+func (c *plumbusServiceClient) CreatePlumbus(ctx context.Context, in *CreatePlumbusRequest, opts ...grpc.CallOption) (*CreatePlumbusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreatePlumbusResponse)
+	err := c.cc.Invoke(ctx, PlumbusService_CreatePlumbus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plumbusServiceClient) GetPlumbus(ctx context.Context, in *GetPlumbusRequest, opts ...grpc.CallOption) (*GetPlumbusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPlumbusResponse)
+	err := c.cc.Invoke(ctx, PlumbusService_GetPlumbus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plumbusServiceClient) ListPlumbuses(ctx context.Context, in *ListPlumbusesRequest, opts ...grpc.CallOption) (*ListPlumbusesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPlumbusesResponse)
+	err := c.cc.Invoke(ctx, PlumbusService_ListPlumbuses_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *plumbusServiceClient) WatchPlumbus(ctx context.Context, in *WatchPlumbusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchPlumbusResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PlumbusService_ServiceDesc.Streams[0], PlumbusService_WatchPlumbus_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchPlumbusRequest, WatchPlumbusResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PlumbusService_WatchPlumbusClient = grpc.ServerStreamingClient[WatchPlumbusResponse]
+
+func (c *plumbusServiceClient) VerifyPlumbus(ctx context.Context, in *VerifyPlumbusRequest, opts ...grpc.CallOption) (*VerifyPlumbusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyPlumbusResponse)
+	err := c.cc.Invoke(ctx, PlumbusService_VerifyPlumbus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PlumbusServiceServer is the server API for PlumbusService service.
+// All implementations must embed UnimplementedPlumbusServiceServer
+// for forward compatibility.
+//
+// PlumbusService - gRPC API фабрики плюмбусов. Аутентификация - access токен
+// Keycloak в метаданных запроса: "authorization: Bearer <token>".
+type PlumbusServiceServer interface {
+	// CreatePlumbus создает плюмбус и запускает его генерацию (роль operator)
+	CreatePlumbus(context.Context, *CreatePlumbusRequest) (*CreatePlumbusResponse, error)
+	// GetPlumbus возвращает плюмбус текущего пользователя
+	GetPlumbus(context.Context, *GetPlumbusRequest) (*GetPlumbusResponse, error)
+	// ListPlumbuses возвращает плюмбусы текущего пользователя
+	ListPlumbuses(context.Context, *ListPlumbusesRequest) (*ListPlumbusesResponse, error)
+	// WatchPlumbus отправляет плюмбус при каждом изменении статуса,
+	// поток завершается после статуса COMPLETED, FAILED или CANCELLED
+	WatchPlumbus(*WatchPlumbusRequest, grpc.ServerStreamingServer[WatchPlumbusResponse]) error
+	// VerifyPlumbus проверяет подпись изображения плюмбуса в sig-store
+	VerifyPlumbus(context.Context, *VerifyPlumbusRequest) (*VerifyPlumbusResponse, error)
+	mustEmbedUnimplementedPlumbusServiceServer()
+}
+
+// UnimplementedPlumbusServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPlumbusServiceServer struct{}
+
+func (UnimplementedPlumbusServiceServer) CreatePlumbus(context.Context, *CreatePlumbusRequest) (*CreatePlumbusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePlumbus not implemented")
+}
+func (UnimplementedPlumbusServiceServer) GetPlumbus(context.Context, *GetPlumbusRequest) (*GetPlumbusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlumbus not implemented")
+}
+func (UnimplementedPlumbusServiceServer) ListPlumbuses(context.Context, *ListPlumbusesRequest) (*ListPlumbusesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPlumbuses not implemented")
+}
+func (UnimplementedPlumbusServiceServer) WatchPlumbus(*WatchPlumbusRequest, grpc.ServerStreamingServer[WatchPlumbusResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPlumbus not implemented")
+}
+func (UnimplementedPlumbusServiceServer) VerifyPlumbus(context.Context, *VerifyPlumbusRequest) (*VerifyPlumbusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyPlumbus not implemented")
+}
+func (UnimplementedPlumbusServiceServer) mustEmbedUnimplementedPlumbusServiceServer() {}
+func (UnimplementedPlumbusServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafePlumbusServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PlumbusServiceServer will
+// result in compilation errors.
+type UnsafePlumbusServiceServer interface {
+	mustEmbedUnimplementedPlumbusServiceServer()
+}
+
+func RegisterPlumbusServiceServer(s grpc.ServiceRegistrar, srv PlumbusServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPlumbusServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PlumbusService_ServiceDesc, srv)
+}
+
+func _PlumbusService_CreatePlumbus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePlumbusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlumbusServiceServer).CreatePlumbus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlumbusService_CreatePlumbus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlumbusServiceServer).CreatePlumbus(ctx, req.(*CreatePlumbusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlumbusService_GetPlumbus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlumbusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlumbusServiceServer).GetPlumbus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlumbusService_GetPlumbus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlumbusServiceServer).GetPlumbus(ctx, req.(*GetPlumbusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlumbusService_ListPlumbuses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPlumbusesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlumbusServiceServer).ListPlumbuses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlumbusService_ListPlumbuses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlumbusServiceServer).ListPlumbuses(ctx, req.(*ListPlumbusesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlumbusService_WatchPlumbus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPlumbusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PlumbusServiceServer).WatchPlumbus(m, &grpc.GenericServerStream[WatchPlumbusRequest, WatchPlumbusResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PlumbusService_WatchPlumbusServer = grpc.ServerStreamingServer[WatchPlumbusResponse]
+
+func _PlumbusService_VerifyPlumbus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyPlumbusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlumbusServiceServer).VerifyPlumbus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PlumbusService_VerifyPlumbus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlumbusServiceServer).VerifyPlumbus(ctx, req.(*VerifyPlumbusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PlumbusService_ServiceDesc is the grpc.ServiceDesc for PlumbusService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PlumbusService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "factory.v1.PlumbusService",
+	HandlerType: (*PlumbusServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePlumbus",
+			Handler:    _PlumbusService_CreatePlumbus_Handler,
+		},
+		{
+			MethodName: "GetPlumbus",
+			Handler:    _PlumbusService_GetPlumbus_Handler,
+		},
+		{
+			MethodName: "ListPlumbuses",
+			Handler:    _PlumbusService_ListPlumbuses_Handler,
+		},
+		{
+			MethodName: "VerifyPlumbus",
+			Handler:    _PlumbusService_VerifyPlumbus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPlumbus",
+			Handler:       _PlumbusService_WatchPlumbus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "factory/v1/plumbus.proto",
+}
@@ -0,0 +1,28 @@
+// Действия администратора над плюмбусами любых пользователей
+document.addEventListener('DOMContentLoaded', function() {
+    const requests = {
+        retry: function(id) { return fetch('/plumbus/' + id + '/retry', { method: 'POST' }); },
+        cancel: function(id) { return fetch('/plumbus/' + id + '/cancel', { method: 'POST' }); },
+        delete: function(id) { return fetch('/plumbus/' + id, { method: 'DELETE' }); },
+        restore: function(id) { return fetch('/plumbus/' + id + '/restore', { method: 'POST' }); }
+    };
+
+    document.querySelectorAll('.btn-admin-action').forEach(function(button) {
+        button.addEventListener('click', async function() {
+            const action = button.dataset.action;
+            if (action === 'delete' && !confirm('Удалить плюмбус?')) {
+                return;
+            }
+
+            button.disabled = true;
+            const response = await requests[action](button.dataset.plumbusId);
+            if (!response.ok) {
+                const body = await response.json().catch(function() { return {}; });
+                alert('Не удалось выполнить действие: ' + (body.error || response.status));
+                button.disabled = false;
+                return;
+            }
+            window.location.reload();
+        });
+    });
+});
@@ -6,8 +6,8 @@ document.addEventListener('DOMContentLoaded', function() {
     const progressText = document.querySelector('.progress-text');
     const plumbusGrid = document.getElementById('plumbus-grid');
 
-    // Form submission
-    form.addEventListener('submit', async function(e) {
+    // Form submission (форма отсутствует у пользователей без роли factory-operator)
+    if (form) form.addEventListener('submit', async function(e) {
         e.preventDefault();
         
         const formData = new FormData(form);
@@ -48,7 +48,11 @@ document.addEventListener('DOMContentLoaded', function() {
             }
 
             const result = await response.json();
-            
+
+            // Генерацию можно отменить, пока она не завершилась
+            cancelButton.dataset.plumbusId = result.id;
+            cancelButton.style.display = 'inline-block';
+
             // Monitor progress
             monitorPlumbusGeneration(result.id);
             
@@ -116,6 +120,12 @@ document.addEventListener('DOMContentLoaded', function() {
                     return;
                 }
 
+                if (status.status === 'cancelled') {
+                    showNotification('Генерация отменена', 'info');
+                    resetForm();
+                    return;
+                }
+
                 // Continue monitoring
                 attempts++;
                 if (attempts < maxAttempts) {
@@ -215,6 +225,8 @@ document.addEventListener('DOMContentLoaded', function() {
         }
         
         // Reset UI
+        cancelButton.style.display = 'none';
+        delete cancelButton.dataset.plumbusId;
         form.style.display = 'block';
         progressContainer.style.display = 'none';
         form.reset();
@@ -311,6 +323,39 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     });
 
+    // Повтор неудавшейся генерации
+    document.addEventListener('click', async function(e) {
+        const button = e.target.closest('.btn-plumbus-retry');
+        if (!button) {
+            return;
+        }
+
+        const plumbusId = button.dataset.plumbusId;
+        const card = button.closest('.plumbus-card');
+        button.disabled = true;
+
+        try {
+            const response = await fetch(`/plumbus/${plumbusId}/retry`, { method: 'POST' });
+            const result = await response.json();
+            if (!response.ok) {
+                throw new Error(result.error || 'Ошибка при повторе генерации');
+            }
+
+            const statusSpan = card.querySelector('.status');
+            card.dataset.status = 'generating';
+            statusSpan.className = 'status status-generating';
+            statusSpan.textContent = 'generating';
+            card.querySelector('.card-content').innerHTML = '<div class="generating-animation"></div>';
+
+            showNotification(`Генерация запущена повторно (попытка ${result.attempts})`, 'info');
+            monitorExistingPlumbus(plumbusId, card);
+        } catch (error) {
+            console.error('Error:', error);
+            showNotification(error.message, 'error');
+            button.disabled = false;
+        }
+    });
+
     // Update existing cards with generating status
     const generatingCards = document.querySelectorAll('[data-status="generating"]');
     generatingCards.forEach(card => {
@@ -353,6 +398,11 @@ document.addEventListener('DOMContentLoaded', function() {
                     return;
                 }
 
+                if (status.status === 'cancelled') {
+                    updateCardToCancelled(cardElement);
+                    return;
+                }
+
                 attempts++;
                 if (attempts < maxAttempts) {
                     setTimeout(checkStatus, 5000);
@@ -433,6 +483,56 @@ document.addEventListener('DOMContentLoaded', function() {
         cardContent.innerHTML = '<div class="error-message">Ошибка генерации</div>';
     }
 
+    // Update card to cancelled status
+    function updateCardToCancelled(cardElement) {
+        const statusSpan = cardElement.querySelector('.status');
+        const cardContent = cardElement.querySelector('.card-content');
+
+        cardElement.dataset.status = 'cancelled';
+        statusSpan.className = 'status status-cancelled';
+        statusSpan.textContent = 'cancelled';
+
+        cardContent.innerHTML = '<div class="pending-message">Генерация отменена</div>';
+    }
+
+    // cancelGeneration отменяет генерацию плюмбуса на сервере
+    async function cancelGeneration(plumbusId) {
+        const response = await fetch(`/plumbus/${plumbusId}/cancel`, { method: 'POST' });
+        if (!response.ok) {
+            const result = await response.json();
+            throw new Error(result.error || 'Ошибка при отмене генерации');
+        }
+    }
+
+    const cancelButton = document.getElementById('cancel-generation');
+    if (cancelButton) {
+        cancelButton.addEventListener('click', async function() {
+            cancelButton.disabled = true;
+            try {
+                await cancelGeneration(cancelButton.dataset.plumbusId);
+            } catch (error) {
+                showNotification(error.message, 'error');
+            }
+            cancelButton.disabled = false;
+        });
+    }
+
+    document.addEventListener('click', async function(e) {
+        const button = e.target.closest('.btn-plumbus-cancel');
+        if (!button) {
+            return;
+        }
+
+        button.disabled = true;
+        try {
+            await cancelGeneration(button.dataset.plumbusId);
+            updateCardToCancelled(button.closest('.plumbus-card'));
+        } catch (error) {
+            showNotification(error.message, 'error');
+            button.disabled = false;
+        }
+    });
+
     // Add completion glow animation
     const glowStyle = document.createElement('style');
     glowStyle.textContent = `
@@ -0,0 +1,56 @@
+// Удаление плюмбусов с возможностью отмены
+document.addEventListener('DOMContentLoaded', function() {
+    let undoBar = null;
+
+    document.querySelectorAll('.btn-plumbus-delete').forEach(function(button) {
+        button.addEventListener('click', async function() {
+            const plumbusId = button.dataset.plumbusId;
+            const card = button.closest('.plumbus-card');
+
+            const response = await fetch('/plumbus/' + plumbusId, { method: 'DELETE' });
+            if (!response.ok) {
+                alert('Не удалось удалить плюмбус');
+                return;
+            }
+
+            const result = await response.json();
+            card.style.display = 'none';
+            showUndo(plumbusId, card, new Date(result.restore_until));
+        });
+    });
+
+    // showUndo показывает панель отмены удаления до конца окна восстановления (не дольше 10 секунд)
+    function showUndo(plumbusId, card, restoreUntil) {
+        if (undoBar) {
+            undoBar.remove();
+        }
+
+        undoBar = document.createElement('div');
+        undoBar.className = 'undo-bar';
+
+        const text = document.createElement('span');
+        text.textContent = 'Плюмбус удален. Восстановить можно до ' + restoreUntil.toLocaleString();
+        const undo = document.createElement('button');
+        undo.className = 'btn btn-secondary btn-revoke';
+        undo.textContent = 'Отменить';
+
+        const bar = undoBar;
+        undo.addEventListener('click', async function() {
+            const response = await fetch('/plumbus/' + plumbusId + '/restore', { method: 'POST' });
+            if (response.ok) {
+                card.style.display = '';
+            } else {
+                alert('Не удалось восстановить плюмбус');
+            }
+            bar.remove();
+        });
+
+        undoBar.appendChild(text);
+        undoBar.appendChild(undo);
+        document.body.appendChild(undoBar);
+
+        setTimeout(function() {
+            bar.remove();
+        }, 10000);
+    }
+});
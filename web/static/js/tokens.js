@@ -0,0 +1,58 @@
+// Управление персональными токенами доступа к API
+document.addEventListener('DOMContentLoaded', function() {
+    const form = document.getElementById('token-form');
+    const created = document.getElementById('token-created');
+    const value = document.getElementById('token-value');
+
+    // Форма отсутствует у пользователей без ролей фабрики
+    if (form) form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const formData = new FormData(form);
+        const request = {
+            name: formData.get('name'),
+            scopes: formData.getAll('scopes'),
+            expires_in_days: parseInt(formData.get('expires_in_days'), 10)
+        };
+
+        if (request.scopes.length === 0) {
+            alert('Выберите хотя бы одно разрешение');
+            return;
+        }
+
+        try {
+            const response = await fetch('/tokens', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(request)
+            });
+            const result = await response.json();
+            if (!response.ok) {
+                throw new Error(result.error || 'Ошибка при создании токена');
+            }
+
+            // Открытое значение токена доступно только в этом ответе
+            value.textContent = result.token;
+            created.style.display = 'block';
+            form.reset();
+        } catch (error) {
+            console.error('Error:', error);
+            alert('Ошибка при создании токена: ' + error.message);
+        }
+    });
+
+    document.querySelectorAll('.btn-revoke').forEach(function(button) {
+        button.addEventListener('click', async function() {
+            if (!confirm('Отозвать токен? Автоматизация, использующая его, перестанет работать.')) {
+                return;
+            }
+
+            const response = await fetch('/tokens/' + button.dataset.tokenId, { method: 'DELETE' });
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                alert('Не удалось отозвать токен');
+            }
+        });
+    });
+});
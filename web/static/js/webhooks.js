@@ -0,0 +1,102 @@
+// Управление webhook о завершении генерации
+document.addEventListener('DOMContentLoaded', function() {
+    const form = document.getElementById('webhook-form');
+    const created = document.getElementById('webhook-created');
+    const secret = document.getElementById('webhook-secret');
+    const deliveries = document.getElementById('webhook-deliveries');
+
+    form.addEventListener('submit', async function(e) {
+        e.preventDefault();
+
+        const formData = new FormData(form);
+        const request = {
+            url: formData.get('url'),
+            events: formData.getAll('events')
+        };
+
+        if (request.events.length === 0) {
+            alert('Выберите хотя бы одно событие');
+            return;
+        }
+
+        try {
+            const response = await fetch('/webhooks', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(request)
+            });
+            const result = await response.json();
+            if (!response.ok) {
+                throw new Error(result.error || 'Ошибка при создании webhook');
+            }
+
+            // Секрет подписи доступен только в этом ответе
+            secret.textContent = result.secret;
+            created.style.display = 'block';
+            form.reset();
+        } catch (error) {
+            console.error('Error:', error);
+            alert('Ошибка при создании webhook: ' + error.message);
+        }
+    });
+
+    document.querySelectorAll('.btn-webhook-delete').forEach(function(button) {
+        button.addEventListener('click', async function() {
+            if (!confirm('Удалить webhook вместе с журналом доставок?')) {
+                return;
+            }
+
+            const response = await fetch('/webhooks/' + button.dataset.webhookId, { method: 'DELETE' });
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                alert('Не удалось удалить webhook');
+            }
+        });
+    });
+
+    document.querySelectorAll('.btn-webhook-enable').forEach(function(button) {
+        button.addEventListener('click', async function() {
+            const response = await fetch('/webhooks/' + button.dataset.webhookId + '/enable', { method: 'POST' });
+            if (response.ok) {
+                window.location.reload();
+            } else {
+                alert('Не удалось включить webhook');
+            }
+        });
+    });
+
+    document.querySelectorAll('.btn-webhook-deliveries').forEach(function(button) {
+        button.addEventListener('click', async function() {
+            const response = await fetch('/webhooks/' + button.dataset.webhookId + '/deliveries');
+            if (!response.ok) {
+                alert('Не удалось загрузить журнал доставок');
+                return;
+            }
+
+            const body = deliveries.querySelector('tbody');
+            body.innerHTML = '';
+            const items = await response.json();
+            items.forEach(function(delivery) {
+                const row = document.createElement('tr');
+                if (!delivery.success) row.className = 'token-revoked';
+                [
+                    new Date(delivery.created_at).toLocaleString('ru-RU'),
+                    delivery.event,
+                    delivery.attempt,
+                    delivery.status_code ? delivery.status_code : (delivery.error || '—'),
+                    delivery.duration_ms + ' мс'
+                ].forEach(function(value) {
+                    const cell = document.createElement('td');
+                    cell.textContent = value;
+                    row.appendChild(cell);
+                });
+                body.appendChild(row);
+            });
+            if (items.length === 0) {
+                body.innerHTML = '<tr><td colspan="5">Доставок пока не было</td></tr>';
+            }
+            deliveries.style.display = 'table';
+        });
+    });
+});